@@ -39,7 +39,7 @@ func main() {
 		os.Exit(128)
 	}
 
-	runner := command.NewRunner(repo)
+	runner := command.NewRunner(repo, os.Stdin)
 
 	osExit, err := runner.RunCmd(ctx, cmd, os.Args[2:], os.Stdout)
 	if err != nil {
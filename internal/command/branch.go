@@ -0,0 +1,356 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/repository"
+	"github.com/LukasJenicek/ggit/internal/revision"
+)
+
+var (
+	// errBranchNotDeleted is returned when at least one of the branches given to -d could not be deleted.
+	errBranchNotDeleted = errors.New("branch not deleted")
+	// errNoCurrentBranch is returned when the current branch is renamed with detached HEAD.
+	errNoCurrentBranch = errors.New("cannot rename the current branch while not on any")
+)
+
+type BranchOptions struct {
+	// List branches, arguments are treated as patterns.
+	List bool
+	// Delete given branches.
+	Delete bool
+	// Delete even branches not merged into HEAD.
+	Force bool
+	// Rename branch.
+	Move bool
+	// Committer recorded in reflog of created and renamed branches.
+	Committer repository.Signature
+}
+
+// BranchCommand lists, creates, deletes and renames branches.
+type BranchCommand struct {
+	repository *repository.Repository
+	args       []string
+	options    BranchOptions
+
+	failed string
+}
+
+func NewBranchCommand(repo *repository.Repository, args []string, options BranchOptions) (*BranchCommand, error) {
+	if repo == nil {
+		return nil, errors.New("repository is nil")
+	}
+
+	if options.Delete && len(args) == 0 {
+		return nil, errors.New("branch name required")
+	}
+
+	if options.Move && (len(args) == 0 || len(args) > 2) {
+		return nil, errors.New("branch rename requires one or two branch names")
+	}
+
+	if !options.List && !options.Delete && !options.Move && len(args) > 2 {
+		return nil, errors.New("too many arguments")
+	}
+
+	return &BranchCommand{
+		repository: repo,
+		args:       args,
+		options:    options,
+	}, nil
+}
+
+func (b *BranchCommand) Run() ([]byte, error) {
+	switch {
+	case b.options.Delete:
+		return b.delete()
+	case b.options.Move:
+		return nil, b.move()
+	case b.options.List || len(b.args) == 0:
+		return b.list()
+	default:
+		return nil, b.create()
+	}
+}
+
+func (b *BranchCommand) list() ([]byte, error) {
+	current, err := b.repository.Refs.CurrentRef()
+	if err != nil {
+		return nil, fmt.Errorf("read current ref: %w", err)
+	}
+
+	refs, err := b.repository.Refs.ListRefs(database.HeadsDir)
+	if err != nil {
+		return nil, fmt.Errorf("list branches: %w", err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	if current == database.HEAD && len(b.args) == 0 {
+		description, err := headDescription(b.repository)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.WriteString("* (" + description + ")\n")
+	}
+
+	for _, ref := range refs {
+		name := ref.ShortName()
+
+		if !b.matches(name) {
+			continue
+		}
+
+		marker := "  "
+		if name == current {
+			marker = "* "
+		}
+
+		buf.WriteString(marker + name + "\n")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (b *BranchCommand) matches(name string) bool {
+	if len(b.args) == 0 {
+		return true
+	}
+
+	for _, pattern := range b.args {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (b *BranchCommand) create() error {
+	name := b.args[0]
+
+	start := "HEAD"
+	if len(b.args) == 2 {
+		start = b.args[1]
+	}
+
+	if err := database.CheckBranchName(name); err != nil {
+		b.failed = name
+
+		return err
+	}
+
+	resolver, err := revision.New(b.repository.Database, b.repository.Refs, b.repository.Clock)
+	if err != nil {
+		return fmt.Errorf("init revision resolver: %w", err)
+	}
+
+	oid, err := resolver.Resolve(start)
+	if err == nil {
+		oid, err = resolver.Peel(oid, database.CommitType)
+	}
+
+	if err != nil {
+		if start == "HEAD" {
+			// HEAD of unborn branch is reported by the branch name
+			if current, refErr := b.repository.Refs.CurrentRef(); refErr == nil {
+				start = current
+			}
+		}
+
+		b.failed = start
+
+		return fmt.Errorf("resolve start point %s: %w", start, err)
+	}
+
+	existing, err := b.repository.Refs.ReadRef(database.BranchRef(name))
+	if err != nil {
+		return fmt.Errorf("read branch %s: %w", name, err)
+	}
+
+	if existing != "" {
+		b.failed = name
+
+		return fmt.Errorf("create branch: %w: %s", database.ErrRefExists, name)
+	}
+
+	// branch created meanwhile fails the transaction
+	transaction := b.repository.Refs.Transaction()
+	transaction.Create(database.BranchRef(name), oid)
+
+	identity, err := reflogIdentity(b.repository, b.options.Committer)
+	if err != nil {
+		return err
+	}
+
+	if err := transaction.Commit(identity, "branch: Created from "+start); err != nil {
+		return fmt.Errorf("create branch: %w", err)
+	}
+
+	return nil
+}
+
+func (b *BranchCommand) delete() ([]byte, error) {
+	current, err := b.repository.Refs.CurrentRef()
+	if err != nil {
+		return nil, fmt.Errorf("read current ref: %w", err)
+	}
+
+	head, err := b.repository.Refs.ReadHead()
+	if err != nil {
+		return nil, fmt.Errorf("read head: %w", err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	failed := false
+
+	for _, name := range b.args {
+		if name == current {
+			fmt.Fprintf(buf, "error: cannot delete branch '%s' checked out at '%s'\n", name, b.repository.RootDir)
+
+			failed = true
+
+			continue
+		}
+
+		ref := database.BranchRef(name)
+
+		oid, err := b.repository.Refs.ReadRef(ref)
+		if err != nil {
+			return nil, fmt.Errorf("read branch %s: %w", name, err)
+		}
+
+		if oid == "" {
+			fmt.Fprintf(buf, "error: branch '%s' not found\n", name)
+
+			failed = true
+
+			continue
+		}
+
+		if !b.options.Force {
+			merged, err := b.repository.IsAncestor(oid, head)
+			if err != nil {
+				return nil, fmt.Errorf("check branch %s is merged: %w", name, err)
+			}
+
+			if !merged {
+				fmt.Fprintf(buf, "error: the branch '%s' is not fully merged\n", name)
+				fmt.Fprintf(buf, "hint: If you are sure you want to delete it, run 'ggit branch -D %s'\n", name)
+
+				failed = true
+
+				continue
+			}
+		}
+
+		// branch moved since it was checked to be merged is not deleted
+		transaction := b.repository.Refs.Transaction()
+		transaction.Delete(ref, oid)
+
+		if err := transaction.Commit(nil, ""); err != nil {
+			return nil, fmt.Errorf("delete branch %s: %w", name, err)
+		}
+
+		short, err := b.repository.Database.ShortOID(oid)
+		if err != nil {
+			return nil, fmt.Errorf("abbreviate %s: %w", oid, err)
+		}
+
+		fmt.Fprintf(buf, "Deleted branch %s (was %s).\n", name, short)
+	}
+
+	if failed {
+		return buf.Bytes(), errBranchNotDeleted
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (b *BranchCommand) move() error {
+	oldName := b.args[0]
+	newName := b.args[len(b.args)-1]
+
+	if len(b.args) == 1 {
+		current, err := b.repository.Refs.CurrentRef()
+		if err != nil {
+			return fmt.Errorf("read current ref: %w", err)
+		}
+
+		if current == database.HEAD {
+			return errNoCurrentBranch
+		}
+
+		oldName = current
+	}
+
+	if err := database.CheckBranchName(newName); err != nil {
+		b.failed = newName
+
+		return err
+	}
+
+	oldRef, newRef := database.BranchRef(oldName), database.BranchRef(newName)
+
+	identity, err := reflogIdentity(b.repository, b.options.Committer)
+	if err != nil {
+		return err
+	}
+
+	message := "Branch: renamed " + oldRef + " to " + newRef
+
+	if err := b.repository.Refs.RenameRef(oldRef, newRef, identity, message); err != nil {
+		b.failed = newName
+		if errors.Is(err, database.ErrRefNotFound) {
+			b.failed = oldName
+		}
+
+		return fmt.Errorf("rename branch: %w", err)
+	}
+
+	return nil
+}
+
+func (b *BranchCommand) Output(msg []byte, err error, stdout io.Writer) (int, error) {
+	if err != nil {
+		var (
+			lockErr *database.ErrRefLock
+			dateErr *database.ErrDateFormat
+		)
+
+		switch {
+		case errors.As(err, &lockErr):
+			fmt.Fprintf(stdout, "fatal: %s\n", lockErr.Error())
+		case errors.As(err, &dateErr):
+			fmt.Fprintf(stdout, "fatal: %s\n", dateErr.Error())
+		case errors.Is(err, errBranchNotDeleted):
+			fmt.Fprint(stdout, string(msg))
+
+			return 1, nil
+		case errors.Is(err, errNoCurrentBranch):
+			fmt.Fprintf(stdout, "fatal: %s.\n", errNoCurrentBranch.Error())
+		case errors.Is(err, database.ErrInvalidRefName):
+			fmt.Fprintf(stdout, "fatal: '%s' is not a valid branch name\n", b.failed)
+		case errors.Is(err, database.ErrRefExists):
+			fmt.Fprintf(stdout, "fatal: a branch named '%s' already exists\n", b.failed)
+		case errors.Is(err, database.ErrRefNotFound):
+			fmt.Fprintf(stdout, "fatal: no branch named '%s'\n", b.failed)
+		case errors.Is(err, revision.ErrUnknownRevision) || errors.Is(err, revision.ErrInvalidRevision):
+			fmt.Fprintf(stdout, "fatal: not a valid object name: '%s'\n", b.failed)
+		default:
+			return 1, fmt.Errorf("branch cmd: %w", err)
+		}
+
+		return 128, nil
+	}
+
+	fmt.Fprint(stdout, string(msg))
+
+	return 0, nil
+}
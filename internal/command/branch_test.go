@@ -0,0 +1,211 @@
+package command_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/command"
+	"github.com/LukasJenicek/ggit/internal/repository"
+)
+
+func TestBranch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("create and list", func(t *testing.T) {
+		t.Parallel()
+
+		repo := committedRepository(t)
+
+		head, err := repo.Refs.ReadHead()
+		require.NoError(t, err)
+
+		exit, out := runCmd(t, repo, "branch", "feature/login")
+		require.Equal(t, 0, exit)
+		require.Empty(t, out)
+
+		exit, out = runCmd(t, repo, "branch", "topic", head)
+		require.Equal(t, 0, exit)
+		require.Empty(t, out)
+
+		oid, err := repo.Refs.ReadRef("refs/heads/feature/login")
+		require.NoError(t, err)
+		require.Equal(t, head, oid)
+
+		exit, out = runCmd(t, repo, "branch")
+		require.Equal(t, 0, exit)
+		require.Equal(t, "  feature/login\n* master\n  topic\n", out)
+
+		exit, out = runCmd(t, repo, "branch", "--list", "feature/*", "top*")
+		require.Equal(t, 0, exit)
+		require.Equal(t, "  feature/login\n  topic\n", out)
+	})
+
+	t.Run("create errors", func(t *testing.T) {
+		t.Parallel()
+
+		repo := committedRepository(t)
+
+		exit, out := runCmd(t, repo, "branch", "master")
+		require.Equal(t, 128, exit)
+		require.Equal(t, "fatal: a branch named 'master' already exists\n", out)
+
+		exit, out = runCmd(t, repo, "branch", "bad..name")
+		require.Equal(t, 128, exit)
+		require.Equal(t, "fatal: 'bad..name' is not a valid branch name\n", out)
+
+		exit, out = runCmd(t, repo, "branch", "topic", "missing")
+		require.Equal(t, 128, exit)
+		require.Equal(t, "fatal: not a valid object name: 'missing'\n", out)
+	})
+
+	t.Run("create without commits", func(t *testing.T) {
+		t.Parallel()
+
+		repo := stagedRepository(t)
+
+		exit, out := runCmd(t, repo, "branch", "topic")
+		require.Equal(t, 128, exit)
+		require.Equal(t, "fatal: not a valid object name: 'master'\n", out)
+	})
+
+	t.Run("invalid committer date", func(t *testing.T) {
+		t.Parallel()
+
+		repo := committedRepository(t)
+
+		cmd, err := command.NewBranchCommand(
+			repo,
+			[]string{"topic"},
+			command.BranchOptions{Committer: repository.Signature{Date: "someday"}},
+		)
+		require.NoError(t, err)
+
+		out, err := cmd.Run()
+
+		buf := bytes.NewBuffer(nil)
+		exit, err := cmd.Output(out, err, buf)
+		require.NoError(t, err)
+		require.Equal(t, 128, exit)
+		require.Equal(t, "fatal: invalid date format: someday\n", buf.String())
+
+		oid, err := repo.Refs.ReadRef("topic")
+		require.NoError(t, err)
+		require.Empty(t, oid)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		t.Parallel()
+
+		repo := committedRepository(t)
+
+		head, err := repo.Refs.ReadHead()
+		require.NoError(t, err)
+
+		root, err := repo.Database.LoadCommit(head)
+		require.NoError(t, err)
+
+		unmerged := storeCommit(t, repo, root.RootOID, []string{head}, "unmerged", time.Unix(2000000000, 0))
+		require.NoError(t, repo.Refs.CreateRef("refs/heads/merged", head))
+		require.NoError(t, repo.Refs.CreateRef("refs/heads/unmerged", unmerged))
+
+		exit, out := runCmd(t, repo, "branch", "-d", "merged", "unmerged", "master", "missing")
+		require.Equal(t, 1, exit)
+		require.Equal(t, "Deleted branch merged (was "+head[:7]+").\n"+
+			"error: the branch 'unmerged' is not fully merged\n"+
+			"hint: If you are sure you want to delete it, run 'ggit branch -D unmerged'\n"+
+			"error: cannot delete branch 'master' checked out at 'tmp/test'\n"+
+			"error: branch 'missing' not found\n", out)
+
+		exit, out = runCmd(t, repo, "branch", "-D", "unmerged")
+		require.Equal(t, 0, exit)
+		require.Equal(t, "Deleted branch unmerged (was "+unmerged[:7]+").\n", out)
+
+		exit, out = runCmd(t, repo, "branch")
+		require.Equal(t, 0, exit)
+		require.Equal(t, "* master\n", out)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		t.Parallel()
+
+		repo := committedRepository(t)
+
+		head, err := repo.Refs.ReadHead()
+		require.NoError(t, err)
+
+		require.NoError(t, repo.Refs.CreateRef("refs/heads/topic", head))
+
+		exit, out := runCmd(t, repo, "branch", "-m", "topic", "master")
+		require.Equal(t, 128, exit)
+		require.Equal(t, "fatal: a branch named 'master' already exists\n", out)
+
+		exit, out = runCmd(t, repo, "branch", "-m", "missing", "other")
+		require.Equal(t, 128, exit)
+		require.Equal(t, "fatal: no branch named 'missing'\n", out)
+
+		exit, _ = runCmd(t, repo, "branch", "-m", "topic", "feature/topic")
+		require.Equal(t, 0, exit)
+
+		exit, _ = runCmd(t, repo, "branch", "-m", "main")
+		require.Equal(t, 0, exit)
+
+		current, err := repo.Refs.CurrentRef()
+		require.NoError(t, err)
+		require.Equal(t, "main", current)
+
+		exit, out = runCmd(t, repo, "branch")
+		require.Equal(t, 0, exit)
+		require.Equal(t, "  feature/topic\n* main\n", out)
+
+		oid, err := repo.Refs.ReadHead()
+		require.NoError(t, err)
+		require.Equal(t, head, oid)
+	})
+
+	t.Run("rename unborn branch", func(t *testing.T) {
+		t.Parallel()
+
+		repo := stagedRepository(t)
+
+		exit, _ := runCmd(t, repo, "branch", "-m", "main")
+		require.Equal(t, 0, exit)
+
+		current, err := repo.Refs.CurrentRef()
+		require.NoError(t, err)
+		require.Equal(t, "main", current)
+	})
+
+	t.Run("list detached head", func(t *testing.T) {
+		t.Parallel()
+
+		repo := committedRepository(t)
+
+		head, err := repo.Refs.ReadHead()
+		require.NoError(t, err)
+		require.NoError(t, repo.Refs.DetachHead(head))
+
+		exit, out := runCmd(t, repo, "branch")
+		require.Equal(t, 0, exit)
+		require.Equal(t, "* (HEAD detached at "+head[:7]+")\n  master\n", out)
+
+		exit, out = runCmd(t, repo, "branch", "-m", "topic")
+		require.Equal(t, 128, exit)
+		require.Equal(t, "fatal: cannot rename the current branch while not on any.\n", out)
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		t.Parallel()
+
+		repo := committedRepository(t)
+
+		exit, _ := runCmd(t, repo, "branch", "-d", "-m", "topic")
+		require.Equal(t, 129, exit)
+
+		exit, out := runCmd(t, repo, "branch", "-d")
+		require.Equal(t, 128, exit)
+		require.Equal(t, "fatal: branch name required\n", out)
+	})
+}
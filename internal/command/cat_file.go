@@ -0,0 +1,165 @@
+package command
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/repository"
+	"github.com/LukasJenicek/ggit/internal/revision"
+)
+
+type CatFileMode string
+
+const (
+	CatFileType   CatFileMode = "type"
+	CatFileSize   CatFileMode = "size"
+	CatFilePretty CatFileMode = "pretty"
+	CatFileExists CatFileMode = "exists"
+)
+
+// CatFileCommand provides content, type or size of objects stored in the database.
+type CatFileCommand struct {
+	repository *repository.Repository
+	mode       CatFileMode
+	object     string
+}
+
+func NewCatFileCommand(repo *repository.Repository, mode CatFileMode, object string) (*CatFileCommand, error) {
+	if repo == nil {
+		return nil, errors.New("repository is nil")
+	}
+
+	if object == "" {
+		return nil, errors.New("object is empty")
+	}
+
+	switch mode {
+	case CatFileType, CatFileSize, CatFilePretty, CatFileExists:
+	default:
+		return nil, fmt.Errorf("unknown cat-file mode %q", mode)
+	}
+
+	return &CatFileCommand{
+		repository: repo,
+		mode:       mode,
+		object:     object,
+	}, nil
+}
+
+func (c *CatFileCommand) Run() ([]byte, error) {
+	resolver, err := revision.New(c.repository.Database, c.repository.Refs, c.repository.Clock)
+	if err != nil {
+		return nil, fmt.Errorf("init revision resolver: %w", err)
+	}
+
+	oid, err := resolver.Resolve(c.object)
+	if err != nil {
+		return nil, fmt.Errorf("resolve object: %w", err)
+	}
+
+	if c.mode == CatFileExists {
+		return []byte(""), nil
+	}
+
+	raw, err := c.repository.Database.ReadObject(oid)
+	if err != nil {
+		return nil, fmt.Errorf("read object: %w", err)
+	}
+
+	switch c.mode {
+	case CatFileType:
+		return []byte(raw.Type + "\n"), nil
+	case CatFileSize:
+		return []byte(strconv.Itoa(raw.Size) + "\n"), nil
+	}
+
+	if raw.Type != database.TreeType {
+		return raw.Data, nil
+	}
+
+	obj, err := c.repository.Database.Load(oid)
+	if err != nil {
+		return nil, fmt.Errorf("load tree: %w", err)
+	}
+
+	tree, ok := obj.(*database.Tree)
+	if !ok {
+		return nil, fmt.Errorf("object %s is not a tree", oid)
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	for _, entry := range tree.Entries() {
+		line, err := formatTreeEntry(entry, "")
+		if err != nil {
+			return nil, fmt.Errorf("format tree entry: %w", err)
+		}
+
+		buf.WriteString(line)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (c *CatFileCommand) Output(msg []byte, err error, stdout io.Writer) (int, error) {
+	if err != nil {
+		var ambiguous *database.ErrAmbiguousObjectID
+
+		notFound := errors.Is(err, revision.ErrUnknownRevision) || errors.Is(err, revision.ErrInvalidRevision)
+		if notFound || errors.As(err, &ambiguous) {
+			if c.mode == CatFileExists {
+				return 1, nil
+			}
+
+			if ambiguous != nil {
+				writeAmbiguousObjectHint(stdout, c.repository, ambiguous)
+			}
+
+			fmt.Fprintf(stdout, "fatal: Not a valid object name %s\n", c.object)
+
+			return 128, nil
+		}
+
+		return 1, fmt.Errorf("cat-file cmd: %w", err)
+	}
+
+	fmt.Fprint(stdout, string(msg))
+
+	return 0, nil
+}
+
+// formatTreeEntry
+// Formats single tree child the same way as git: {mode} {type} {oid}\t{path}.
+func formatTreeEntry(entry database.Object, dir string) (string, error) {
+	var (
+		mode       string
+		objectType string
+		name       string
+		oid        []byte
+	)
+
+	switch e := entry.(type) {
+	case *database.Tree:
+		mode, objectType, name, oid = e.Mode(), database.TreeType, e.Name(), e.OID()
+	case *database.Entry:
+		mode, objectType, name, oid = e.Mode(), e.Type(), e.Name, e.OID
+	default:
+		return "", fmt.Errorf("unexpected tree entry %T", entry)
+	}
+
+	octal, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return "", fmt.Errorf("parse mode %q: %w", mode, err)
+	}
+
+	if dir != "" {
+		name = dir + "/" + name
+	}
+
+	return fmt.Sprintf("%06o %s %s\t%s\n", octal, objectType, hex.EncodeToString(oid), name), nil
+}
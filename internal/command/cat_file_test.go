@@ -0,0 +1,136 @@
+package command_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/clock"
+	"github.com/LukasJenicek/ggit/internal/command"
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/filesystem/memory"
+	"github.com/LukasJenicek/ggit/internal/repository"
+)
+
+func TestCatFile(t *testing.T) {
+	t.Parallel()
+
+	repo := committedRepository(t)
+
+	head, err := repo.Refs.ReadHead()
+	require.NoError(t, err)
+
+	obj, err := repo.Database.Load(head)
+	require.NoError(t, err)
+
+	commit, ok := obj.(*database.Commit)
+	require.True(t, ok)
+
+	blobID, err := repo.Database.Store(database.NewBlob([]byte("hello")))
+	require.NoError(t, err)
+
+	worldID, err := repo.Database.Store(database.NewBlob([]byte("world")))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		args   []string
+		exit   int
+		output string
+	}{
+		{
+			name:   "type of commit",
+			args:   []string{"-t", head},
+			output: "commit\n",
+		},
+		{
+			name:   "size of blob",
+			args:   []string{"-s", hex.EncodeToString(blobID)},
+			output: "5\n",
+		},
+		{
+			name:   "pretty print blob",
+			args:   []string{"-p", hex.EncodeToString(blobID)},
+			output: "hello",
+		},
+		{
+			name: "pretty print tree",
+			args: []string{"-p", commit.RootOID},
+			output: "100644 blob " + hex.EncodeToString(blobID) + "\thello.txt\n" +
+				"100644 blob " + hex.EncodeToString(worldID) + "\tworld.txt\n",
+		},
+		{
+			name: "existing object",
+			args: []string{"-e", head},
+		},
+		{
+			name: "missing object",
+			args: []string{"-e", "0000000000000000000000000000000000000000"},
+			exit: 1,
+		},
+		{
+			name:   "invalid object name",
+			args:   []string{"-t", "main"},
+			exit:   128,
+			output: "fatal: Not a valid object name main\n",
+		},
+		{
+			name:   "multiple modes",
+			args:   []string{"-t", "-s", head},
+			exit:   129,
+			output: "usage: ggit cat-file (-t | -s | -e | -p) <object>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := bytes.NewBuffer(nil)
+
+			exit, err := command.NewRunner(repo, nil).RunCmd(t.Context(), "cat-file", tt.args, buf)
+			require.NoError(t, err)
+			require.Equal(t, tt.exit, exit)
+			require.Equal(t, tt.output, buf.String())
+		})
+	}
+}
+
+// committedRepository creates repository with hello.txt and world.txt committed.
+func committedRepository(t *testing.T) *repository.Repository {
+	t.Helper()
+
+	repo, err := repository.New(
+		memory.New(fstest.MapFS{
+			"tmp/test/": &fstest.MapFile{
+				Mode: os.ModeDir,
+			},
+			"tmp/test/hello.txt": &fstest.MapFile{
+				Data: []byte("hello"),
+				Mode: 0o644,
+				Sys:  defaultStat(0o644, 5),
+			},
+			"tmp/test/world.txt": &fstest.MapFile{
+				Data: []byte("world"),
+				Mode: 0o644,
+				Sys:  defaultStat(0o644, 5),
+			},
+		}),
+		clock.NewFakeClock(time.Date(2000, 12, 15, 17, 8, 0o0, 0, time.UTC)),
+		"tmp/test",
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Init())
+	require.NoError(t, repo.Add([]string{"."}))
+
+	_, err = repo.Commit("all", repository.CommitOptions{})
+	require.NoError(t, err)
+
+	return repo
+}
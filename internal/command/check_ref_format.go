@@ -0,0 +1,112 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/repository"
+	"github.com/LukasJenicek/ggit/internal/revision"
+)
+
+type CheckRefFormatOptions struct {
+	// Check name as a branch name, @{-n} is expanded to n-th previously checked out branch.
+	Branch bool
+	// Print normalized name.
+	Normalize bool
+	database.RefFormatOptions
+}
+
+// CheckRefFormatCommand validates ref name, exit status tells whether the name is valid.
+type CheckRefFormatCommand struct {
+	repository *repository.Repository
+	name       string
+	options    CheckRefFormatOptions
+}
+
+func NewCheckRefFormatCommand(repo *repository.Repository, name string, options CheckRefFormatOptions) (*CheckRefFormatCommand, error) {
+	if repo == nil {
+		return nil, errors.New("repository is nil")
+	}
+
+	return &CheckRefFormatCommand{
+		repository: repo,
+		name:       name,
+		options:    options,
+	}, nil
+}
+
+func (c *CheckRefFormatCommand) Run() ([]byte, error) {
+	if c.options.Branch {
+		return c.branch()
+	}
+
+	name := c.name
+	if c.options.Normalize {
+		name = database.NormalizeRefName(name)
+	}
+
+	if err := database.CheckRefFormat(name, c.options.RefFormatOptions); err != nil {
+		return nil, err
+	}
+
+	if c.options.Normalize {
+		return []byte(name + "\n"), nil
+	}
+
+	return nil, nil
+}
+
+func (c *CheckRefFormatCommand) branch() ([]byte, error) {
+	name := c.name
+
+	if n, ok := strings.CutPrefix(name, "@{-"); ok && strings.HasSuffix(n, "}") {
+		nth, err := strconv.Atoi(strings.TrimSuffix(n, "}"))
+		if err != nil || nth < 1 {
+			return nil, fmt.Errorf("%w: %s", database.ErrInvalidRefName, name)
+		}
+
+		resolver, err := revision.New(c.repository.Database, c.repository.Refs, c.repository.Clock)
+		if err != nil {
+			return nil, fmt.Errorf("init revision resolver: %w", err)
+		}
+
+		name, err = resolver.PreviousBranch(nth)
+		if err != nil {
+			return nil, fmt.Errorf("expand %s: %w", c.name, err)
+		}
+
+		if name == "" {
+			return nil, fmt.Errorf("%w: %s", database.ErrInvalidRefName, c.name)
+		}
+	}
+
+	if err := database.CheckBranchName(name); err != nil {
+		return nil, err
+	}
+
+	return []byte(name + "\n"), nil
+}
+
+func (c *CheckRefFormatCommand) Output(msg []byte, err error, stdout io.Writer) (int, error) {
+	if err != nil {
+		if !errors.Is(err, database.ErrInvalidRefName) {
+			return 1, fmt.Errorf("check-ref-format cmd: %w", err)
+		}
+
+		if c.options.Branch {
+			fmt.Fprintf(stdout, "fatal: '%s' is not a valid branch name\n", c.name)
+
+			return 128, nil
+		}
+
+		return 1, nil
+	}
+
+	fmt.Fprint(stdout, string(msg))
+
+	return 0, nil
+}
@@ -0,0 +1,50 @@
+package command_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRefFormat(t *testing.T) {
+	t.Parallel()
+
+	repo := committedRepository(t)
+
+	tests := []struct {
+		args []string
+		exit int
+		out  string
+	}{
+		{args: []string{"refs/heads/master"}},
+		{args: []string{"refs/heads/a..b"}, exit: 1},
+		{args: []string{"master"}, exit: 1},
+		{args: []string{"--allow-onelevel", "master"}},
+		{args: []string{"--refspec-pattern", "refs/heads/*"}},
+		{args: []string{"--normalize", "//refs//heads/x"}, out: "refs/heads/x\n"},
+		{args: []string{"--branch", "feature"}, out: "feature\n"},
+		{args: []string{"--branch", "-dash"}, exit: 128, out: "fatal: '-dash' is not a valid branch name\n"},
+		{args: []string{"--branch", "x.lock"}, exit: 128, out: "fatal: 'x.lock' is not a valid branch name\n"},
+		{args: []string{"--branch", "@{-1}"}, exit: 128, out: "fatal: '@{-1}' is not a valid branch name\n"},
+		{args: []string{}, exit: 129},
+		{args: []string{"--branch", "feature", "extra"}, exit: 129},
+	}
+
+	for _, tt := range tests {
+		exit, out := runCmd(t, repo, "check-ref-format", tt.args...)
+		require.Equal(t, tt.exit, exit, tt.args)
+
+		if tt.exit != 129 {
+			require.Equal(t, tt.out, out, tt.args)
+		}
+	}
+
+	// names rejected by check-ref-format are not accepted by commands creating refs
+	exit, out := runUpdateRef(t, repo, nil, "refs/heads/a..b", "HEAD")
+	require.Equal(t, 128, exit)
+	require.Equal(t, "fatal: invalid ref format: refs/heads/a..b\n", out)
+
+	exit, out = runCmd(t, repo, "branch", "x.lock")
+	require.Equal(t, 128, exit)
+	require.Equal(t, "fatal: 'x.lock' is not a valid branch name\n", out)
+}
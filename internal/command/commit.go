@@ -1,23 +1,116 @@
 package command
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"path/filepath"
+	"sort"
 
+	"github.com/LukasJenicek/ggit/internal/database"
 	"github.com/LukasJenicek/ggit/internal/repository"
 )
 
+const commitTemplate = `
+# Please enter the commit message for your changes. Lines starting
+# with '#' will be ignored, and an empty message aborts the commit.
+#
+%s`
+
+type CommitOptions struct {
+	// Messages given by -m, each one becomes a separate paragraph.
+	Messages []string
+	// File given by -F, "-" reads the message from stdin.
+	File    string
+	Cleanup repository.CleanupMode
+	// Author and Committer override identities from git config and the commit time.
+	Author    repository.Signature
+	Committer repository.Signature
+	// AllowEmpty records a commit even when it does not change anything.
+	AllowEmpty bool
+}
+
 type CommitCmd struct {
 	repository *repository.Repository
+	options    CommitOptions
+	stdin      io.Reader
+	editor     EditorFunc
 }
 
-func NewCommitCmd(repository *repository.Repository) (*CommitCmd, error) {
-	return &CommitCmd{repository: repository}, nil
+func NewCommitCmd(
+	repo *repository.Repository,
+	options CommitOptions,
+	stdin io.Reader,
+	editor EditorFunc,
+) (*CommitCmd, error) {
+	if repo == nil {
+		return nil, errors.New("repository is nil")
+	}
+
+	if len(options.Messages) > 0 && options.File != "" {
+		return nil, errors.New("only one of -m and -F can be used")
+	}
+
+	if options.Cleanup == "" {
+		options.Cleanup = repository.CleanupDefault
+	}
+
+	return &CommitCmd{
+		repository: repo,
+		options:    options,
+		stdin:      stdin,
+		editor:     editor,
+	}, nil
 }
 
 func (c *CommitCmd) Run() ([]byte, error) {
-	commit, err := c.repository.Commit()
+	idx, err := c.repository.Index.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load index: %w", err)
+	}
+
+	if len(idx.Conflicts) > 0 {
+		paths := make([]string, 0, len(idx.Conflicts))
+		for path := range idx.Conflicts {
+			paths = append(paths, path)
+		}
+
+		sort.Strings(paths)
+
+		buf := bytes.NewBuffer(nil)
+		for _, path := range paths {
+			buf.WriteString("U\t" + path + "\n")
+		}
+
+		return buf.Bytes(), repository.ErrUnmergedFiles
+	}
+
+	if idx.Entries.Len() == 0 {
+		return nil, repository.ErrNoFilesToCommit
+	}
+
+	// find out there is nothing to commit before the editor is opened
+	if !c.options.AllowEmpty {
+		changes, err := c.repository.IndexChanges(idx)
+		if err != nil {
+			return nil, fmt.Errorf("compare index with head: %w", err)
+		}
+
+		if len(changes) == 0 {
+			return nil, repository.ErrNothingToCommit
+		}
+	}
+
+	message, edited, err := c.message()
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := c.repository.Commit(
+		repository.CleanupMessage(message, c.options.Cleanup, edited),
+		repository.CommitOptions{Author: c.options.Author, Committer: c.options.Committer, AllowEmpty: c.options.AllowEmpty},
+	)
 	if err != nil {
 		return nil, fmt.Errorf("run commit cmd: %w", err)
 	}
@@ -27,16 +120,51 @@ func (c *CommitCmd) Run() ([]byte, error) {
 		return nil, fmt.Errorf("read current ref: %w", err)
 	}
 
+	if ref == database.HEAD {
+		ref = "detached HEAD"
+	}
+
+	short, err := c.repository.Database.ShortOID(commit.OID)
+	if err != nil {
+		return nil, fmt.Errorf("abbreviate commit id: %w", err)
+	}
+
 	m := ""
-	if commit.Parent == "" {
-		m = "root-commit"
+	if commit.Parent() == "" {
+		m = " (root-commit)"
 	}
 
-	msg := fmt.Sprintf("[%s (%s) %s] %s", ref, m, commit.OID[0:7], commit.Message)
+	msg := fmt.Sprintf("[%s%s %s] %s\n", ref, m, short, subject(commit.Message))
 
 	return []byte(msg), nil
 }
 
+// message returns raw commit message and whether it was written in the editor.
+func (c *CommitCmd) message() (string, bool, error) {
+	source := &messageSource{
+		repository:  c.repository,
+		messages:    c.options.Messages,
+		file:        c.options.File,
+		stdin:       c.stdin,
+		editor:      c.editor,
+		editMsgPath: filepath.Join(c.repository.GitPath, "COMMIT_EDITMSG"),
+	}
+
+	return source.read(func() (string, error) {
+		status, err := NewStatusCommand(c.repository, StatusOptions{})
+		if err != nil {
+			return "", err
+		}
+
+		long, err := status.commitTemplate()
+		if err != nil {
+			return "", fmt.Errorf("status for commit template: %w", err)
+		}
+
+		return fmt.Sprintf(commitTemplate, long), nil
+	})
+}
+
 func (c *CommitCmd) Output(msg []byte, err error, stdout io.Writer) (int, error) {
 	if err != nil {
 		if errors.Is(err, repository.ErrNoFilesToCommit) {
@@ -45,6 +173,47 @@ func (c *CommitCmd) Output(msg []byte, err error, stdout io.Writer) (int, error)
 			return 1, nil
 		}
 
+		if errors.Is(err, repository.ErrNothingToCommit) {
+			description, descErr := headDescription(c.repository)
+			if descErr != nil {
+				return 1, fmt.Errorf("describe HEAD: %w", descErr)
+			}
+
+			fmt.Fprintf(stdout, "%s\n%s\n", description, err.Error())
+
+			return 1, nil
+		}
+
+		var dateErr *database.ErrDateFormat
+		if errors.As(err, &dateErr) {
+			fmt.Fprintf(stdout, "fatal: %s\n", dateErr.Error())
+
+			return 128, nil
+		}
+
+		if errors.Is(err, repository.ErrUnmergedFiles) {
+			fmt.Fprint(stdout, string(msg))
+			fmt.Fprint(stdout, "error: Committing is not possible because you have unmerged files.\n"+
+				"hint: Fix them up in the work tree, and then use 'ggit add <file>'\n"+
+				"hint: to mark resolution and make a commit.\n"+
+				"fatal: Exiting because of an unresolved conflict.\n")
+
+			return 128, nil
+		}
+
+		if errors.Is(err, repository.ErrEmptyCommitMessage) {
+			fmt.Fprint(stdout, "Aborting commit due to empty commit message.\n")
+
+			return 1, nil
+		}
+
+		var lockErr *database.ErrRefLock
+		if errors.As(err, &lockErr) {
+			fmt.Fprintf(stdout, "fatal: %s\n", lockErr.Error())
+
+			return 128, nil
+		}
+
 		return 1, fmt.Errorf("commit cmd: %w", err)
 	}
 
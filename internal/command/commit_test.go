@@ -0,0 +1,320 @@
+package command_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/clock"
+	"github.com/LukasJenicek/ggit/internal/command"
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/filesystem/memory"
+	"github.com/LukasJenicek/ggit/internal/repository"
+)
+
+func TestCommit(t *testing.T) {
+	t.Parallel()
+
+	t.Run("multiple messages become paragraphs", func(t *testing.T) {
+		t.Parallel()
+
+		repo := stagedRepository(t)
+		buf := bytes.NewBuffer(nil)
+
+		exit, err := command.NewRunner(repo, nil).RunCmd(t.Context(), "commit", []string{"-m", "subject", "-m", "body  "}, buf)
+		require.NoError(t, err)
+		require.Equal(t, 0, exit)
+		require.Regexp(t, `^\[master \(root-commit\) [0-9a-f]{7}\] subject\n$`, buf.String())
+
+		require.Equal(t, "subject\n\nbody", headMessage(t, repo))
+	})
+
+	t.Run("message from stdin", func(t *testing.T) {
+		t.Parallel()
+
+		repo := stagedRepository(t)
+		stdin := strings.NewReader("\nfrom stdin\n\n\n# kept\n")
+
+		exit, err := command.NewRunner(repo, stdin).RunCmd(t.Context(), "commit", []string{"-F", "-"}, bytes.NewBuffer(nil))
+		require.NoError(t, err)
+		require.Equal(t, 0, exit)
+
+		require.Equal(t, "from stdin\n\n# kept", headMessage(t, repo))
+	})
+
+	t.Run("message from file", func(t *testing.T) {
+		t.Parallel()
+
+		repo := stagedRepository(t)
+		require.NoError(t, repo.FS.WriteFile("tmp/test/msg.txt", []byte("from file\n"), 0o644))
+
+		exit, err := command.NewRunner(repo, nil).RunCmd(t.Context(), "commit", []string{"-F", "msg.txt"}, bytes.NewBuffer(nil))
+		require.NoError(t, err)
+		require.Equal(t, 0, exit)
+
+		require.Equal(t, "from file", headMessage(t, repo))
+	})
+
+	t.Run("message from editor", func(t *testing.T) {
+		t.Parallel()
+
+		repo := stagedRepository(t)
+
+		editor := func(path string) error {
+			template, err := repo.FS.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			require.Equal(
+				t,
+				"\n# Please enter the commit message for your changes. Lines starting\n"+
+					"# with '#' will be ignored, and an empty message aborts the commit.\n"+
+					"#\n"+
+					"# On branch master\n"+
+					"#\n"+
+					"# Initial commit\n"+
+					"#\n"+
+					"# Changes to be committed:\n"+
+					"#\tnew file:   hello.txt\n"+
+					"#\n",
+				string(template),
+			)
+
+			return repo.FS.WriteFile(path, append([]byte("edited\n"), template...), 0o644)
+		}
+
+		cmd, err := command.NewCommitCmd(repo, command.CommitOptions{}, nil, editor)
+		require.NoError(t, err)
+
+		out, err := cmd.Run()
+		require.NoError(t, err)
+		require.Contains(t, string(out), "] edited\n")
+
+		require.Equal(t, "edited", headMessage(t, repo))
+	})
+
+	t.Run("verbatim cleanup", func(t *testing.T) {
+		t.Parallel()
+
+		repo := stagedRepository(t)
+
+		exit, err := command.NewRunner(repo, nil).RunCmd(
+			t.Context(),
+			"commit",
+			[]string{"--cleanup=verbatim", "-m", "subject  \n# comment"},
+			bytes.NewBuffer(nil),
+		)
+		require.NoError(t, err)
+		require.Equal(t, 0, exit)
+
+		require.Equal(t, "subject  \n# comment", headMessage(t, repo))
+	})
+
+	t.Run("empty message aborts commit", func(t *testing.T) {
+		t.Parallel()
+
+		repo := stagedRepository(t)
+		buf := bytes.NewBuffer(nil)
+
+		exit, err := command.NewRunner(repo, nil).RunCmd(t.Context(), "commit", []string{"--cleanup=strip", "-m", "# only comment"}, buf)
+		require.NoError(t, err)
+		require.Equal(t, 1, exit)
+		require.Equal(t, "Aborting commit due to empty commit message.\n", buf.String())
+
+		head, err := repo.Refs.ReadHead()
+		require.NoError(t, err)
+		require.Empty(t, head)
+	})
+
+	t.Run("author and committer identities", func(t *testing.T) {
+		t.Parallel()
+
+		repo := stagedRepository(t)
+
+		cmd, err := command.NewCommitCmd(repo, command.CommitOptions{
+			Messages: []string{"identities"},
+			Author: repository.Signature{
+				Name:  "Jane Doe",
+				Email: "jane@example.com",
+				Date:  "2005-04-07T22:13:13+09:00",
+			},
+			Committer: repository.Signature{Name: "John Doe"},
+		}, nil, nil)
+		require.NoError(t, err)
+
+		_, err = cmd.Run()
+		require.NoError(t, err)
+
+		commit := headCommit(t, repo)
+		require.Equal(t, "Jane Doe <jane@example.com> 1112879593 +0900", commit.Author.String())
+		require.Equal(t, "John Doe <lukas.jenicek5@gmail.com> 976900080 +0000", commit.Committer.String())
+	})
+
+	t.Run("author and date flags", func(t *testing.T) {
+		t.Parallel()
+
+		repo := stagedRepository(t)
+
+		exit, err := command.NewRunner(repo, nil).RunCmd(
+			t.Context(),
+			"commit",
+			[]string{"--author", "Jane Doe <jane@example.com>", "--date", "1112911993 -0130", "-m", "subject"},
+			bytes.NewBuffer(nil),
+		)
+		require.NoError(t, err)
+		require.Equal(t, 0, exit)
+
+		commit := headCommit(t, repo)
+		require.Equal(t, "Jane Doe <jane@example.com> 1112911993 -0130", commit.Author.String())
+		require.Equal(t, "Lukas Jenicek <lukas.jenicek5@gmail.com> 976900080 +0000", commit.Committer.String())
+	})
+
+	t.Run("invalid author and date", func(t *testing.T) {
+		t.Parallel()
+
+		repo := stagedRepository(t)
+		buf := bytes.NewBuffer(nil)
+
+		exit, err := command.NewRunner(repo, nil).RunCmd(t.Context(), "commit", []string{"--author", "Jane", "-m", "subject"}, buf)
+		require.NoError(t, err)
+		require.Equal(t, 128, exit)
+		require.Equal(t, "fatal: --author 'Jane' is not 'Name <email>'\n", buf.String())
+
+		buf.Reset()
+
+		exit, err = command.NewRunner(repo, nil).RunCmd(t.Context(), "commit", []string{"--date", "someday", "-m", "subject"}, buf)
+		require.NoError(t, err)
+		require.Equal(t, 128, exit)
+		require.Equal(t, "fatal: invalid date format: someday\n", buf.String())
+	})
+
+	t.Run("detached head", func(t *testing.T) {
+		t.Parallel()
+
+		repo := committedRepository(t)
+
+		head, err := repo.Refs.ReadHead()
+		require.NoError(t, err)
+		require.NoError(t, repo.Refs.DetachHead(head))
+
+		editor := func(path string) error {
+			template, err := repo.FS.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			require.Contains(t, string(template), "# HEAD detached at "+head[:7]+"\n")
+
+			return repo.FS.WriteFile(path, append([]byte("detached\n"), template...), 0o644)
+		}
+
+		cmd, err := command.NewCommitCmd(repo, command.CommitOptions{AllowEmpty: true}, nil, editor)
+		require.NoError(t, err)
+
+		out, err := cmd.Run()
+		require.NoError(t, err)
+		require.Regexp(t, `^\[detached HEAD [0-9a-f]{7}\] detached\n$`, string(out))
+
+		commit := headCommit(t, repo)
+		require.Equal(t, []string{head}, commit.Parents)
+
+		master, err := repo.Refs.ReadRef("master")
+		require.NoError(t, err)
+		require.Equal(t, head, master)
+	})
+
+	t.Run("nothing to commit", func(t *testing.T) {
+		t.Parallel()
+
+		repo := committedRepository(t)
+		head := headCommit(t, repo)
+
+		editor := func(string) error {
+			require.Fail(t, "editor must not be opened when there is nothing to commit")
+
+			return nil
+		}
+
+		buf := bytes.NewBuffer(nil)
+
+		exit, err := command.NewRunner(repo, nil).RunCmd(t.Context(), "commit", nil, buf)
+		require.NoError(t, err)
+		require.Equal(t, 1, exit)
+		require.Equal(t, "On branch master\nno changes added to commit\n", buf.String())
+
+		cmd, err := command.NewCommitCmd(repo, command.CommitOptions{}, nil, editor)
+		require.NoError(t, err)
+
+		_, err = cmd.Run()
+		require.ErrorIs(t, err, repository.ErrNothingToCommit)
+
+		buf.Reset()
+
+		exit, err = command.NewRunner(repo, nil).RunCmd(t.Context(), "commit", []string{"--allow-empty", "-m", "empty"}, buf)
+		require.NoError(t, err)
+		require.Equal(t, 0, exit)
+		require.Equal(t, head.RootOID, headCommit(t, repo).RootOID)
+	})
+
+	t.Run("invalid cleanup mode", func(t *testing.T) {
+		t.Parallel()
+
+		repo := stagedRepository(t)
+		buf := bytes.NewBuffer(nil)
+
+		exit, err := command.NewRunner(repo, nil).RunCmd(t.Context(), "commit", []string{"--cleanup=none", "-m", "subject"}, buf)
+		require.NoError(t, err)
+		require.Equal(t, 128, exit)
+		require.Equal(t, "fatal: invalid cleanup mode none\n", buf.String())
+	})
+}
+
+// stagedRepository creates repository with hello.txt added to the index but not committed.
+func stagedRepository(t *testing.T) *repository.Repository {
+	t.Helper()
+
+	repo, err := repository.New(
+		memory.New(fstest.MapFS{
+			"tmp/test/": &fstest.MapFile{
+				Mode: os.ModeDir,
+			},
+			"tmp/test/hello.txt": &fstest.MapFile{
+				Data: []byte("hello"),
+				Mode: 0o644,
+				Sys:  defaultStat(0o644, 5),
+			},
+		}),
+		clock.NewFakeClock(time.Date(2000, 12, 15, 17, 8, 0o0, 0, time.UTC)),
+		"tmp/test",
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Init())
+	require.NoError(t, repo.Add([]string{"hello.txt"}))
+
+	return repo
+}
+
+func headMessage(t *testing.T, repo *repository.Repository) string {
+	t.Helper()
+
+	return headCommit(t, repo).Message
+}
+
+func headCommit(t *testing.T, repo *repository.Repository) *database.Commit {
+	t.Helper()
+
+	head, err := repo.Refs.ReadHead()
+	require.NoError(t, err)
+
+	commit, err := repo.Database.LoadCommit(head)
+	require.NoError(t, err)
+
+	return commit
+}
@@ -0,0 +1,383 @@
+package command
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/diff"
+	"github.com/LukasJenicek/ggit/internal/index"
+	"github.com/LukasJenicek/ggit/internal/repository"
+	"github.com/LukasJenicek/ggit/internal/revision"
+)
+
+type DiffOptions struct {
+	// Number of unchanged lines shown around each change.
+	Context int
+	// Compare the index with HEAD, or with the commit given in Revisions, instead of the workspace with the index.
+	Cached bool
+	// Commits to compare, either two of them or a single "A..B" range where an empty side means HEAD.
+	Revisions []string
+}
+
+// DiffCommand shows changes of tracked files in the workspace which are not staged yet, staged changes
+// or changes between two commits.
+type DiffCommand struct {
+	repo    *repository.Repository
+	options DiffOptions
+
+	// revision argument resolved last, it is reported when it does not name a tree
+	failed string
+}
+
+// diffFile is one side of a compared file.
+type diffFile struct {
+	path string
+	oid  string
+	mode string
+	data []byte
+}
+
+func NewDiffCommand(repo *repository.Repository, options DiffOptions) (*DiffCommand, error) {
+	if repo == nil {
+		return nil, errors.New("repository is nil")
+	}
+
+	return &DiffCommand{repo: repo, options: options}, nil
+}
+
+func (d *DiffCommand) Run() ([]byte, error) {
+	if d.options.Cached || len(d.options.Revisions) > 0 {
+		return d.diffChanges()
+	}
+
+	return d.diffWorkspace()
+}
+
+// diffChanges writes patches of files which differ between two trees or a tree and the index.
+func (d *DiffCommand) diffChanges() ([]byte, error) {
+	changes, err := d.changes()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	for _, change := range changes {
+		a, err := d.storedFile(change.Path, change.OldOID, change.OldMode)
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := d.storedFile(change.Path, change.NewOID, change.NewMode)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := d.writeFileDiff(buf, a, b); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// changes compares trees of two commits, or the index with HEAD or the given commit when cached.
+func (d *DiffCommand) changes() ([]*repository.Change, error) {
+	if !d.options.Cached {
+		oldRev, newRev, rangeArg := d.options.Revisions[0], "", ""
+		if len(d.options.Revisions) == 2 {
+			newRev = d.options.Revisions[1]
+		} else {
+			rangeArg = oldRev
+			oldRev, newRev, _ = strings.Cut(oldRev, "..")
+		}
+
+		oldTree, err := d.resolveTree(oldRev, rangeArg)
+		if err != nil {
+			return nil, err
+		}
+
+		newTree, err := d.resolveTree(newRev, rangeArg)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.repo.TreeDiff(oldTree, newTree)
+	}
+
+	idx, err := d.repo.Index.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load index: %w", err)
+	}
+
+	if len(d.options.Revisions) == 0 {
+		return d.repo.IndexChanges(idx)
+	}
+
+	tree, err := d.resolveTree(d.options.Revisions[0], "")
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := d.repo.TreeFiles(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	return repository.CompareFiles(files, repository.IndexFiles(idx)), nil
+}
+
+// resolveTree returns oid of the tree the revision points to, empty revision means HEAD. rangeArg is the A..B
+// argument the revision was cut from, it is reported as a whole when the revision does not resolve.
+func (d *DiffCommand) resolveTree(rev, rangeArg string) (string, error) {
+	d.failed = rev
+	if rangeArg != "" {
+		d.failed = rangeArg
+	}
+
+	if rev == "" {
+		rev = "HEAD"
+	}
+
+	resolver, err := revision.New(d.repo.Database, d.repo.Refs, d.repo.Clock)
+	if err != nil {
+		return "", fmt.Errorf("init revision resolver: %w", err)
+	}
+
+	oid, err := resolver.Resolve(rev)
+	if err != nil {
+		return "", fmt.Errorf("resolve revision: %w", err)
+	}
+
+	oid, err = resolver.Peel(oid, database.TreeType)
+	if err != nil {
+		return "", fmt.Errorf("resolve revision: %w", err)
+	}
+
+	return oid, nil
+}
+
+// storedFile returns the file stored in the database, nil when there is no oid.
+func (d *DiffCommand) storedFile(path, oid, mode string) (*diffFile, error) {
+	if oid == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	data, err := d.blobData(oid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &diffFile{path: path, oid: oid, mode: mode, data: data}, nil
+}
+
+// diffWorkspace writes patches of tracked files which differ from their index entries.
+func (d *DiffCommand) diffWorkspace() ([]byte, error) {
+	idx, err := d.repo.Index.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load index: %w", err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	for _, entry := range idx.Entries.SortedValues() {
+		a := &diffFile{path: string(entry.Path), oid: hex.EncodeToString(entry.OID), mode: fmt.Sprintf("%o", entry.Mode)}
+
+		b, err := d.workspaceFile(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		if b != nil && a.oid == b.oid && a.mode == b.mode {
+			continue
+		}
+
+		a.data, err = d.blobData(a.oid)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := d.writeFileDiff(buf, a, b); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// workspaceFile returns the workspace version of the index entry, nil when the file was deleted.
+// Files whose stat data match the index are not read and the entry values are returned.
+func (d *DiffCommand) workspaceFile(entry *index.Entry) (*diffFile, error) {
+	path := string(entry.Path)
+
+	stat, err := d.repo.FS.Stat(filepath.Join(d.repo.RootDir, path))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("stat %s: %w", path, err)
+	}
+
+	if stat == nil || stat.IsDir() {
+		return nil, nil //nolint:nilnil
+	}
+
+	file := &diffFile{path: path, mode: fmt.Sprintf("%o", index.ModeFromFileInfo(stat))}
+
+	if entry.StatMatch(stat) && entry.TimesMatch(stat) {
+		file.oid = hex.EncodeToString(entry.OID)
+
+		return file, nil
+	}
+
+	file.data, err = d.repo.Workspace.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read workspace file: %w", err)
+	}
+
+	oid, err := database.Hash(database.NewBlob(file.data))
+	if err != nil {
+		return nil, fmt.Errorf("hash %s: %w", path, err)
+	}
+
+	file.oid = hex.EncodeToString(oid)
+
+	return file, nil
+}
+
+func (d *DiffCommand) blobData(oid string) ([]byte, error) {
+	obj, err := d.repo.Database.Load(oid)
+	if err != nil {
+		return nil, fmt.Errorf("load blob %s: %w", oid, err)
+	}
+
+	blob, ok := obj.(*database.Blob)
+	if !ok {
+		return nil, fmt.Errorf("object %s is not a blob", oid)
+	}
+
+	return blob.Data(), nil
+}
+
+// writeFileDiff writes the git header of the file followed by hunks. Side a is the old version and b the new one,
+// nil side means the file does not exist there.
+func (d *DiffCommand) writeFileDiff(buf *bytes.Buffer, a, b *diffFile) error {
+	path := b.pathOr(a)
+
+	fmt.Fprintf(buf, "diff --git %s %s\n", quotePath("a/"+path), quotePath("b/"+path))
+
+	switch {
+	case a == nil:
+		fmt.Fprintf(buf, "new file mode %s\n", b.mode)
+	case b == nil:
+		fmt.Fprintf(buf, "deleted file mode %s\n", a.mode)
+	case a.mode != b.mode:
+		fmt.Fprintf(buf, "old mode %s\nnew mode %s\n", a.mode, b.mode)
+	}
+
+	// only the mode changed
+	if a != nil && b != nil && a.oid == b.oid {
+		return nil
+	}
+
+	aOID, err := d.shortOID(a)
+	if err != nil {
+		return err
+	}
+
+	bOID, err := d.shortOID(b)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(buf, "index %s..%s", aOID, bOID)
+
+	if a != nil && b != nil && a.mode == b.mode {
+		buf.WriteString(" " + a.mode)
+	}
+
+	buf.WriteString("\n")
+
+	aName, aData := "/dev/null", []byte(nil)
+	if a != nil {
+		aName, aData = quotePath("a/"+path), a.data
+	}
+
+	bName, bData := "/dev/null", []byte(nil)
+	if b != nil {
+		bName, bData = quotePath("b/"+path), b.data
+	}
+
+	if diff.Binary(aData) || diff.Binary(bData) {
+		fmt.Fprintf(buf, "Binary files %s and %s differ\n", aName, bName)
+
+		return nil
+	}
+
+	hunks := diff.Unified(aData, bData, d.options.Context)
+	// added or deleted empty file has no hunks
+	if hunks == "" {
+		return nil
+	}
+
+	fmt.Fprintf(buf, "--- %s\n+++ %s\n%s", fileHeaderName(aName), fileHeaderName(bName), hunks)
+
+	return nil
+}
+
+// fileHeaderName terminates names with spaces by a tab like git does, so the name end is unambiguous.
+func fileHeaderName(name string) string {
+	if strings.Contains(name, " ") {
+		return name + "\t"
+	}
+
+	return name
+}
+
+func (d *DiffCommand) shortOID(file *diffFile) (string, error) {
+	oid := database.NullOID
+	if file != nil {
+		oid = file.oid
+	}
+
+	short, err := d.repo.Database.ShortOID(oid)
+	if err != nil {
+		return "", fmt.Errorf("short oid %s: %w", oid, err)
+	}
+
+	return short, nil
+}
+
+// pathOr returns path of the file, or of the other side when the file does not exist.
+func (f *diffFile) pathOr(other *diffFile) string {
+	if f == nil {
+		return other.path
+	}
+
+	return f.path
+}
+
+func (d *DiffCommand) Output(msg []byte, err error, stdout io.Writer) (int, error) {
+	if err != nil {
+		var ambiguous *database.ErrAmbiguousObjectID
+		if errors.As(err, &ambiguous) {
+			writeAmbiguousObjectHint(stdout, d.repo, ambiguous)
+		}
+
+		if errors.Is(err, revision.ErrUnknownRevision) || errors.Is(err, revision.ErrInvalidRevision) || ambiguous != nil {
+			writeUnknownRevision(stdout, d.failed)
+
+			return 128, nil
+		}
+
+		return 1, fmt.Errorf("diff cmd: %w", err)
+	}
+
+	fmt.Fprint(stdout, string(msg))
+
+	return 0, nil
+}
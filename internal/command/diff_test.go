@@ -0,0 +1,274 @@
+package command_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/repository"
+)
+
+func TestDiffWorkspaceChanges(t *testing.T) {
+	t.Parallel()
+
+	lines := "one\ntwo\nthree\nfour\nfive\n"
+
+	tests := []struct {
+		name     string
+		args     []string
+		change   func(fs fstest.MapFS)
+		expected string
+	}{
+		{
+			name:     "clean workspace",
+			change:   func(fstest.MapFS) {},
+			expected: "",
+		},
+		{
+			name: "touched file with the same content",
+			change: func(fs fstest.MapFS) {
+				stat := defaultStat(0o644, 5)
+				stat.Mtim.Sec++
+
+				fs["tmp/test/hello.txt"].Sys = stat
+			},
+			expected: "",
+		},
+		{
+			name: "modified file",
+			change: func(fs fstest.MapFS) {
+				fs["tmp/test/hello.txt"] = &fstest.MapFile{Data: []byte("hello\nworld\n"), Mode: 0o644, Sys: defaultStat(0o644, 12)}
+			},
+			expected: "diff --git a/hello.txt b/hello.txt\n" +
+				"index b6fc4c6..94954ab 100644\n" +
+				"--- a/hello.txt\n" +
+				"+++ b/hello.txt\n" +
+				"@@ -1 +1,2 @@\n" +
+				"-hello\n" +
+				"\\ No newline at end of file\n" +
+				"+hello\n" +
+				"+world\n",
+		},
+		{
+			name: "deleted file",
+			change: func(fs fstest.MapFS) {
+				delete(fs, "tmp/test/docs/readme.md")
+			},
+			expected: "diff --git a/docs/readme.md b/docs/readme.md\n" +
+				"deleted file mode 100644\n" +
+				"index 5c457d7..0000000\n" +
+				"--- a/docs/readme.md\n" +
+				"+++ /dev/null\n" +
+				"@@ -1 +0,0 @@\n" +
+				"-docs\n" +
+				"\\ No newline at end of file\n",
+		},
+		{
+			name: "mode change",
+			change: func(fs fstest.MapFS) {
+				fs["tmp/test/world.txt"] = &fstest.MapFile{Data: []byte("world"), Mode: 0o755, Sys: defaultStat(0o755, 5)}
+			},
+			expected: "diff --git a/world.txt b/world.txt\n" +
+				"old mode 100644\n" +
+				"new mode 100755\n",
+		},
+		{
+			name: "mode and content change",
+			change: func(fs fstest.MapFS) {
+				fs["tmp/test/world.txt"] = &fstest.MapFile{Data: []byte("world\n"), Mode: 0o755, Sys: defaultStat(0o755, 6)}
+			},
+			expected: "diff --git a/world.txt b/world.txt\n" +
+				"old mode 100644\n" +
+				"new mode 100755\n" +
+				"index 04fea06..cc628cc\n" +
+				"--- a/world.txt\n" +
+				"+++ b/world.txt\n" +
+				"@@ -1 +1 @@\n" +
+				"-world\n" +
+				"\\ No newline at end of file\n" +
+				"+world\n",
+		},
+		{
+			name: "binary file",
+			change: func(fs fstest.MapFS) {
+				fs["tmp/test/hello.txt"] = &fstest.MapFile{Data: []byte("hel\x00lo"), Mode: 0o644, Sys: defaultStat(0o644, 6)}
+			},
+			expected: "diff --git a/hello.txt b/hello.txt\n" +
+				"index b6fc4c6..b419381 100644\n" +
+				"Binary files a/hello.txt and b/hello.txt differ\n",
+		},
+		{
+			name: "less context lines",
+			args: []string{"-U1"},
+			change: func(fs fstest.MapFS) {
+				fs["tmp/test/hello.txt"] = &fstest.MapFile{Data: []byte(lines + "six\n"), Mode: 0o644, Sys: defaultStat(0o644, 28)}
+			},
+			expected: "diff --git a/hello.txt b/hello.txt\n" +
+				"index b6fc4c6..b566061 100644\n" +
+				"--- a/hello.txt\n" +
+				"+++ b/hello.txt\n" +
+				"@@ -1 +1,6 @@\n" +
+				"-hello\n" +
+				"\\ No newline at end of file\n" +
+				"+one\n" +
+				"+two\n" +
+				"+three\n" +
+				"+four\n" +
+				"+five\n" +
+				"+six\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo, fs := committedStatusRepository(t)
+			tt.change(fs)
+
+			exit, out := runCmd(t, repo, "diff", tt.args...)
+			require.Equal(t, 0, exit)
+			require.Equal(t, tt.expected, out)
+		})
+	}
+}
+
+func TestDiffContextLines(t *testing.T) {
+	t.Parallel()
+
+	repo, fs := committedStatusRepository(t)
+
+	content := "one\ntwo\nthree\nfour\nfive\nsix\nseven\n"
+	fs["tmp/test/docs/readme.md"] = &fstest.MapFile{Data: []byte(content), Mode: 0o644, Sys: defaultStat(0o644, 34)}
+	require.NoError(t, repo.Add([]string{"docs/readme.md"}))
+
+	fs["tmp/test/docs/readme.md"] = &fstest.MapFile{Data: []byte("one\ntwo\nthree\n4\nfive\nsix\nseven\n"), Mode: 0o644, Sys: defaultStat(0o644, 31)}
+
+	header := "diff --git a/docs/readme.md b/docs/readme.md\n" +
+		"index 2019eda..301ee03 100644\n" +
+		"--- a/docs/readme.md\n" +
+		"+++ b/docs/readme.md\n"
+
+	exit, out := runCmd(t, repo, "diff")
+	require.Equal(t, 0, exit)
+	require.Equal(t, header+"@@ -1,7 +1,7 @@\n one\n two\n three\n-four\n+4\n five\n six\n seven\n", out)
+
+	exit, out = runCmd(t, repo, "diff", "--unified=0")
+	require.Equal(t, 0, exit)
+	require.Equal(t, header+"@@ -4 +4 @@ three\n-four\n+4\n", out)
+
+	exit, out = runCmd(t, repo, "diff", "-unified=0")
+	require.Equal(t, 0, exit)
+	require.Equal(t, header+"@@ -4 +4 @@ three\n-four\n+4\n", out)
+
+	exit, out = runCmd(t, repo, "diff", "-U", "1")
+	require.Equal(t, 0, exit)
+	require.Equal(t, header+"@@ -3,3 +3,3 @@ two\n three\n-four\n+4\n five\n", out)
+
+	exit, out = runCmd(t, repo, "diff", "-Ux")
+	require.Equal(t, 129, exit)
+	require.Contains(t, out, "usage: ggit diff")
+}
+
+func TestDiffStagedAndCommitChanges(t *testing.T) {
+	t.Parallel()
+
+	repo, fs := committedStatusRepository(t)
+
+	fs["tmp/test/hello.txt"] = &fstest.MapFile{Data: []byte("hello!"), Mode: 0o644, Sys: defaultStat(0o644, 6)}
+	fs["tmp/test/new.txt"] = &fstest.MapFile{Data: []byte("new\n"), Mode: 0o644, Sys: defaultStat(0o644, 4)}
+	require.NoError(t, repo.Add([]string{"hello.txt", "new.txt"}))
+
+	// unstaged change is not part of the staged diff
+	fs["tmp/test/world.txt"] = &fstest.MapFile{Data: []byte("world!"), Mode: 0o644, Sys: defaultStat(0o644, 6)}
+
+	patch := "diff --git a/hello.txt b/hello.txt\n" +
+		"index " + blobOID(t, "hello")[:7] + ".." + blobOID(t, "hello!")[:7] + " 100644\n" +
+		"--- a/hello.txt\n" +
+		"+++ b/hello.txt\n" +
+		"@@ -1 +1 @@\n" +
+		"-hello\n" +
+		"\\ No newline at end of file\n" +
+		"+hello!\n" +
+		"\\ No newline at end of file\n" +
+		"diff --git a/new.txt b/new.txt\n" +
+		"new file mode 100644\n" +
+		"index 0000000.." + blobOID(t, "new\n")[:7] + "\n" +
+		"--- /dev/null\n" +
+		"+++ b/new.txt\n" +
+		"@@ -0,0 +1 @@\n" +
+		"+new\n"
+
+	for _, args := range [][]string{{"--cached"}, {"--staged"}, {"--cached", "HEAD"}} {
+		exit, out := runCmd(t, repo, "diff", args...)
+		require.Equal(t, 0, exit, args)
+		require.Equal(t, patch, out, args)
+	}
+
+	_, err := repo.Commit("second", repository.CommitOptions{})
+	require.NoError(t, err)
+
+	exit, out := runCmd(t, repo, "diff", "--cached")
+	require.Equal(t, 0, exit)
+	require.Empty(t, out)
+
+	for _, args := range [][]string{{"HEAD~1", "HEAD"}, {"HEAD~1..HEAD"}, {"HEAD~1.."}, {"--cached", "HEAD~1"}} {
+		exit, out = runCmd(t, repo, "diff", args...)
+		require.Equal(t, 0, exit, args)
+		require.Equal(t, patch, out, args)
+	}
+
+	exit, out = runCmd(t, repo, "diff", "HEAD", "HEAD~1")
+	require.Equal(t, 0, exit)
+	require.Equal(t, "diff --git a/hello.txt b/hello.txt\n"+
+		"index "+blobOID(t, "hello!")[:7]+".."+blobOID(t, "hello")[:7]+" 100644\n"+
+		"--- a/hello.txt\n"+
+		"+++ b/hello.txt\n"+
+		"@@ -1 +1 @@\n"+
+		"-hello!\n"+
+		"\\ No newline at end of file\n"+
+		"+hello\n"+
+		"\\ No newline at end of file\n"+
+		"diff --git a/new.txt b/new.txt\n"+
+		"deleted file mode 100644\n"+
+		"index "+blobOID(t, "new\n")[:7]+"..0000000\n"+
+		"--- a/new.txt\n"+
+		"+++ /dev/null\n"+
+		"@@ -1 +0,0 @@\n"+
+		"-new\n", out)
+
+	exit, out = runCmd(t, repo, "diff", "HEAD", "HEAD")
+	require.Equal(t, 0, exit)
+	require.Empty(t, out)
+}
+
+func TestDiffInvalidRevisions(t *testing.T) {
+	t.Parallel()
+
+	repo, _ := committedStatusRepository(t)
+
+	unknown := func(rev string) string {
+		return "fatal: ambiguous argument '" + rev + "': unknown revision or path not in the working tree.\n" +
+			"Use '--' to separate paths from revisions, like this:\n" +
+			"'ggit <command> [<revision>...] -- [<file>...]'\n"
+	}
+
+	exit, out := runCmd(t, repo, "diff", "HEAD", "nope")
+	require.Equal(t, 128, exit)
+	require.Equal(t, unknown("nope"), out)
+
+	exit, out = runCmd(t, repo, "diff", "nope..HEAD")
+	require.Equal(t, 128, exit)
+	require.Equal(t, unknown("nope..HEAD"), out)
+
+	exit, out = runCmd(t, repo, "diff", "--cached", "nope")
+	require.Equal(t, 128, exit)
+	require.Equal(t, unknown("nope"), out)
+
+	for _, args := range [][]string{{"HEAD"}, {"--cached", "HEAD", "HEAD"}, {"HEAD", "HEAD", "HEAD"}} {
+		exit, out = runCmd(t, repo, "diff", args...)
+		require.Equal(t, 129, exit, args)
+		require.Contains(t, out, "usage: ggit diff", args)
+	}
+}
@@ -0,0 +1,417 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/repository"
+)
+
+const defaultRefFormat = "%(objectname) %(objecttype)\t%(refname)"
+
+var (
+	errUnknownField   = errors.New("unknown field name")
+	errUnknownSortKey = errors.New("unsupported sort specification")
+)
+
+// refAtoms are fields supported in --format, see refField.
+var refAtoms = map[string]bool{
+	"refname":          true,
+	"refname:short":    true,
+	"objectname":       true,
+	"objectname:short": true,
+	"objecttype":       true,
+	"subject":          true,
+	"upstream":         true,
+	"upstream:short":   true,
+	"HEAD":             true,
+}
+
+type ForEachRefOptions struct {
+	// Format of each line, %(atom) is replaced by value of the ref field and %% by percent sign.
+	Format string
+	// Sort keys, the last one is the primary key. Prefix - reverses the order.
+	Sort []string
+	// Count limits number of printed refs, zero means no limit.
+	Count int
+}
+
+// ForEachRefCommand prints refs matching patterns in a given format.
+type ForEachRefCommand struct {
+	repository *repository.Repository
+	patterns   []string
+	options    ForEachRefOptions
+
+	failed string
+}
+
+// refInfo is ref with the lazily loaded object it points to.
+type refInfo struct {
+	ref        *database.Ref
+	objectType string
+	commit     *database.Commit
+	tag        *database.Tag
+}
+
+// formatToken is either literal text or atom of the format.
+type formatToken struct {
+	literal string
+	atom    string
+}
+
+func NewForEachRefCommand(
+	repo *repository.Repository,
+	patterns []string,
+	options ForEachRefOptions,
+) (*ForEachRefCommand, error) {
+	if repo == nil {
+		return nil, errors.New("repository is nil")
+	}
+
+	if options.Format == "" {
+		options.Format = defaultRefFormat
+	}
+
+	if len(options.Sort) == 0 {
+		options.Sort = []string{"refname"}
+	}
+
+	return &ForEachRefCommand{
+		repository: repo,
+		patterns:   patterns,
+		options:    options,
+	}, nil
+}
+
+func (f *ForEachRefCommand) Run() ([]byte, error) {
+	format, err := f.parseFormat()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range f.options.Sort {
+		name := strings.TrimPrefix(key, "-")
+
+		if _, ok := refSortKeys[name]; !ok {
+			if !refAtoms[name] {
+				f.failed = name
+
+				return nil, fmt.Errorf("%w: %s", errUnknownField, name)
+			}
+
+			f.failed = key
+
+			return nil, fmt.Errorf("%w: %s", errUnknownSortKey, key)
+		}
+	}
+
+	refs, err := f.repository.Refs.ListRefs("refs")
+	if err != nil {
+		return nil, fmt.Errorf("list refs: %w", err)
+	}
+
+	infos := make([]*refInfo, 0, len(refs))
+
+	for _, ref := range refs {
+		if !matchRefPattern(f.patterns, ref.Name) {
+			continue
+		}
+
+		info, err := f.load(ref)
+		if err != nil {
+			return nil, err
+		}
+
+		infos = append(infos, info)
+	}
+
+	f.sort(infos)
+
+	if f.options.Count > 0 && len(infos) > f.options.Count {
+		infos = infos[:f.options.Count]
+	}
+
+	head, err := f.repository.Refs.Head()
+	if err != nil {
+		return nil, fmt.Errorf("read HEAD: %w", err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	for _, info := range infos {
+		for _, token := range format {
+			if token.atom == "" {
+				buf.WriteString(token.literal)
+
+				continue
+			}
+
+			value, err := f.field(info, token.atom, head)
+			if err != nil {
+				return nil, err
+			}
+
+			buf.WriteString(value)
+		}
+
+		buf.WriteString("\n")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (f *ForEachRefCommand) parseFormat() ([]formatToken, error) {
+	var tokens []formatToken
+
+	format := f.options.Format
+
+	for format != "" {
+		i := strings.Index(format, "%")
+		if i == -1 {
+			tokens = append(tokens, formatToken{literal: format})
+
+			break
+		}
+
+		tokens = append(tokens, formatToken{literal: format[:i]})
+		format = format[i:]
+
+		switch {
+		case strings.HasPrefix(format, "%%"):
+			tokens = append(tokens, formatToken{literal: "%"})
+			format = format[2:]
+		case strings.HasPrefix(format, "%("):
+			end := strings.Index(format, ")")
+			if end == -1 {
+				f.failed = format
+
+				return nil, fmt.Errorf("%w: %s", errUnknownField, format)
+			}
+
+			atom := format[2:end]
+			if !refAtoms[atom] {
+				f.failed = atom
+
+				return nil, fmt.Errorf("%w: %s", errUnknownField, atom)
+			}
+
+			tokens = append(tokens, formatToken{atom: atom})
+			format = format[end+1:]
+		default:
+			tokens = append(tokens, formatToken{literal: "%"})
+			format = format[1:]
+		}
+	}
+
+	return tokens, nil
+}
+
+func (f *ForEachRefCommand) load(ref *database.Ref) (*refInfo, error) {
+	info := &refInfo{ref: ref}
+
+	raw, err := f.repository.Database.ReadObject(ref.OID)
+	if err != nil {
+		return nil, fmt.Errorf("read object %s of %s: %w", ref.OID, ref.Name, err)
+	}
+
+	info.objectType = raw.Type
+
+	switch raw.Type {
+	case database.CommitType:
+		if info.commit, err = f.repository.Database.LoadCommit(ref.OID); err != nil {
+			return nil, fmt.Errorf("load commit %s: %w", ref.OID, err)
+		}
+	case database.TagType:
+		if info.tag, err = f.repository.Database.LoadTag(ref.OID); err != nil {
+			return nil, fmt.Errorf("load tag %s: %w", ref.OID, err)
+		}
+	}
+
+	return info, nil
+}
+
+func (f *ForEachRefCommand) field(info *refInfo, atom string, head *database.Head) (string, error) {
+	switch atom {
+	case "refname":
+		return info.ref.Name, nil
+	case "refname:short":
+		return info.ref.ShortName(), nil
+	case "objectname":
+		return info.ref.OID, nil
+	case "objectname:short":
+		short, err := f.repository.Database.ShortOID(info.ref.OID)
+		if err != nil {
+			return "", fmt.Errorf("abbreviate %s: %w", info.ref.OID, err)
+		}
+
+		return short, nil
+	case "objecttype":
+		return info.objectType, nil
+	case "subject":
+		switch {
+		case info.commit != nil:
+			return paragraphSubject(info.commit.Message), nil
+		case info.tag != nil:
+			return paragraphSubject(info.tag.Message), nil
+		}
+
+		return "", nil
+	case "upstream", "upstream:short":
+		upstream, err := f.repository.Upstream(info.ref.Name)
+		if err != nil || upstream == "" || atom == "upstream" {
+			return upstream, err
+		}
+
+		return (&database.Ref{Name: upstream}).ShortName(), nil
+	case "HEAD":
+		if !head.Detached() && head.Ref == info.ref.Name {
+			return "*", nil
+		}
+
+		return " ", nil
+	}
+
+	return "", fmt.Errorf("%w: %s", errUnknownField, atom)
+}
+
+// paragraphSubject joins lines of the first message paragraph, like git does for %(subject).
+func paragraphSubject(message string) string {
+	paragraph, _, _ := strings.Cut(strings.TrimLeft(message, "\n"), "\n\n")
+
+	return strings.Join(strings.Fields(paragraph), " ")
+}
+
+// refSortKeys compare two refs by the key, they return negative number when a sorts before b.
+var refSortKeys = map[string]func(a, b *refInfo) int{
+	"refname": func(a, b *refInfo) int {
+		return strings.Compare(a.ref.Name, b.ref.Name)
+	},
+	"version:refname": func(a, b *refInfo) int {
+		return compareVersions(a.ref.Name, b.ref.Name)
+	},
+	"committerdate": func(a, b *refInfo) int {
+		return int(committerUnix(a) - committerUnix(b))
+	},
+	"objecttype": func(a, b *refInfo) int {
+		return strings.Compare(a.objectType, b.objectType)
+	},
+}
+
+func (f *ForEachRefCommand) sort(infos []*refInfo) {
+	sort.SliceStable(infos, func(i, j int) bool {
+		// the last key is the primary one
+		for k := len(f.options.Sort) - 1; k >= 0; k-- {
+			key, reverse := strings.CutPrefix(f.options.Sort[k], "-")
+
+			cmp := refSortKeys[key](infos[i], infos[j])
+			if cmp == 0 {
+				continue
+			}
+
+			return (cmp < 0) != reverse
+		}
+
+		return infos[i].ref.Name < infos[j].ref.Name
+	})
+}
+
+func committerUnix(info *refInfo) int64 {
+	if info.commit == nil {
+		return 0
+	}
+
+	return info.commit.Committer.Now.Unix()
+}
+
+// compareVersions compares strings treating runs of digits as numbers, so v1.9 sorts before v1.10.
+func compareVersions(a, b string) int {
+	for a != "" && b != "" {
+		aDigits, bDigits := isDigit(a[0]), isDigit(b[0])
+
+		if !aDigits || !bDigits {
+			if a[0] != b[0] {
+				return int(a[0]) - int(b[0])
+			}
+
+			a, b = a[1:], b[1:]
+
+			continue
+		}
+
+		aNum, aRest := splitDigits(a)
+		bNum, bRest := splitDigits(b)
+
+		aNum = strings.TrimLeft(aNum, "0")
+		bNum = strings.TrimLeft(bNum, "0")
+
+		if len(aNum) != len(bNum) {
+			return len(aNum) - len(bNum)
+		}
+
+		if cmp := strings.Compare(aNum, bNum); cmp != 0 {
+			return cmp
+		}
+
+		a, b = aRest, bRest
+	}
+
+	return len(a) - len(b)
+}
+
+func splitDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+
+	return s[:i], s[i:]
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// matchRefPattern reports whether ref matches any pattern, either as a prefix ending at slash or as a glob.
+// Refs always match when there are no patterns.
+func matchRefPattern(patterns []string, ref string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		if rest, ok := strings.CutPrefix(ref, pattern); ok && (rest == "" || strings.HasSuffix(pattern, "/") || rest[0] == '/') {
+			return true
+		}
+
+		if ok, _ := path.Match(pattern, ref); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *ForEachRefCommand) Output(msg []byte, err error, stdout io.Writer) (int, error) {
+	if err != nil {
+		switch {
+		case errors.Is(err, errUnknownField):
+			fmt.Fprintf(stdout, "fatal: unknown field name: %s\n", f.failed)
+		case errors.Is(err, errUnknownSortKey):
+			fmt.Fprintf(stdout, "fatal: unsupported sort specification '%s'\n", f.failed)
+		default:
+			return 1, fmt.Errorf("for-each-ref cmd: %w", err)
+		}
+
+		return 128, nil
+	}
+
+	fmt.Fprint(stdout, string(msg))
+
+	return 0, nil
+}
@@ -0,0 +1,143 @@
+package command_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestForEachRef(t *testing.T) {
+	t.Parallel()
+
+	repo := committedRepository(t)
+
+	head, err := repo.Refs.ReadHead()
+	require.NoError(t, err)
+
+	root, err := repo.Database.LoadCommit(head)
+	require.NoError(t, err)
+
+	// older commit than the one on master, so committerdate order differs from refname order
+	old := storeCommit(t, repo, root.RootOID, nil, "old\nwrapped subject\n\nbody", time.Unix(900000000, 0))
+	require.NoError(t, repo.Refs.CreateRef("refs/heads/zz-old", old))
+
+	for _, args := range [][]string{{"v1.9"}, {"v1.10"}, {"-m", "release\n\nnotes", "v2.0"}} {
+		exit, _ := runCmd(t, repo, "tag", args...)
+		require.Equal(t, 0, exit)
+	}
+
+	annotated, err := repo.Refs.ReadRef("v2.0")
+	require.NoError(t, err)
+
+	// packed refs are listed together with loose ones
+	exit, _ := runCmd(t, repo, "pack-refs")
+	require.Equal(t, 0, exit)
+
+	require.NoError(t, repo.FS.WriteFile(filepath.Join(repo.GitPath, "config"), []byte(
+		"[remote \"origin\"]\n"+
+			"\tfetch = +refs/heads/*:refs/remotes/origin/*\n"+
+			"[branch \"master\"]\n"+
+			"\tremote = origin\n"+
+			"\tmerge = refs/heads/master\n"+
+			"[branch \"zz-old\"]\n"+
+			"\tremote = .\n"+
+			"\tmerge = refs/heads/master\n",
+	), 0o644))
+
+	tests := []struct {
+		name     string
+		args     []string
+		exit     int
+		expected string
+	}{
+		{
+			name: "default format",
+			exit: 0,
+			expected: head + " commit\trefs/heads/master\n" +
+				old + " commit\trefs/heads/zz-old\n" +
+				head + " commit\trefs/tags/v1.10\n" +
+				head + " commit\trefs/tags/v1.9\n" +
+				annotated + " tag\trefs/tags/v2.0\n",
+		},
+		{
+			name:     "patterns",
+			args:     []string{"--format=%(refname)", "refs/heads/m*", "refs/tags/v2.0"},
+			exit:     0,
+			expected: "refs/heads/master\nrefs/tags/v2.0\n",
+		},
+		{
+			name:     "prefix pattern",
+			args:     []string{"--format=%(refname:short)", "refs/heads"},
+			exit:     0,
+			expected: "master\nzz-old\n",
+		},
+		{
+			name:     "version sort and count",
+			args:     []string{"--sort=-version:refname", "--count=2", "--format=%(refname:short)", "refs/tags"},
+			exit:     0,
+			expected: "v2.0\nv1.10\n",
+		},
+		{
+			name:     "committerdate sort",
+			args:     []string{"--sort=committerdate", "--format=%(refname:short)", "refs/heads"},
+			exit:     0,
+			expected: "zz-old\nmaster\n",
+		},
+		{
+			name:     "multiple sort keys",
+			args:     []string{"--sort=refname", "--sort=-objecttype", "--format=%(objecttype) %(refname:short)", "refs/tags"},
+			exit:     0,
+			expected: "tag v2.0\ncommit v1.10\ncommit v1.9\n",
+		},
+		{
+			name: "format atoms",
+			args: []string{"--format=%(HEAD)%(refname:short) %(objectname:short) %(subject) [%(upstream:short)] %%", "refs/heads", "refs/tags/v2.0"},
+			exit: 0,
+			expected: "*master " + head[:7] + " all [origin/master] %\n" +
+				" zz-old " + old[:7] + " old wrapped subject [master] %\n" +
+				" v2.0 " + annotated[:7] + " release [] %\n",
+		},
+		{
+			name:     "upstream",
+			args:     []string{"--format=%(upstream)", "refs/heads"},
+			exit:     0,
+			expected: "refs/remotes/origin/master\nrefs/heads/master\n",
+		},
+		{
+			name:     "unknown field",
+			args:     []string{"--format=%(bogus)"},
+			exit:     128,
+			expected: "fatal: unknown field name: bogus\n",
+		},
+		{
+			name:     "unknown sort key",
+			args:     []string{"--sort=-bogus"},
+			exit:     128,
+			expected: "fatal: unknown field name: bogus\n",
+		},
+		{
+			name:     "unsupported sort key",
+			args:     []string{"--sort=subject"},
+			exit:     128,
+			expected: "fatal: unsupported sort specification 'subject'\n",
+		},
+		{
+			name:     "invalid count",
+			args:     []string{"--count=-1"},
+			exit:     129,
+			expected: "usage: ggit for-each-ref [--count=<count>] [--sort=<key>]... [--format=<format>] [<pattern>...]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			exit, out := runCmd(t, repo, "for-each-ref", tt.args...)
+			require.Equal(t, tt.exit, exit)
+			require.Equal(t, tt.expected, out)
+		})
+	}
+}
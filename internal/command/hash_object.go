@@ -0,0 +1,129 @@
+package command
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/repository"
+)
+
+var ErrInvalidObject = errors.New("object fails validation")
+
+// HashObjectCommand computes object ids of files or stdin and optionally stores them.
+type HashObjectCommand struct {
+	repository *repository.Repository
+	objectType string
+	write      bool
+	stdin      io.Reader
+	paths      []string
+}
+
+func NewHashObjectCommand(
+	repo *repository.Repository,
+	objectType string,
+	write bool,
+	stdin io.Reader,
+	paths []string,
+) (*HashObjectCommand, error) {
+	if repo == nil {
+		return nil, errors.New("repository is nil")
+	}
+
+	switch objectType {
+	case database.BlobType, database.TreeType, database.CommitType, database.TagType:
+	default:
+		return nil, fmt.Errorf("unsupported object type %q", objectType)
+	}
+
+	if stdin == nil && len(paths) == 0 {
+		return nil, errors.New("paths is empty")
+	}
+
+	return &HashObjectCommand{
+		repository: repo,
+		objectType: objectType,
+		write:      write,
+		stdin:      stdin,
+		paths:      paths,
+	}, nil
+}
+
+func (h *HashObjectCommand) Run() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+
+	if h.stdin != nil {
+		content, err := io.ReadAll(h.stdin)
+		if err != nil {
+			return nil, fmt.Errorf("read stdin: %w", err)
+		}
+
+		oid, err := h.hash(content)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.WriteString(oid + "\n")
+	}
+
+	for _, path := range h.paths {
+		content, err := h.repository.FS.ReadFile(filepath.Join(h.repository.Cwd, path))
+		if err != nil {
+			return nil, fmt.Errorf("could not open '%s' for reading: %w", path, err)
+		}
+
+		oid, err := h.hash(content)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.WriteString(oid + "\n")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (h *HashObjectCommand) hash(content []byte) (string, error) {
+	if err := database.Validate(h.objectType, content); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidObject, err)
+	}
+
+	obj := database.NewRawObject(h.objectType, content)
+
+	if !h.write {
+		oid, err := database.Hash(obj)
+		if err != nil {
+			return "", fmt.Errorf("hash object: %w", err)
+		}
+
+		return hex.EncodeToString(oid), nil
+	}
+
+	oid, err := h.repository.Database.Store(obj)
+	if err != nil {
+		return "", fmt.Errorf("store object: %w", err)
+	}
+
+	return hex.EncodeToString(oid), nil
+}
+
+func (h *HashObjectCommand) Output(msg []byte, err error, stdout io.Writer) (int, error) {
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, ErrInvalidObject) {
+			fmt.Fprintf(stdout, "fatal: %s\n", err.Error())
+
+			return 128, nil
+		}
+
+		return 1, fmt.Errorf("hash-object cmd: %w", err)
+	}
+
+	fmt.Fprint(stdout, string(msg))
+
+	return 0, nil
+}
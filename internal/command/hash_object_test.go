@@ -0,0 +1,76 @@
+package command_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/command"
+)
+
+func TestHashObject(t *testing.T) {
+	t.Parallel()
+
+	helloOID := "b6fc4c620b67d95f953a5c1c1230aaab5db5a1b0"
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		exit   int
+		output string
+		stored bool
+	}{
+		{
+			name:   "hash file without writing",
+			args:   []string{"hello.txt"},
+			output: helloOID + "\n",
+		},
+		{
+			name:   "hash and write stdin",
+			args:   []string{"-w", "--stdin"},
+			stdin:  "hello",
+			output: helloOID + "\n",
+			stored: true,
+		},
+		{
+			name:   "stdin is hashed before files",
+			args:   []string{"--stdin", "world.txt"},
+			stdin:  "hello",
+			output: helloOID + "\n04fea06420ca60892f73becee3614f6d023a4b7f\n",
+		},
+		{
+			name:   "invalid commit",
+			args:   []string{"-t", "commit", "--stdin"},
+			stdin:  "hello",
+			exit:   128,
+			output: "fatal: object fails validation: invalid commit: commit message separator not found\n",
+		},
+		{
+			name:   "missing file",
+			args:   []string{"missing.txt"},
+			exit:   128,
+			output: "fatal: could not open 'missing.txt' for reading: open tmp/test/missing.txt: file does not exist\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := committedRepository(t)
+			buf := bytes.NewBuffer(nil)
+
+			exit, err := command.NewRunner(repo, strings.NewReader(tt.stdin)).RunCmd(t.Context(), "hash-object", tt.args, buf)
+			require.NoError(t, err)
+			require.Equal(t, tt.exit, exit)
+			require.Equal(t, tt.output, buf.String())
+
+			if tt.stored {
+				require.True(t, repo.Database.Exists(helloOID))
+			}
+		})
+	}
+}
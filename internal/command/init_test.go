@@ -27,7 +27,7 @@ func TestInitNewRepository(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, repo)
 
-	runner := command.NewRunner(repo)
+	runner := command.NewRunner(repo, nil)
 
 	osExit, err := runner.RunCmd(t.Context(), "init", []string{}, io.Discard)
 
@@ -64,7 +64,7 @@ func TestInitAlreadyInitializedRepository(t *testing.T) {
 	)
 	require.NoError(t, err)
 
-	runner := command.NewRunner(repo)
+	runner := command.NewRunner(repo, nil)
 
 	buf := bytes.NewBuffer(nil)
 	_, _ = runner.RunCmd(t.Context(), "init", []string{}, buf)
@@ -0,0 +1,166 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/repository"
+	"github.com/LukasJenicek/ggit/internal/revision"
+)
+
+const logDateFormat = "Mon Jan 2 15:04:05 2006 -0700"
+
+// LogCommand shows commit history reachable from HEAD or given revision.
+type LogCommand struct {
+	repository *repository.Repository
+	revision   string
+	oneline    bool
+	limit      int
+}
+
+func NewLogCommand(repo *repository.Repository, revision string, oneline bool, limit int) (*LogCommand, error) {
+	if repo == nil {
+		return nil, errors.New("repository is nil")
+	}
+
+	if revision == "" {
+		revision = "HEAD"
+	}
+
+	return &LogCommand{
+		repository: repo,
+		revision:   revision,
+		oneline:    oneline,
+		limit:      limit,
+	}, nil
+}
+
+func (l *LogCommand) Run() ([]byte, error) {
+	start, err := l.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := l.repository.Log([]string{start}, l.limit)
+	if err != nil {
+		return nil, fmt.Errorf("walk history: %w", err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	for i, commit := range commits {
+		if l.oneline {
+			short, err := l.repository.Database.ShortOID(commit.OID)
+			if err != nil {
+				return nil, fmt.Errorf("abbreviate commit id: %w", err)
+			}
+
+			fmt.Fprintf(buf, "%s %s\n", short, subject(commit.Message))
+
+			continue
+		}
+
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+
+		fmt.Fprintf(buf, "commit %s\n", commit.OID)
+
+		if len(commit.Parents) > 1 {
+			abbrevs := make([]string, 0, len(commit.Parents))
+			for _, parent := range commit.Parents {
+				short, err := l.repository.Database.ShortOID(parent)
+				if err != nil {
+					return nil, fmt.Errorf("abbreviate parent id: %w", err)
+				}
+
+				abbrevs = append(abbrevs, short)
+			}
+
+			fmt.Fprintf(buf, "Merge: %s\n", strings.Join(abbrevs, " "))
+		}
+
+		fmt.Fprintf(buf, "Author: %s <%s>\n", commit.Author.Name, commit.Author.Email)
+		fmt.Fprintf(buf, "Date:   %s\n\n", commit.Author.Now.Format(logDateFormat))
+
+		for _, line := range strings.Split(commit.Message, "\n") {
+			if line == "" {
+				buf.WriteString("\n")
+
+				continue
+			}
+
+			fmt.Fprintf(buf, "    %s\n", line)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (l *LogCommand) resolve() (string, error) {
+	if l.revision == "HEAD" {
+		head, err := l.repository.Refs.ReadHead()
+		if err != nil {
+			return "", fmt.Errorf("read head: %w", err)
+		}
+
+		if head == "" {
+			return "", repository.ErrNoCommits
+		}
+	}
+
+	resolver, err := revision.New(l.repository.Database, l.repository.Refs, l.repository.Clock)
+	if err != nil {
+		return "", fmt.Errorf("init revision resolver: %w", err)
+	}
+
+	oid, err := resolver.Resolve(l.revision)
+	if err != nil {
+		return "", fmt.Errorf("resolve revision: %w", err)
+	}
+
+	return resolver.Peel(oid, database.CommitType)
+}
+
+func (l *LogCommand) Output(msg []byte, err error, stdout io.Writer) (int, error) {
+	if err != nil {
+		if errors.Is(err, repository.ErrNoCommits) {
+			ref, refErr := l.repository.Refs.CurrentRef()
+			if refErr != nil {
+				return 1, fmt.Errorf("read current ref: %w", refErr)
+			}
+
+			fmt.Fprintf(stdout, "fatal: your current branch '%s' does not have any commits yet\n", ref)
+
+			return 128, nil
+		}
+
+		var ambiguous *database.ErrAmbiguousObjectID
+		if errors.As(err, &ambiguous) {
+			writeAmbiguousObjectHint(stdout, l.repository, ambiguous)
+		}
+
+		if errors.Is(err, revision.ErrUnknownRevision) || errors.Is(err, revision.ErrInvalidRevision) || ambiguous != nil {
+			writeUnknownRevision(stdout, l.revision)
+
+			return 128, nil
+		}
+
+		return 1, fmt.Errorf("log cmd: %w", err)
+	}
+
+	fmt.Fprint(stdout, string(msg))
+
+	return 0, nil
+}
+
+// subject returns the first line of commit message.
+func subject(message string) string {
+	line, _, _ := strings.Cut(message, "\n")
+
+	return line
+}
@@ -0,0 +1,102 @@
+package command_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/clock"
+	"github.com/LukasJenicek/ggit/internal/command"
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/filesystem/memory"
+	"github.com/LukasJenicek/ggit/internal/repository"
+)
+
+func TestLog(t *testing.T) {
+	t.Parallel()
+
+	repo := committedRepository(t)
+
+	head, err := repo.Refs.ReadHead()
+	require.NoError(t, err)
+
+	root, err := repo.Database.LoadCommit(head)
+	require.NoError(t, err)
+
+	second := storeCommit(t, repo, root.RootOID, []string{head}, "second\n\nwith body", time.Unix(2000000000, 0))
+	third := storeCommit(t, repo, root.RootOID, []string{second}, "third", time.Unix(2000000100, 0))
+	require.NoError(t, repo.Refs.UpdateHead(third, nil, ""))
+
+	t.Run("oneline", func(t *testing.T) {
+		t.Parallel()
+
+		buf := bytes.NewBuffer(nil)
+
+		exit, err := command.NewRunner(repo, nil).RunCmd(t.Context(), "log", []string{"--oneline"}, buf)
+		require.NoError(t, err)
+		require.Equal(t, 0, exit)
+		require.Equal(t, third[:7]+" third\n"+second[:7]+" second\n"+head[:7]+" all\n", buf.String())
+	})
+
+	t.Run("limit and revision", func(t *testing.T) {
+		t.Parallel()
+
+		buf := bytes.NewBuffer(nil)
+
+		exit, err := command.NewRunner(repo, nil).RunCmd(t.Context(), "log", []string{"-n", "1", second}, buf)
+		require.NoError(t, err)
+		require.Equal(t, 0, exit)
+		require.Equal(t, "commit "+second+"\n"+
+			"Author: Lukas Jenicek <lukas.jenicek5@gmail.com>\n"+
+			"Date:   Wed May 18 05:33:20 2033 +0200\n"+
+			"\n"+
+			"    second\n"+
+			"\n"+
+			"    with body\n", buf.String())
+	})
+}
+
+func TestLogWithoutCommits(t *testing.T) {
+	t.Parallel()
+
+	repo, err := repository.New(
+		memory.New(fstest.MapFS{}),
+		clock.NewFakeClock(time.Date(2000, 12, 15, 17, 8, 0o0, 0, time.UTC)),
+		"tmp/test",
+	)
+	require.NoError(t, err)
+	require.NoError(t, repo.Init())
+
+	buf := bytes.NewBuffer(nil)
+
+	exit, err := command.NewRunner(repo, nil).RunCmd(t.Context(), "log", nil, buf)
+	require.NoError(t, err)
+	require.Equal(t, 128, exit)
+	require.Equal(t, "fatal: your current branch 'master' does not have any commits yet\n", buf.String())
+}
+
+func storeCommit(
+	t *testing.T,
+	repo *repository.Repository,
+	tree string,
+	parents []string,
+	message string,
+	date time.Time,
+) string {
+	t.Helper()
+
+	now := date.In(time.FixedZone("", 2*3600))
+	author := database.NewAuthor("lukas.jenicek5@gmail.com", "Lukas Jenicek", &now)
+
+	commit, err := database.NewCommit(parents, tree, author, author, message)
+	require.NoError(t, err)
+
+	oid, err := repo.Database.Store(commit)
+	require.NoError(t, err)
+
+	return hex.EncodeToString(oid)
+}
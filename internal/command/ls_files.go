@@ -0,0 +1,153 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/index"
+	"github.com/LukasJenicek/ggit/internal/repository"
+)
+
+type LsFilesOptions struct {
+	// Show mode, object id and stage number of tracked files.
+	Stage bool
+	// Dump stat data stored in the index after each path.
+	Debug bool
+	// Show tracked files missing in the workspace.
+	Deleted bool
+	// Show tracked files which differ from the workspace.
+	Modified bool
+	// Show untracked files.
+	Others bool
+}
+
+// LsFilesCommand shows information about files in the index and the workspace.
+type LsFilesCommand struct {
+	repository *repository.Repository
+	options    LsFilesOptions
+}
+
+func NewLsFilesCommand(repo *repository.Repository, options LsFilesOptions) (*LsFilesCommand, error) {
+	if repo == nil {
+		return nil, errors.New("repository is nil")
+	}
+
+	return &LsFilesCommand{
+		repository: repo,
+		options:    options,
+	}, nil
+}
+
+func (l *LsFilesCommand) Run() ([]byte, error) {
+	idx, err := l.repository.Index.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load index: %w", err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	if l.options.Others {
+		files, err := l.repository.Workspace.ListFiles()
+		if err != nil {
+			return nil, fmt.Errorf("list workspace files: %w", err)
+		}
+
+		for _, file := range files {
+			if _, tracked := idx.Entries.Get(file); !tracked {
+				buf.WriteString(file + "\n")
+			}
+		}
+	}
+
+	cached := l.options.Stage || (!l.options.Deleted && !l.options.Modified && !l.options.Others)
+
+	for _, entry := range idx.Entries.SortedValues() {
+		if cached {
+			l.writeEntry(buf, entry)
+		}
+
+		if !l.options.Deleted && !l.options.Modified {
+			continue
+		}
+
+		stat, err := l.repository.FS.Stat(filepath.Join(l.repository.RootDir, string(entry.Path)))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("stat %s: %w", entry.Path, err)
+		}
+
+		deleted := stat == nil || stat.IsDir()
+
+		if deleted && l.options.Deleted {
+			l.writeEntry(buf, entry)
+		}
+
+		if !l.options.Modified {
+			continue
+		}
+
+		modified := deleted
+		if !deleted {
+			modified, err = l.modified(entry, stat)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if modified {
+			l.writeEntry(buf, entry)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (l *LsFilesCommand) modified(entry *index.Entry, stat os.FileInfo) (bool, error) {
+	if index.ModeFromFileInfo(stat) != entry.Mode || uint32(stat.Size()) != entry.FileSize { //nolint:gosec
+		return true, nil
+	}
+
+	content, err := l.repository.FS.ReadFile(filepath.Join(l.repository.RootDir, string(entry.Path)))
+	if err != nil {
+		return false, fmt.Errorf("read %s: %w", entry.Path, err)
+	}
+
+	oid, err := database.Hash(database.NewBlob(content))
+	if err != nil {
+		return false, fmt.Errorf("hash %s: %w", entry.Path, err)
+	}
+
+	return !bytes.Equal(oid, entry.OID), nil
+}
+
+func (l *LsFilesCommand) writeEntry(buf *bytes.Buffer, entry *index.Entry) {
+	if l.options.Stage {
+		fmt.Fprintf(buf, "%06o %x %d\t%s\n", entry.Mode, entry.OID, entry.Stage(), entry.Path)
+	} else {
+		fmt.Fprintf(buf, "%s\n", entry.Path)
+	}
+
+	if !l.options.Debug {
+		return
+	}
+
+	fmt.Fprintf(buf, "  ctime: %d:%d\n", entry.Ctime, entry.CtimeNsec)
+	fmt.Fprintf(buf, "  mtime: %d:%d\n", entry.Mtime, entry.MtimeNsec)
+	fmt.Fprintf(buf, "  dev: %d\tino: %d\n", entry.Dev, entry.Inode)
+	fmt.Fprintf(buf, "  uid: %d\tgid: %d\n", entry.UID, entry.GID)
+	fmt.Fprintf(buf, "  size: %d\tflags: %x\n", entry.FileSize, entry.Flags&^0xfff)
+}
+
+func (l *LsFilesCommand) Output(msg []byte, err error, stdout io.Writer) (int, error) {
+	if err != nil {
+		return 1, fmt.Errorf("ls-files cmd: %w", err)
+	}
+
+	fmt.Fprint(stdout, string(msg))
+
+	return 0, nil
+}
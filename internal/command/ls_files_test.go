@@ -0,0 +1,112 @@
+package command_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/clock"
+	"github.com/LukasJenicek/ggit/internal/command"
+	"github.com/LukasJenicek/ggit/internal/filesystem/memory"
+	"github.com/LukasJenicek/ggit/internal/repository"
+)
+
+func TestLsFiles(t *testing.T) {
+	t.Parallel()
+
+	fs := fstest.MapFS{
+		"tmp/test/": &fstest.MapFile{
+			Mode: os.ModeDir,
+		},
+		"tmp/test/hello.txt": &fstest.MapFile{
+			Data: []byte("hello"),
+			Mode: 0o644,
+			Sys:  defaultStat(0o644, 5),
+		},
+		"tmp/test/lib/world.txt": &fstest.MapFile{
+			Data: []byte("world"),
+			Mode: 0o644,
+			Sys:  defaultStat(0o644, 5),
+		},
+		"tmp/test/run.sh": &fstest.MapFile{
+			Data: []byte("run"),
+			Mode: 0o755,
+			Sys:  defaultStat(0o755, 3),
+		},
+	}
+
+	repo, err := repository.New(
+		memory.New(fs),
+		clock.NewFakeClock(time.Date(2000, 12, 15, 17, 8, 0o0, 0, time.UTC)),
+		"tmp/test",
+	)
+	require.NoError(t, err)
+	require.NoError(t, repo.Init())
+	require.NoError(t, repo.Add([]string{"."}))
+
+	delete(fs, "tmp/test/hello.txt")
+	fs["tmp/test/lib/world.txt"] = &fstest.MapFile{
+		Data: []byte("earth"),
+		Mode: 0o644,
+		Sys:  defaultStat(0o644, 5),
+	}
+	fs["tmp/test/new.txt"] = &fstest.MapFile{
+		Data: []byte("new"),
+		Mode: 0o644,
+		Sys:  defaultStat(0o644, 3),
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		output string
+	}{
+		{
+			name:   "cached",
+			output: "hello.txt\nlib/world.txt\nrun.sh\n",
+		},
+		{
+			name: "stage",
+			args: []string{"--stage"},
+			output: "100644 b6fc4c620b67d95f953a5c1c1230aaab5db5a1b0 0\thello.txt\n" +
+				"100644 04fea06420ca60892f73becee3614f6d023a4b7f 0\tlib/world.txt\n" +
+				"100755 e5224d533ef27b001224859a9b36696846a7e7fe 0\trun.sh\n",
+		},
+		{
+			name: "debug",
+			args: []string{"--debug", "--deleted"},
+			output: "hello.txt\n" +
+				"  ctime: 1739287401:888108884\n" +
+				"  mtime: 1739287401:888108884\n" +
+				"  dev: 66306\tino: 26874043\n" +
+				"  uid: 1000\tgid: 1000\n" +
+				"  size: 5\tflags: 0\n",
+		},
+		{
+			name:   "modified",
+			args:   []string{"-m"},
+			output: "hello.txt\nlib/world.txt\n",
+		},
+		{
+			name:   "others",
+			args:   []string{"--others"},
+			output: "new.txt\n",
+		},
+	}
+
+	// subtests share the index which is locked while loading, so they run sequentially
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.NewBuffer(nil)
+
+			exit, err := command.NewRunner(repo, nil).RunCmd(t.Context(), "ls-files", tt.args, buf)
+			require.NoError(t, err)
+			require.Equal(t, 0, exit)
+			require.Equal(t, tt.output, buf.String())
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package command
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/repository"
+	"github.com/LukasJenicek/ggit/internal/revision"
+)
+
+type LsTreeOptions struct {
+	// Recurse into subtrees.
+	Recursive bool
+	// Show tree entries even when recursing.
+	ShowTrees bool
+	// Show only tree entries.
+	OnlyTrees bool
+	// Print only paths.
+	NameOnly bool
+}
+
+// LsTreeCommand lists the contents of a tree object.
+type LsTreeCommand struct {
+	repository *repository.Repository
+	treeish    string
+	options    LsTreeOptions
+}
+
+func NewLsTreeCommand(repo *repository.Repository, treeish string, options LsTreeOptions) (*LsTreeCommand, error) {
+	if repo == nil {
+		return nil, errors.New("repository is nil")
+	}
+
+	if treeish == "" {
+		return nil, errors.New("tree-ish is empty")
+	}
+
+	return &LsTreeCommand{
+		repository: repo,
+		treeish:    treeish,
+		options:    options,
+	}, nil
+}
+
+func (l *LsTreeCommand) Run() ([]byte, error) {
+	resolver, err := revision.New(l.repository.Database, l.repository.Refs, l.repository.Clock)
+	if err != nil {
+		return nil, fmt.Errorf("init revision resolver: %w", err)
+	}
+
+	oid, err := resolver.Resolve(l.treeish)
+	if err != nil {
+		return nil, fmt.Errorf("resolve tree-ish: %w", err)
+	}
+
+	treeOID, err := resolver.Peel(oid, database.TreeType)
+	if err != nil {
+		return nil, fmt.Errorf("not a tree object: %w", err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	if err := l.list(buf, treeOID, ""); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (l *LsTreeCommand) list(buf *bytes.Buffer, treeOID string, dir string) error {
+	obj, err := l.repository.Database.Load(treeOID)
+	if err != nil {
+		return fmt.Errorf("load tree %s: %w", treeOID, err)
+	}
+
+	tree, ok := obj.(*database.Tree)
+	if !ok {
+		return fmt.Errorf("object %s is not a tree", treeOID)
+	}
+
+	for _, entry := range tree.Entries() {
+		subtree, isTree := entry.(*database.Tree)
+
+		if !isTree && l.options.OnlyTrees {
+			continue
+		}
+
+		recurse := isTree && l.options.Recursive
+		if !recurse || l.options.ShowTrees || l.options.OnlyTrees {
+			if err := l.print(buf, entry, dir); err != nil {
+				return err
+			}
+		}
+
+		if recurse {
+			path := subtree.Name()
+			if dir != "" {
+				path = dir + "/" + path
+			}
+
+			if err := l.list(buf, hex.EncodeToString(subtree.OID()), path); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (l *LsTreeCommand) print(buf *bytes.Buffer, entry database.Object, dir string) error {
+	if !l.options.NameOnly {
+		line, err := formatTreeEntry(entry, dir)
+		if err != nil {
+			return fmt.Errorf("format tree entry: %w", err)
+		}
+
+		buf.WriteString(line)
+
+		return nil
+	}
+
+	name := ""
+
+	switch e := entry.(type) {
+	case *database.Tree:
+		name = e.Name()
+	case *database.Entry:
+		name = e.Name
+	}
+
+	if dir != "" {
+		name = dir + "/" + name
+	}
+
+	buf.WriteString(name + "\n")
+
+	return nil
+}
+
+func (l *LsTreeCommand) Output(msg []byte, err error, stdout io.Writer) (int, error) {
+	if err != nil {
+		if errors.Is(err, revision.ErrUnknownRevision) || errors.Is(err, revision.ErrInvalidRevision) {
+			fmt.Fprintf(stdout, "fatal: Not a valid object name %s\n", l.treeish)
+
+			return 128, nil
+		}
+
+		return 1, fmt.Errorf("ls-tree cmd: %w", err)
+	}
+
+	fmt.Fprint(stdout, string(msg))
+
+	return 0, nil
+}
@@ -0,0 +1,141 @@
+package command_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/clock"
+	"github.com/LukasJenicek/ggit/internal/command"
+	"github.com/LukasJenicek/ggit/internal/filesystem/memory"
+	"github.com/LukasJenicek/ggit/internal/repository"
+)
+
+func TestLsTree(t *testing.T) {
+	t.Parallel()
+
+	repo, err := repository.New(
+		memory.New(fstest.MapFS{
+			"tmp/test/": &fstest.MapFile{
+				Mode: os.ModeDir,
+			},
+			"tmp/test/hello.txt": &fstest.MapFile{
+				Data: []byte("hello"),
+				Mode: 0o644,
+				Sys:  defaultStat(0o644, 5),
+			},
+			"tmp/test/run.sh": &fstest.MapFile{
+				Data: []byte("world"),
+				Mode: 0o755,
+				Sys:  defaultStat(0o755, 5),
+			},
+			"tmp/test/lib/a/one.txt": &fstest.MapFile{
+				Data: []byte("hello"),
+				Mode: 0o644,
+				Sys:  defaultStat(0o644, 5),
+			},
+			"tmp/test/lib/a/two.txt": &fstest.MapFile{
+				Data: []byte("world"),
+				Mode: 0o644,
+				Sys:  defaultStat(0o644, 5),
+			},
+			"tmp/test/lib/b/three.txt": &fstest.MapFile{
+				Data: []byte("hello"),
+				Mode: 0o644,
+				Sys:  defaultStat(0o644, 5),
+			},
+		}),
+		clock.NewFakeClock(time.Date(2000, 12, 15, 17, 8, 0o0, 0, time.UTC)),
+		"tmp/test",
+	)
+	require.NoError(t, err)
+	require.NoError(t, repo.Init())
+	require.NoError(t, repo.Add([]string{"."}))
+
+	_, err = repo.Commit("all", repository.CommitOptions{})
+	require.NoError(t, err)
+
+	hello := "b6fc4c620b67d95f953a5c1c1230aaab5db5a1b0"
+	world := "04fea06420ca60892f73becee3614f6d023a4b7f"
+
+	libTree := revParse(t, repo, "HEAD:lib")
+	aTree := revParse(t, repo, "HEAD:lib/a")
+	bTree := revParse(t, repo, "HEAD:lib/b")
+
+	tests := []struct {
+		name   string
+		args   []string
+		exit   int
+		output string
+	}{
+		{
+			name: "top level",
+			args: []string{"HEAD"},
+			output: "100644 blob " + hello + "\thello.txt\n" +
+				"040000 tree " + libTree + "\tlib\n" +
+				"100755 blob " + world + "\trun.sh\n",
+		},
+		{
+			name: "recursive",
+			args: []string{"-r", "master"},
+			output: "100644 blob " + hello + "\thello.txt\n" +
+				"100644 blob " + hello + "\tlib/a/one.txt\n" +
+				"100644 blob " + world + "\tlib/a/two.txt\n" +
+				"100644 blob " + hello + "\tlib/b/three.txt\n" +
+				"100755 blob " + world + "\trun.sh\n",
+		},
+		{
+			name: "recursive with trees",
+			args: []string{"-r", "-t", "--name-only", "HEAD"},
+			output: "hello.txt\nlib\nlib/a\nlib/a/one.txt\nlib/a/two.txt\n" +
+				"lib/b\nlib/b/three.txt\nrun.sh\n",
+		},
+		{
+			name: "only trees",
+			args: []string{"-r", "-d", "HEAD^{tree}"},
+			output: "040000 tree " + libTree + "\tlib\n" +
+				"040000 tree " + aTree + "\tlib/a\n" +
+				"040000 tree " + bTree + "\tlib/b\n",
+		},
+		{
+			name:   "subtree",
+			args:   []string{"--name-only", "HEAD:lib/a"},
+			output: "one.txt\ntwo.txt\n",
+		},
+		{
+			name:   "unknown tree-ish",
+			args:   []string{"missing"},
+			exit:   128,
+			output: "fatal: Not a valid object name missing\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := bytes.NewBuffer(nil)
+
+			exit, err := command.NewRunner(repo, nil).RunCmd(t.Context(), "ls-tree", tt.args, buf)
+			require.NoError(t, err)
+			require.Equal(t, tt.exit, exit)
+			require.Equal(t, tt.output, buf.String())
+		})
+	}
+}
+
+func revParse(t *testing.T, repo *repository.Repository, rev string) string {
+	t.Helper()
+
+	buf := bytes.NewBuffer(nil)
+
+	exit, err := command.NewRunner(repo, nil).RunCmd(t.Context(), "rev-parse", []string{rev}, buf)
+	require.NoError(t, err)
+	require.Equal(t, 0, exit, buf.String())
+
+	return buf.String()[:40]
+}
@@ -0,0 +1,86 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/LukasJenicek/ggit/internal/repository"
+)
+
+// EditorFunc opens file at path in the user's editor and returns once the editor exits.
+type EditorFunc func(path string) error
+
+// messageSource reads message of a commit or a tag given by -m, -F or written in the editor.
+type messageSource struct {
+	repository *repository.Repository
+	// Messages given by -m, each one becomes a separate paragraph.
+	messages []string
+	// File given by -F, "-" reads the message from stdin.
+	file   string
+	stdin  io.Reader
+	editor EditorFunc
+	// editMsgPath is file the message is stored in, e.g. .git/COMMIT_EDITMSG.
+	editMsgPath string
+}
+
+// read returns raw message and whether it was written in the editor.
+// Template is called only when the editor is opened.
+func (m *messageSource) read(template func() (string, error)) (string, bool, error) {
+	var message string
+
+	switch {
+	case len(m.messages) > 0:
+		message = strings.Join(m.messages, "\n\n")
+	case m.file == "-":
+		content, err := io.ReadAll(m.stdin)
+		if err != nil {
+			return "", false, fmt.Errorf("read message from stdin: %w", err)
+		}
+
+		message = string(content)
+	case m.file != "":
+		content, err := m.repository.FS.ReadFile(filepath.Join(m.repository.Cwd, m.file))
+		if err != nil {
+			return "", false, fmt.Errorf("could not read file '%s': %w", m.file, err)
+		}
+
+		message = string(content)
+	default:
+		return m.edit(template)
+	}
+
+	if err := m.repository.FS.WriteFile(m.editMsgPath, []byte(message), 0o644); err != nil {
+		return "", false, fmt.Errorf("write %s: %w", m.editMsgPath, err)
+	}
+
+	return message, false, nil
+}
+
+func (m *messageSource) edit(template func() (string, error)) (string, bool, error) {
+	if m.editor == nil {
+		return "", false, errors.New("editor is not configured")
+	}
+
+	initial, err := template()
+	if err != nil {
+		return "", false, err
+	}
+
+	if err := m.repository.FS.WriteFile(m.editMsgPath, []byte(initial), 0o644); err != nil {
+		return "", false, fmt.Errorf("write %s: %w", m.editMsgPath, err)
+	}
+
+	if err := m.editor(m.editMsgPath); err != nil {
+		return "", false, fmt.Errorf("there was a problem with the editor: %w", err)
+	}
+
+	content, err := m.repository.FS.ReadFile(m.editMsgPath)
+	if err != nil {
+		return "", false, fmt.Errorf("read %s: %w", m.editMsgPath, err)
+	}
+
+	return string(content), true, nil
+}
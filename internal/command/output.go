@@ -0,0 +1,108 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/repository"
+)
+
+// writeAmbiguousObjectHint lists candidates of an ambiguous short object id the same way as git.
+func writeAmbiguousObjectHint(stdout io.Writer, repo *repository.Repository, ambiguous *database.ErrAmbiguousObjectID) {
+	fmt.Fprintf(stdout, "error: short object ID %s is ambiguous\n", ambiguous.Prefix)
+	fmt.Fprint(stdout, "hint: The candidates are:\n")
+
+	for _, oid := range ambiguous.Candidates {
+		objectType := "unknown"
+
+		if raw, err := repo.Database.ReadObject(oid); err == nil {
+			objectType = raw.Type
+		}
+
+		short, err := repo.Database.ShortOID(oid)
+		if err != nil {
+			short = oid
+		}
+
+		fmt.Fprintf(stdout, "hint:   %s %s\n", short, objectType)
+	}
+}
+
+func writeUnknownRevision(stdout io.Writer, rev string) {
+	fmt.Fprintf(stdout, "fatal: ambiguous argument '%s': unknown revision or path not in the working tree.\n", rev)
+	fmt.Fprint(stdout, "Use '--' to separate paths from revisions, like this:\n")
+	fmt.Fprint(stdout, "'ggit <command> [<revision>...] -- [<file>...]'\n")
+}
+
+// headDescription describes HEAD the same way as git status does, "On branch <name>" or "HEAD detached at <abbrev>".
+func headDescription(repo *repository.Repository) (string, error) {
+	head, err := repo.Refs.Head()
+	if err != nil {
+		return "", fmt.Errorf("read HEAD: %w", err)
+	}
+
+	if !head.Detached() {
+		return "On branch " + head.Branch(), nil
+	}
+
+	short, err := repo.Database.ShortOID(head.OID)
+	if err != nil {
+		return "", fmt.Errorf("abbreviate %s: %w", head.OID, err)
+	}
+
+	return "HEAD detached at " + short, nil
+}
+
+// reflogIdentity resolves identity recorded in reflog, ref updates are not logged when it is not configured.
+// Other errors, e.g. invalid GIT_COMMITTER_DATE, are returned.
+func reflogIdentity(repo *repository.Repository, signature repository.Signature) (*database.Author, error) {
+	identity, err := repo.Committer(signature)
+	if errors.Is(err, repository.ErrUnknownIdentity) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("reflog identity: %w", err)
+	}
+
+	return identity, nil
+}
+
+// pathEscapes are C escape sequences git uses when quoting paths.
+var pathEscapes = map[byte]string{
+	'\a': `\a`, '\b': `\b`, '\t': `\t`, '\n': `\n`, '\v': `\v`, '\f': `\f`, '\r': `\r`, '"': `\"`, '\\': `\\`,
+}
+
+// quotePath wraps path in double quotes and escapes it like C string when it contains control characters,
+// quotes, backslashes or non-ASCII bytes, other paths are returned unchanged.
+func quotePath(path string) string {
+	var quoted strings.Builder
+
+	needsQuotes := false
+
+	for i := range len(path) {
+		c := path[i]
+
+		switch escape, ok := pathEscapes[c]; {
+		case ok:
+			quoted.WriteString(escape)
+		case c < 0x20 || c >= 0x7f:
+			fmt.Fprintf(&quoted, `\%03o`, c)
+		default:
+			quoted.WriteByte(c)
+
+			continue
+		}
+
+		needsQuotes = true
+	}
+
+	if !needsQuotes {
+		return path
+	}
+
+	return `"` + quoted.String() + `"`
+}
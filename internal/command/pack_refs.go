@@ -0,0 +1,49 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/LukasJenicek/ggit/internal/repository"
+)
+
+type PackRefsOptions struct {
+	// Pack all refs, by default only tags are packed.
+	All bool
+}
+
+// PackRefsCommand moves loose refs into .git/packed-refs.
+type PackRefsCommand struct {
+	repository *repository.Repository
+	options    PackRefsOptions
+}
+
+func NewPackRefsCommand(repo *repository.Repository, options PackRefsOptions) (*PackRefsCommand, error) {
+	if repo == nil {
+		return nil, errors.New("repository is nil")
+	}
+
+	return &PackRefsCommand{
+		repository: repo,
+		options:    options,
+	}, nil
+}
+
+func (p *PackRefsCommand) Run() ([]byte, error) {
+	if err := p.repository.Refs.PackRefs(p.repository.Database, p.options.All); err != nil {
+		return nil, fmt.Errorf("pack refs: %w", err)
+	}
+
+	return nil, nil
+}
+
+func (p *PackRefsCommand) Output(msg []byte, err error, stdout io.Writer) (int, error) {
+	if err != nil {
+		return 1, fmt.Errorf("pack-refs cmd: %w", err)
+	}
+
+	fmt.Fprint(stdout, string(msg))
+
+	return 0, nil
+}
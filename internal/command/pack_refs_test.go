@@ -0,0 +1,74 @@
+package command_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackRefs(t *testing.T) {
+	t.Parallel()
+
+	repo := committedRepository(t)
+
+	head, err := repo.Refs.ReadHead()
+	require.NoError(t, err)
+
+	exit, _ := runCmd(t, repo, "tag", "v1.0")
+	require.Equal(t, 0, exit)
+
+	exit, _ = runCmd(t, repo, "tag", "-m", "release", "v2.0")
+	require.Equal(t, 0, exit)
+
+	annotated, err := repo.Refs.ReadRef("v2.0")
+	require.NoError(t, err)
+
+	exit, _ = runCmd(t, repo, "branch", "topic")
+	require.Equal(t, 0, exit)
+
+	exit, out := runCmd(t, repo, "pack-refs")
+	require.Equal(t, 0, exit)
+	require.Empty(t, out)
+
+	// without --all only tags are packed
+	_, err = repo.FS.Stat(filepath.Join(repo.GitPath, "refs/tags/v1.0"))
+	require.Error(t, err)
+	_, err = repo.FS.Stat(filepath.Join(repo.GitPath, "refs/heads/topic"))
+	require.NoError(t, err)
+
+	exit, _ = runCmd(t, repo, "pack-refs", "--all")
+	require.Equal(t, 0, exit)
+
+	content, err := repo.FS.ReadFile(filepath.Join(repo.GitPath, "packed-refs"))
+	require.NoError(t, err)
+	require.Equal(t, "# pack-refs with: peeled fully-peeled sorted \n"+
+		head+" refs/heads/master\n"+
+		head+" refs/heads/topic\n"+
+		head+" refs/tags/v1.0\n"+
+		annotated+" refs/tags/v2.0\n"+
+		"^"+head+"\n", string(content))
+
+	_, err = repo.FS.Stat(filepath.Join(repo.GitPath, "refs/heads/master"))
+	require.Error(t, err)
+
+	oid, err := repo.Refs.ReadHead()
+	require.NoError(t, err)
+	require.Equal(t, head, oid)
+
+	exit, out = runCmd(t, repo, "tag")
+	require.Equal(t, 0, exit)
+	require.Equal(t, "v1.0\nv2.0\n", out)
+
+	exit, out = runCmd(t, repo, "tag", "-d", "v1.0")
+	require.Equal(t, 0, exit)
+	require.Equal(t, "Deleted tag 'v1.0' (was "+head[:7]+")\n", out)
+
+	exit, out = runCmd(t, repo, "branch")
+	require.Equal(t, 0, exit)
+	require.Equal(t, "* master\n  topic\n", out)
+
+	exit, out = runCmd(t, repo, "pack-refs", "--bogus")
+	require.Equal(t, 129, exit)
+	require.Equal(t, "usage: ggit pack-refs [--all]\n", out)
+}
@@ -0,0 +1,266 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/repository"
+)
+
+const (
+	ReflogShow   = "show"
+	ReflogExpire = "expire"
+	ReflogDelete = "delete"
+
+	// defaultReflogExpire is git default of gc.reflogExpire.
+	defaultReflogExpire = "90.days.ago"
+)
+
+var (
+	errNoReflog      = errors.New("no reflog")
+	errInvalidExpire = errors.New("invalid expire date")
+)
+
+type ReflogOptions struct {
+	// Entries older than Expire are removed by expire, "all" removes every entry and "never" none.
+	Expire string
+	// Expire logs of all refs.
+	All bool
+}
+
+// ReflogCommand shows, expires and deletes entries of ref logs.
+type ReflogCommand struct {
+	repository *repository.Repository
+	action     string
+	args       []string
+	options    ReflogOptions
+
+	failed string
+}
+
+func NewReflogCommand(repo *repository.Repository, action string, args []string, options ReflogOptions) (*ReflogCommand, error) {
+	if repo == nil {
+		return nil, errors.New("repository is nil")
+	}
+
+	switch action {
+	case ReflogShow:
+		if len(args) > 1 {
+			return nil, errors.New("too many arguments")
+		}
+	case ReflogExpire:
+	case ReflogDelete:
+		if len(args) == 0 {
+			return nil, errors.New("no reflog entry specified")
+		}
+	default:
+		return nil, fmt.Errorf("unknown reflog action %q", action)
+	}
+
+	if options.Expire == "" {
+		options.Expire = defaultReflogExpire
+	}
+
+	return &ReflogCommand{
+		repository: repo,
+		action:     action,
+		args:       args,
+		options:    options,
+	}, nil
+}
+
+func (r *ReflogCommand) Run() ([]byte, error) {
+	switch r.action {
+	case ReflogExpire:
+		return nil, r.expire()
+	case ReflogDelete:
+		return nil, r.delete()
+	default:
+		return r.show()
+	}
+}
+
+func (r *ReflogCommand) show() ([]byte, error) {
+	name := database.HEAD
+	if len(r.args) == 1 {
+		name = r.args[0]
+	}
+
+	ref, err := r.reflogRef(name)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := r.repository.Refs.ReadReflog(ref)
+	if err != nil {
+		return nil, fmt.Errorf("read reflog: %w", err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		short, err := r.repository.Database.ShortOID(entries[i].NewOID)
+		if err != nil {
+			short = entries[i].NewOID[:7]
+		}
+
+		fmt.Fprintf(buf, "%s %s@{%d}: %s\n", short, name, len(entries)-1-i, entries[i].Message)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (r *ReflogCommand) expire() error {
+	refs := make([]string, 0, len(r.args))
+
+	if r.options.All {
+		all, err := r.repository.Refs.ListReflogs()
+		if err != nil {
+			return fmt.Errorf("list reflogs: %w", err)
+		}
+
+		refs = append(refs, all...)
+	}
+
+	for _, name := range r.args {
+		ref, err := r.reflogRef(name)
+		if err != nil {
+			return err
+		}
+
+		refs = append(refs, ref)
+	}
+
+	var cutoff time.Time
+
+	switch r.options.Expire {
+	case "never", "false":
+		return nil
+	case "all", "now":
+		cutoff = r.repository.Clock.Now().Add(time.Second)
+	default:
+		date, err := database.ParseApproxidate(r.options.Expire, r.repository.Clock.Now())
+		if err != nil {
+			return fmt.Errorf("%w: %w", errInvalidExpire, err)
+		}
+
+		cutoff = date
+	}
+
+	for _, ref := range refs {
+		entries, err := r.repository.Refs.ReadReflog(ref)
+		if err != nil {
+			return fmt.Errorf("read reflog %s: %w", ref, err)
+		}
+
+		kept := make([]*database.ReflogEntry, 0, len(entries))
+
+		for _, entry := range entries {
+			if !entry.Identity.Now.Before(cutoff) {
+				kept = append(kept, entry)
+			}
+		}
+
+		if len(kept) == len(entries) {
+			continue
+		}
+
+		if err := r.repository.Refs.WriteReflog(ref, kept); err != nil {
+			return fmt.Errorf("expire reflog %s: %w", ref, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *ReflogCommand) delete() error {
+	for _, arg := range r.args {
+		name, selector, found := strings.Cut(strings.TrimSuffix(arg, "}"), "@{")
+
+		nth, err := strconv.Atoi(selector)
+		if !found || err != nil || nth < 0 {
+			r.failed = arg
+
+			return fmt.Errorf("%w: %s", errNoReflog, arg)
+		}
+
+		if name == "" {
+			name = database.HEAD
+		}
+
+		ref, err := r.reflogRef(name)
+		if err != nil {
+			return err
+		}
+
+		entries, err := r.repository.Refs.ReadReflog(ref)
+		if err != nil {
+			return fmt.Errorf("read reflog %s: %w", ref, err)
+		}
+
+		if nth >= len(entries) {
+			r.failed = arg
+
+			return fmt.Errorf("%w: %s", errNoReflog, arg)
+		}
+
+		// entries are stored oldest first, @{0} is the last line
+		i := len(entries) - 1 - nth
+		entries = append(entries[:i], entries[i+1:]...)
+
+		if err := r.repository.Refs.WriteReflog(ref, entries); err != nil {
+			return fmt.Errorf("delete reflog entry %s: %w", arg, err)
+		}
+	}
+
+	return nil
+}
+
+// reflogRef expands ref name given by user to the ref whose log is used.
+func (r *ReflogCommand) reflogRef(name string) (string, error) {
+	if name == database.HEAD || name == "@" {
+		return database.HEAD, nil
+	}
+
+	ref, err := r.repository.Refs.ExpandRef(name)
+	if err != nil {
+		return "", fmt.Errorf("expand ref %s: %w", name, err)
+	}
+
+	if ref == "" {
+		r.failed = name
+
+		return "", fmt.Errorf("%w: %s", database.ErrRefNotFound, name)
+	}
+
+	return ref, nil
+}
+
+func (r *ReflogCommand) Output(msg []byte, err error, stdout io.Writer) (int, error) {
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrRefNotFound):
+			writeUnknownRevision(stdout, r.failed)
+		case errors.Is(err, errNoReflog):
+			fmt.Fprintf(stdout, "fatal: reflog entry '%s' not found\n", r.failed)
+		case errors.Is(err, errInvalidExpire):
+			fmt.Fprintf(stdout, "error: invalid value for '--expire': '%s'\n", r.options.Expire)
+
+			return 129, nil
+		default:
+			return 1, fmt.Errorf("reflog cmd: %w", err)
+		}
+
+		return 128, nil
+	}
+
+	fmt.Fprint(stdout, string(msg))
+
+	return 0, nil
+}
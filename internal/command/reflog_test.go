@@ -0,0 +1,83 @@
+package command_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+)
+
+func TestReflog(t *testing.T) {
+	t.Parallel()
+
+	repo := committedRepository(t)
+
+	head, err := repo.Refs.ReadHead()
+	require.NoError(t, err)
+
+	root, err := repo.Database.LoadCommit(head)
+	require.NoError(t, err)
+
+	now := repo.Clock.Now()
+	identity := database.NewAuthor("lukas.jenicek5@gmail.com", "Lukas Jenicek", &now)
+
+	second := storeCommit(t, repo, root.RootOID, []string{head}, "second", time.Unix(2000000000, 0))
+	require.NoError(t, repo.Refs.UpdateHead(second, identity, "commit: second"))
+
+	exit, _ := runCmd(t, repo, "branch", "topic", "HEAD~1")
+	require.Equal(t, 0, exit)
+
+	exit, out := runCmd(t, repo, "reflog")
+	require.Equal(t, 0, exit)
+	require.Equal(t, second[:7]+" HEAD@{0}: commit: second\n"+head[:7]+" HEAD@{1}: commit (initial): all\n", out)
+
+	exit, out = runCmd(t, repo, "reflog", "show", "topic")
+	require.Equal(t, 0, exit)
+	require.Equal(t, head[:7]+" topic@{0}: branch: Created from HEAD~1\n", out)
+
+	require.Equal(t, head, revParse(t, repo, "@{1}"))
+	require.Equal(t, second, revParse(t, repo, "master@{0}"))
+	// log does not go back to yesterday, the oldest value is used
+	require.Equal(t, head, revParse(t, repo, "master@{yesterday}"))
+
+	exit, out = runCmd(t, repo, "reflog", "delete", "master@{0}")
+	require.Equal(t, 0, exit)
+	require.Empty(t, out)
+
+	exit, out = runCmd(t, repo, "reflog", "master")
+	require.Equal(t, 0, exit)
+	require.Equal(t, head[:7]+" master@{0}: commit (initial): all\n", out)
+
+	// entries are not older than a day
+	exit, _ = runCmd(t, repo, "reflog", "expire", "--expire=1.day.ago", "--all")
+	require.Equal(t, 0, exit)
+
+	exit, _ = runCmd(t, repo, "reflog", "expire", "--expire=all", "topic")
+	require.Equal(t, 0, exit)
+
+	entries, err := repo.Refs.ReadReflog("refs/heads/topic")
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	entries, err = repo.Refs.ReadReflog(database.HEAD)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	exit, out = runCmd(t, repo, "reflog", "missing")
+	require.Equal(t, 128, exit)
+	require.Contains(t, out, "fatal: ambiguous argument 'missing': unknown revision or path not in the working tree.\n")
+
+	exit, out = runCmd(t, repo, "reflog", "delete", "master@{5}")
+	require.Equal(t, 128, exit)
+	require.Equal(t, "fatal: reflog entry 'master@{5}' not found\n", out)
+
+	exit, out = runCmd(t, repo, "reflog", "expire", "--expire=someday")
+	require.Equal(t, 129, exit)
+	require.Equal(t, "error: invalid value for '--expire': 'someday'\n", out)
+
+	exit, out = runCmd(t, repo, "reflog", "show", "--all")
+	require.Equal(t, 129, exit)
+	require.Contains(t, out, "usage: ggit reflog [show] [<ref>]\n")
+}
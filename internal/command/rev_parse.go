@@ -0,0 +1,99 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/repository"
+	"github.com/LukasJenicek/ggit/internal/revision"
+)
+
+// RevParseCommand prints object ids of given revisions.
+type RevParseCommand struct {
+	repository *repository.Repository
+	revisions  []string
+	short      bool
+	verify     bool
+
+	failed string
+}
+
+func NewRevParseCommand(repo *repository.Repository, revisions []string, short, verify bool) (*RevParseCommand, error) {
+	if repo == nil {
+		return nil, errors.New("repository is nil")
+	}
+
+	if len(revisions) == 0 {
+		return nil, errors.New("revisions is empty")
+	}
+
+	return &RevParseCommand{
+		repository: repo,
+		revisions:  revisions,
+		short:      short,
+		verify:     verify,
+	}, nil
+}
+
+func (r *RevParseCommand) Run() ([]byte, error) {
+	if r.verify && len(r.revisions) != 1 {
+		return nil, revision.ErrInvalidRevision
+	}
+
+	resolver, err := revision.New(r.repository.Database, r.repository.Refs, r.repository.Clock)
+	if err != nil {
+		return nil, fmt.Errorf("init revision resolver: %w", err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	for _, rev := range r.revisions {
+		oid, err := resolver.Resolve(rev)
+		if err != nil {
+			r.failed = rev
+
+			return nil, fmt.Errorf("resolve %s: %w", rev, err)
+		}
+
+		if r.short {
+			oid, err = r.repository.Database.ShortOID(oid)
+			if err != nil {
+				return nil, fmt.Errorf("abbreviate %s: %w", rev, err)
+			}
+		}
+
+		buf.WriteString(oid + "\n")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (r *RevParseCommand) Output(msg []byte, err error, stdout io.Writer) (int, error) {
+	if err != nil {
+		var ambiguous *database.ErrAmbiguousObjectID
+		if errors.As(err, &ambiguous) {
+			writeAmbiguousObjectHint(stdout, r.repository, ambiguous)
+		}
+
+		if r.verify {
+			fmt.Fprint(stdout, "fatal: Needed a single revision\n")
+
+			return 128, nil
+		}
+
+		if errors.Is(err, revision.ErrUnknownRevision) || errors.Is(err, revision.ErrInvalidRevision) || ambiguous != nil {
+			writeUnknownRevision(stdout, r.failed)
+
+			return 128, nil
+		}
+
+		return 1, fmt.Errorf("rev-parse cmd: %w", err)
+	}
+
+	fmt.Fprint(stdout, string(msg))
+
+	return 0, nil
+}
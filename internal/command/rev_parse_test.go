@@ -0,0 +1,80 @@
+package command_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/command"
+)
+
+func TestRevParse(t *testing.T) {
+	t.Parallel()
+
+	repo := committedRepository(t)
+
+	head, err := repo.Refs.ReadHead()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		args   []string
+		exit   int
+		output string
+	}{
+		{
+			name:   "branch and head",
+			args:   []string{"master", "HEAD"},
+			output: head + "\n" + head + "\n",
+		},
+		{
+			name:   "short",
+			args:   []string{"--short", "HEAD"},
+			output: head[:7] + "\n",
+		},
+		{
+			name: "unknown revision",
+			args: []string{"HEAD~1"},
+			exit: 128,
+			output: "fatal: ambiguous argument 'HEAD~1': unknown revision or path not in the working tree.\n" +
+				"Use '--' to separate paths from revisions, like this:\n" +
+				"'ggit <command> [<revision>...] -- [<file>...]'\n",
+		},
+		{
+			name: "files of git dir are not refs",
+			args: []string{"index"},
+			exit: 128,
+			output: "fatal: ambiguous argument 'index': unknown revision or path not in the working tree.\n" +
+				"Use '--' to separate paths from revisions, like this:\n" +
+				"'ggit <command> [<revision>...] -- [<file>...]'\n",
+		},
+		{
+			name: "path outside of git dir",
+			args: []string{"../x"},
+			exit: 128,
+			output: "fatal: ambiguous argument '../x': unknown revision or path not in the working tree.\n" +
+				"Use '--' to separate paths from revisions, like this:\n" +
+				"'ggit <command> [<revision>...] -- [<file>...]'\n",
+		},
+		{
+			name:   "verify",
+			args:   []string{"--verify", "missing"},
+			exit:   128,
+			output: "fatal: Needed a single revision\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := bytes.NewBuffer(nil)
+
+			exit, err := command.NewRunner(repo, nil).RunCmd(t.Context(), "rev-parse", tt.args, buf)
+			require.NoError(t, err)
+			require.Equal(t, tt.exit, exit)
+			require.Equal(t, tt.output, buf.String())
+		})
+	}
+}
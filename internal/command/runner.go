@@ -2,19 +2,27 @@ package command
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"io"
+	"os"
+	"os/exec"
+	"strings"
 
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/diff"
 	"github.com/LukasJenicek/ggit/internal/repository"
 )
 
 type Runner struct {
 	repository *repository.Repository
+	stdin      io.Reader
 }
 
-func NewRunner(repo *repository.Repository) *Runner {
+func NewRunner(repo *repository.Repository, stdin io.Reader) *Runner {
 	return &Runner{
 		repository: repo,
+		stdin:      stdin,
 	}
 }
 
@@ -23,19 +31,90 @@ func (r *Runner) RunCmd(ctx context.Context, cmd string, args []string, output i
 	switch cmd {
 	case "add":
 		return r.addCmd(args, output)
+	case "branch":
+		return r.branchCmd(args, output)
+	case "cat-file":
+		return r.catFileCmd(args, output)
+	case "check-ref-format":
+		return r.checkRefFormatCmd(args, output)
+	case "diff":
+		return r.diffCmd(args, output)
+	case "for-each-ref":
+		return r.forEachRefCmd(args, output)
+	case "hash-object":
+		return r.hashObjectCmd(args, output)
 	case "commit":
-		return r.commitCmd(output)
+		return r.commitCmd(args, output)
 	case "init":
 		return r.initCmd(output)
+	case "ls-files":
+		return r.lsFilesCmd(args, output)
+	case "ls-tree":
+		return r.lsTreeCmd(args, output)
+	case "log":
+		return r.logCmd(args, output)
+	case "pack-refs":
+		return r.packRefsCmd(args, output)
+	case "reflog":
+		return r.reflogCmd(args, output)
+	case "rev-parse":
+		return r.revParseCmd(args, output)
+	case "tag":
+		return r.tagCmd(args, output)
+	case "update-ref":
+		return r.updateRefCmd(args, output)
+	case "show-ref":
+		return r.showRefCmd(args, output)
 	case "status":
-		return r.statusCmd(output)
+		return r.statusCmd(args, output)
 	}
 
 	return 1, fmt.Errorf("ggit: %q is not a ggit command. See 'ggit --help'", cmd)
 }
 
-func (r *Runner) statusCmd(output io.Writer) (int, error) {
-	cmd, err := NewStatusCommand(r.repository)
+func (r *Runner) statusCmd(args []string, output io.Writer) (int, error) {
+	usage := `usage: ggit status [-s | --short] [--porcelain[=<version>]] [-b | --branch] [-z] [-u[<mode>] | --untracked-files[=<mode>]]
+`
+
+	flags := flag.NewFlagSet("status", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	options := StatusOptions{}
+	flags.BoolVar(&options.Short, "short", false, "show status concisely")
+	flags.BoolVar(&options.Short, "s", false, "show status concisely")
+	flags.BoolVar(&options.Branch, "branch", false, "show branch information")
+	flags.BoolVar(&options.Branch, "b", false, "show branch information")
+	flags.BoolVar(&options.NullTerminated, "z", false, "terminate entries with NUL")
+
+	var porcelain porcelainFlag
+
+	flags.Var(&porcelain, "porcelain", "machine-readable output")
+
+	untracked := &untrackedFilesFlag{mode: &options.UntrackedFiles}
+	flags.Var(untracked, "untracked-files", "show untracked files, mode is one of no, normal or all")
+	flags.Var(untracked, "u", "show untracked files, mode is one of no, normal or all")
+
+	// -u takes its mode without separator, e.g. -uno
+	if err := parseFlags(flags, attachedValues(flags, args, "u")); err != nil || flags.NArg() != 0 {
+		if porcelain.invalid != "" {
+			fmt.Fprintf(output, "fatal: unsupported porcelain version '%s'\n", porcelain.invalid)
+
+			return 128, nil
+		}
+
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	options.Porcelain = porcelain.version
+
+	// -z implies porcelain format unless the short one is asked for
+	if options.NullTerminated && !options.Short && options.Porcelain == 0 {
+		options.Porcelain = 1
+	}
+
+	cmd, err := NewStatusCommand(r.repository, options)
 	if err != nil {
 		return 1, fmt.Errorf("init status cmd: %w", err)
 	}
@@ -45,8 +124,63 @@ func (r *Runner) statusCmd(output io.Writer) (int, error) {
 	return cmd.Output(out, err, output)
 }
 
-func (r *Runner) commitCmd(output io.Writer) (int, error) {
-	cmd, err := NewCommitCmd(r.repository)
+func (r *Runner) commitCmd(args []string, output io.Writer) (int, error) {
+	usage := `usage: ggit commit [-m <message>]... [-F <file>] [--cleanup=<mode>] [--author=<author>] [--date=<date>] [--allow-empty]
+`
+
+	flags := flag.NewFlagSet("commit", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	var messages stringsFlag
+
+	flags.Var(&messages, "m", "commit message, multiple -m options are joined as paragraphs")
+	flags.Var(&messages, "message", "commit message, multiple -m options are joined as paragraphs")
+	file := flags.String("F", "", "read message from file, - reads from stdin")
+	flags.StringVar(file, "file", "", "read message from file, - reads from stdin")
+	cleanup := flags.String("cleanup", string(repository.CleanupDefault), "how to strip spaces and #comments from message")
+	author := flags.String("author", "", "override author, expects 'Name <email>'")
+	date := flags.String("date", "", "override author date")
+	allowEmpty := flags.Bool("allow-empty", false, "allow recording a commit which does not change anything")
+
+	if err := parseFlags(flags, args); err != nil || flags.NArg() != 0 {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	cleanupMode, err := repository.ParseCleanupMode(*cleanup)
+	if err != nil {
+		fmt.Fprintf(output, "fatal: %s\n", err.Error())
+
+		return 128, nil
+	}
+
+	options := CommitOptions{
+		Messages:   messages,
+		File:       *file,
+		Cleanup:    cleanupMode,
+		Author:     signatureFromEnv("AUTHOR"),
+		Committer:  signatureFromEnv("COMMITTER"),
+		AllowEmpty: *allowEmpty,
+	}
+
+	if *author != "" {
+		identity, err := repository.ParseIdentity(*author)
+		if err != nil {
+			fmt.Fprintf(output, "fatal: --author '%s' is not 'Name <email>'\n", *author)
+
+			return 128, nil
+		}
+
+		options.Author.Name = identity.Name
+		options.Author.Email = identity.Email
+	}
+
+	if *date != "" {
+		options.Author.Date = *date
+	}
+
+	cmd, err := NewCommitCmd(r.repository, options, r.stdin, r.launchEditor(output))
 	if err != nil {
 		return 1, fmt.Errorf("init commit cmd: %w", err)
 	}
@@ -56,6 +190,121 @@ func (r *Runner) commitCmd(output io.Writer) (int, error) {
 	return cmd.Output(out, err, output)
 }
 
+// launchEditor
+// Editor is picked in the same order as git does: $GIT_EDITOR, core.editor, $VISUAL, $EDITOR and vi.
+func (r *Runner) launchEditor(output io.Writer) EditorFunc {
+	return func(path string) error {
+		editor := "vi"
+
+		for _, candidate := range []string{
+			os.Getenv("GIT_EDITOR"),
+			r.repository.GitConfig.Core.Editor,
+			os.Getenv("VISUAL"),
+			os.Getenv("EDITOR"),
+		} {
+			if candidate != "" {
+				editor = candidate
+
+				break
+			}
+		}
+
+		// run through shell so editor can contain arguments, e.g. "code --wait"
+		//nolint:gosec
+		cmd := exec.Command("sh", "-c", editor+` "$@"`, editor, path)
+		cmd.Stdin = r.stdin
+		cmd.Stdout = output
+		cmd.Stderr = os.Stderr
+
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("run editor %q: %w", editor, err)
+		}
+
+		return nil
+	}
+}
+
+// signatureFromEnv reads GIT_<kind>_NAME, GIT_<kind>_EMAIL and GIT_<kind>_DATE.
+func signatureFromEnv(kind string) repository.Signature {
+	return repository.Signature{
+		Name:  os.Getenv("GIT_" + kind + "_NAME"),
+		Email: os.Getenv("GIT_" + kind + "_EMAIL"),
+		Date:  os.Getenv("GIT_" + kind + "_DATE"),
+	}
+}
+
+// stringsFlag collects values of repeated flag.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+
+	return nil
+}
+
+// porcelainFlag is --porcelain[=<version>], the version defaults to v1 when the flag is given without value.
+type porcelainFlag struct {
+	version int
+	// invalid holds unsupported version, flag package does not keep the error returned by Set
+	invalid string
+}
+
+func (p *porcelainFlag) String() string {
+	return fmt.Sprintf("v%d", p.version)
+}
+
+func (p *porcelainFlag) Set(value string) error {
+	switch value {
+	case "true", "v1":
+		p.version = 1
+	case "v2":
+		p.version = 2
+	default:
+		p.invalid = value
+
+		return fmt.Errorf("unsupported porcelain version '%s'", value)
+	}
+
+	return nil
+}
+
+// IsBoolFlag allows the flag without value.
+func (p *porcelainFlag) IsBoolFlag() bool {
+	return true
+}
+
+// untrackedFilesFlag is --untracked-files[=<mode>], the flag without value lists all untracked files.
+type untrackedFilesFlag struct {
+	mode *string
+}
+
+func (u *untrackedFilesFlag) String() string {
+	if u.mode == nil {
+		return ""
+	}
+
+	return *u.mode
+}
+
+func (u *untrackedFilesFlag) Set(value string) error {
+	if value == "true" {
+		value = untrackedAll
+	}
+
+	*u.mode = value
+
+	return nil
+}
+
+// IsBoolFlag allows the flag without value.
+func (u *untrackedFilesFlag) IsBoolFlag() bool {
+	return true
+}
+
 func (r *Runner) addCmd(args []string, output io.Writer) (int, error) {
 	if len(args) == 0 {
 		help := `Usage: ggit add <pattern>
@@ -88,3 +337,702 @@ func (r *Runner) initCmd(output io.Writer) (int, error) {
 
 	return cmd.Output(out, err, output)
 }
+
+func (r *Runner) catFileCmd(args []string, output io.Writer) (int, error) {
+	usage := `usage: ggit cat-file (-t | -s | -e | -p) <object>
+`
+
+	flags := flag.NewFlagSet("cat-file", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	showType := flags.Bool("t", false, "show object type")
+	showSize := flags.Bool("s", false, "show object size")
+	exists := flags.Bool("e", false, "exit with zero status if object exists")
+	pretty := flags.Bool("p", false, "pretty-print object's content")
+
+	if err := parseFlags(flags, args); err != nil || flags.NArg() != 1 {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	var modes []CatFileMode
+
+	if *showType {
+		modes = append(modes, CatFileType)
+	}
+
+	if *showSize {
+		modes = append(modes, CatFileSize)
+	}
+
+	if *exists {
+		modes = append(modes, CatFileExists)
+	}
+
+	if *pretty {
+		modes = append(modes, CatFilePretty)
+	}
+
+	if len(modes) != 1 {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	cmd, err := NewCatFileCommand(r.repository, modes[0], flags.Arg(0))
+	if err != nil {
+		return 1, fmt.Errorf("init cat-file cmd: %w", err)
+	}
+
+	out, err := cmd.Run()
+
+	return cmd.Output(out, err, output)
+}
+
+func (r *Runner) hashObjectCmd(args []string, output io.Writer) (int, error) {
+	usage := `usage: ggit hash-object [-t <type>] [-w] [--stdin] [<file>...]
+`
+
+	flags := flag.NewFlagSet("hash-object", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	objectType := flags.String("t", database.BlobType, "object type")
+	write := flags.Bool("w", false, "write the object into the object database")
+	stdin := flags.Bool("stdin", false, "read the object from stdin")
+
+	if err := parseFlags(flags, args); err != nil || (!*stdin && flags.NArg() == 0) {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	var input io.Reader
+	if *stdin {
+		input = r.stdin
+	}
+
+	cmd, err := NewHashObjectCommand(r.repository, *objectType, *write, input, flags.Args())
+	if err != nil {
+		return 1, fmt.Errorf("init hash-object cmd: %w", err)
+	}
+
+	out, err := cmd.Run()
+
+	return cmd.Output(out, err, output)
+}
+
+func (r *Runner) logCmd(args []string, output io.Writer) (int, error) {
+	usage := `usage: ggit log [--oneline] [-n <number>] [<revision>]
+`
+
+	flags := flag.NewFlagSet("log", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	oneline := flags.Bool("oneline", false, "show each commit on a single line")
+	limit := flags.Int("n", 0, "limit the number of commits to output")
+	flags.IntVar(limit, "max-count", 0, "limit the number of commits to output")
+
+	if err := parseFlags(flags, args); err != nil || flags.NArg() > 1 {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	cmd, err := NewLogCommand(r.repository, flags.Arg(0), *oneline, *limit)
+	if err != nil {
+		return 1, fmt.Errorf("init log cmd: %w", err)
+	}
+
+	out, err := cmd.Run()
+
+	return cmd.Output(out, err, output)
+}
+
+func (r *Runner) revParseCmd(args []string, output io.Writer) (int, error) {
+	usage := `usage: ggit rev-parse [--short] [--verify] <revision>...
+`
+
+	flags := flag.NewFlagSet("rev-parse", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	short := flags.Bool("short", false, "print shortest unique object id")
+	verify := flags.Bool("verify", false, "require exactly one valid revision")
+
+	if err := parseFlags(flags, args); err != nil || flags.NArg() == 0 {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	cmd, err := NewRevParseCommand(r.repository, flags.Args(), *short, *verify)
+	if err != nil {
+		return 1, fmt.Errorf("init rev-parse cmd: %w", err)
+	}
+
+	out, err := cmd.Run()
+
+	return cmd.Output(out, err, output)
+}
+
+func (r *Runner) lsTreeCmd(args []string, output io.Writer) (int, error) {
+	usage := `usage: ggit ls-tree [-r] [-t] [-d] [--name-only] <tree-ish>
+`
+
+	flags := flag.NewFlagSet("ls-tree", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	options := LsTreeOptions{}
+	flags.BoolVar(&options.Recursive, "r", false, "recurse into subtrees")
+	flags.BoolVar(&options.ShowTrees, "t", false, "show trees when recursing")
+	flags.BoolVar(&options.OnlyTrees, "d", false, "only show trees")
+	flags.BoolVar(&options.NameOnly, "name-only", false, "list only filenames")
+
+	if err := parseFlags(flags, args); err != nil || flags.NArg() != 1 {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	cmd, err := NewLsTreeCommand(r.repository, flags.Arg(0), options)
+	if err != nil {
+		return 1, fmt.Errorf("init ls-tree cmd: %w", err)
+	}
+
+	out, err := cmd.Run()
+
+	return cmd.Output(out, err, output)
+}
+
+func (r *Runner) branchCmd(args []string, output io.Writer) (int, error) {
+	usage := `usage: ggit branch [--list] [<pattern>...]
+   or: ggit branch <branch-name> [<start-point>]
+   or: ggit branch (-d | -D) <branch-name>...
+   or: ggit branch -m [<old-branch>] <new-branch>
+`
+
+	flags := flag.NewFlagSet("branch", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	options := BranchOptions{Committer: signatureFromEnv("COMMITTER")}
+	flags.BoolVar(&options.List, "list", false, "list branch names")
+	flags.BoolVar(&options.List, "l", false, "list branch names")
+	flags.BoolVar(&options.Delete, "delete", false, "delete fully merged branch")
+	flags.BoolVar(&options.Delete, "d", false, "delete fully merged branch")
+	forceDelete := flags.Bool("D", false, "delete branch (even if not merged)")
+	flags.BoolVar(&options.Move, "move", false, "move/rename a branch")
+	flags.BoolVar(&options.Move, "m", false, "move/rename a branch")
+
+	if err := parseFlags(flags, args); err != nil {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	if *forceDelete {
+		options.Delete = true
+		options.Force = true
+	}
+
+	modes := 0
+
+	for _, mode := range []bool{options.List, options.Delete, options.Move} {
+		if mode {
+			modes++
+		}
+	}
+
+	invalid := modes > 1 ||
+		(options.Move && (flags.NArg() == 0 || flags.NArg() > 2)) ||
+		(modes == 0 && flags.NArg() > 2)
+
+	if invalid {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	if options.Delete && flags.NArg() == 0 {
+		fmt.Fprint(output, "fatal: branch name required\n")
+
+		return 128, nil
+	}
+
+	cmd, err := NewBranchCommand(r.repository, flags.Args(), options)
+	if err != nil {
+		return 1, fmt.Errorf("init branch cmd: %w", err)
+	}
+
+	out, err := cmd.Run()
+
+	return cmd.Output(out, err, output)
+}
+
+func (r *Runner) tagCmd(args []string, output io.Writer) (int, error) {
+	usage := `usage: ggit tag [-a] [-f] [-m <message> | -F <file>] <tagname> [<commit> | <object>]
+   or: ggit tag -d <tagname>...
+   or: ggit tag [-l] [<pattern>...]
+`
+
+	flags := flag.NewFlagSet("tag", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	options := TagOptions{Tagger: signatureFromEnv("COMMITTER")}
+
+	var messages stringsFlag
+
+	flags.BoolVar(&options.List, "list", false, "list tag names")
+	flags.BoolVar(&options.List, "l", false, "list tag names")
+	flags.BoolVar(&options.Delete, "delete", false, "delete tags")
+	flags.BoolVar(&options.Delete, "d", false, "delete tags")
+	flags.BoolVar(&options.Annotate, "annotate", false, "annotated tag, needs a message")
+	flags.BoolVar(&options.Annotate, "a", false, "annotated tag, needs a message")
+	flags.BoolVar(&options.Force, "force", false, "replace the tag if exists")
+	flags.BoolVar(&options.Force, "f", false, "replace the tag if exists")
+	flags.Var(&messages, "m", "tag message")
+	flags.Var(&messages, "message", "tag message")
+	flags.StringVar(&options.File, "F", "", "read message from file, - reads from stdin")
+	flags.StringVar(&options.File, "file", "", "read message from file, - reads from stdin")
+
+	if err := parseFlags(flags, args); err != nil {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	options.Messages = messages
+
+	creating := !options.List && !options.Delete && flags.NArg() > 0
+	invalid := (options.List && options.Delete) ||
+		(!options.List && !options.Delete && flags.NArg() > 2) ||
+		(len(messages) > 0 && options.File != "") ||
+		(!creating && (options.Annotate || options.Force || len(messages) > 0 || options.File != ""))
+
+	if invalid {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	if options.Delete && flags.NArg() == 0 {
+		fmt.Fprint(output, "fatal: tag name required\n")
+
+		return 128, nil
+	}
+
+	cmd, err := NewTagCommand(r.repository, flags.Args(), options, r.stdin, r.launchEditor(output))
+	if err != nil {
+		return 1, fmt.Errorf("init tag cmd: %w", err)
+	}
+
+	out, err := cmd.Run()
+
+	return cmd.Output(out, err, output)
+}
+
+func (r *Runner) packRefsCmd(args []string, output io.Writer) (int, error) {
+	usage := `usage: ggit pack-refs [--all]
+`
+
+	flags := flag.NewFlagSet("pack-refs", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	options := PackRefsOptions{}
+	flags.BoolVar(&options.All, "all", false, "pack everything")
+
+	if err := parseFlags(flags, args); err != nil || flags.NArg() != 0 {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	cmd, err := NewPackRefsCommand(r.repository, options)
+	if err != nil {
+		return 1, fmt.Errorf("init pack-refs cmd: %w", err)
+	}
+
+	out, err := cmd.Run()
+
+	return cmd.Output(out, err, output)
+}
+
+func (r *Runner) reflogCmd(args []string, output io.Writer) (int, error) {
+	usage := `usage: ggit reflog [show] [<ref>]
+   or: ggit reflog expire [--expire=<time>] [--all] [<refs>...]
+   or: ggit reflog delete <ref>@{<n>}...
+`
+
+	action := ReflogShow
+
+	if len(args) > 0 && (args[0] == ReflogShow || args[0] == ReflogExpire || args[0] == ReflogDelete) {
+		action = args[0]
+		args = args[1:]
+	}
+
+	flags := flag.NewFlagSet("reflog", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	options := ReflogOptions{}
+	flags.StringVar(&options.Expire, "expire", "", "prune entries older than the specified time")
+	flags.BoolVar(&options.All, "all", false, "process the reflogs of all references")
+
+	if err := parseFlags(flags, args); err != nil {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	invalid := (action != ReflogExpire && (options.Expire != "" || options.All)) ||
+		(action == ReflogShow && flags.NArg() > 1) ||
+		(action == ReflogDelete && flags.NArg() == 0)
+
+	if invalid {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	cmd, err := NewReflogCommand(r.repository, action, flags.Args(), options)
+	if err != nil {
+		return 1, fmt.Errorf("init reflog cmd: %w", err)
+	}
+
+	out, err := cmd.Run()
+
+	return cmd.Output(out, err, output)
+}
+
+func (r *Runner) updateRefCmd(args []string, output io.Writer) (int, error) {
+	usage := `usage: ggit update-ref [-m <reason>] <refname> <new-oid> [<old-oid>]
+   or: ggit update-ref [-m <reason>] -d <refname> [<old-oid>]
+   or: ggit update-ref [-m <reason>] --stdin
+`
+
+	flags := flag.NewFlagSet("update-ref", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	options := UpdateRefOptions{Committer: signatureFromEnv("COMMITTER")}
+	flags.StringVar(&options.Message, "m", "", "reason of the update")
+	flags.BoolVar(&options.Delete, "d", false, "delete the reference")
+	flags.BoolVar(&options.Stdin, "stdin", false, "read updates from stdin")
+
+	if err := parseFlags(flags, args); err != nil {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	invalid := (options.Stdin && (options.Delete || flags.NArg() != 0)) ||
+		(options.Delete && (flags.NArg() == 0 || flags.NArg() > 2)) ||
+		(!options.Stdin && !options.Delete && (flags.NArg() < 2 || flags.NArg() > 3))
+
+	if invalid {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	cmd, err := NewUpdateRefCommand(r.repository, flags.Args(), options, r.stdin)
+	if err != nil {
+		return 1, fmt.Errorf("init update-ref cmd: %w", err)
+	}
+
+	out, err := cmd.Run()
+
+	return cmd.Output(out, err, output)
+}
+
+func (r *Runner) checkRefFormatCmd(args []string, output io.Writer) (int, error) {
+	usage := `usage: ggit check-ref-format [--normalize] [<options>] <refname>
+   or: ggit check-ref-format --branch <branchname-shorthand>
+`
+
+	flags := flag.NewFlagSet("check-ref-format", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	options := CheckRefFormatOptions{}
+	// string flag so branch names starting with dash are accepted as git does
+	branch := flags.String("branch", "", "check branch name")
+	flags.BoolVar(&options.Normalize, "normalize", false, "print normalized name")
+	flags.BoolVar(&options.AllowOneLevel, "allow-onelevel", false, "accept one level names")
+	flags.BoolVar(&options.RefspecPattern, "refspec-pattern", false, "accept single * in the name")
+
+	if err := parseFlags(flags, args); err != nil {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		options.Branch = options.Branch || f.Name == "branch"
+	})
+
+	name := flags.Arg(0)
+	if options.Branch {
+		name = *branch
+	}
+
+	invalid := (options.Branch && (flags.NArg() != 0 || options.Normalize || options.AllowOneLevel || options.RefspecPattern)) ||
+		(!options.Branch && flags.NArg() != 1)
+
+	if invalid {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	cmd, err := NewCheckRefFormatCommand(r.repository, name, options)
+	if err != nil {
+		return 1, fmt.Errorf("init check-ref-format cmd: %w", err)
+	}
+
+	out, err := cmd.Run()
+
+	return cmd.Output(out, err, output)
+}
+
+func (r *Runner) forEachRefCmd(args []string, output io.Writer) (int, error) {
+	usage := `usage: ggit for-each-ref [--count=<count>] [--sort=<key>]... [--format=<format>] [<pattern>...]
+`
+
+	flags := flag.NewFlagSet("for-each-ref", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	options := ForEachRefOptions{}
+
+	var sortKeys stringsFlag
+
+	flags.StringVar(&options.Format, "format", "", "format to use for the output")
+	flags.Var(&sortKeys, "sort", "field name to sort on")
+	flags.IntVar(&options.Count, "count", 0, "show only <count> matched refs")
+
+	if err := parseFlags(flags, args); err != nil || options.Count < 0 {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	options.Sort = sortKeys
+
+	cmd, err := NewForEachRefCommand(r.repository, flags.Args(), options)
+	if err != nil {
+		return 1, fmt.Errorf("init for-each-ref cmd: %w", err)
+	}
+
+	out, err := cmd.Run()
+
+	return cmd.Output(out, err, output)
+}
+
+func (r *Runner) showRefCmd(args []string, output io.Writer) (int, error) {
+	usage := `usage: ggit show-ref [--head] [-d | --dereference] [-s | --hash] [--heads] [--tags] [<pattern>...]
+   or: ggit show-ref --verify [-d | --dereference] [-s | --hash] <ref>...
+`
+
+	flags := flag.NewFlagSet("show-ref", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	options := ShowRefOptions{}
+	flags.BoolVar(&options.Heads, "heads", false, "only show branches")
+	flags.BoolVar(&options.Tags, "tags", false, "only show tags")
+	flags.BoolVar(&options.Head, "head", false, "show the HEAD reference")
+	flags.BoolVar(&options.Dereference, "dereference", false, "dereference tags into object IDs")
+	flags.BoolVar(&options.Dereference, "d", false, "dereference tags into object IDs")
+	flags.BoolVar(&options.Hash, "hash", false, "only show object ids")
+	flags.BoolVar(&options.Hash, "s", false, "only show object ids")
+	flags.BoolVar(&options.Verify, "verify", false, "stricter reference checking, requires exact ref path")
+
+	if err := parseFlags(flags, args); err != nil || (options.Verify && flags.NArg() == 0) {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	cmd, err := NewShowRefCommand(r.repository, flags.Args(), options)
+	if err != nil {
+		return 1, fmt.Errorf("init show-ref cmd: %w", err)
+	}
+
+	out, err := cmd.Run()
+
+	return cmd.Output(out, err, output)
+}
+
+func (r *Runner) lsFilesCmd(args []string, output io.Writer) (int, error) {
+	usage := `usage: ggit ls-files [--stage] [--debug] [--deleted] [--modified] [--others]
+`
+
+	flags := flag.NewFlagSet("ls-files", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	options := LsFilesOptions{}
+	flags.BoolVar(&options.Stage, "stage", false, "show staged contents' mode bits, object name and stage number")
+	flags.BoolVar(&options.Stage, "s", false, "show staged contents' mode bits, object name and stage number")
+	flags.BoolVar(&options.Debug, "debug", false, "show debugging data")
+	flags.BoolVar(&options.Deleted, "deleted", false, "show deleted files")
+	flags.BoolVar(&options.Deleted, "d", false, "show deleted files")
+	flags.BoolVar(&options.Modified, "modified", false, "show modified files")
+	flags.BoolVar(&options.Modified, "m", false, "show modified files")
+	flags.BoolVar(&options.Others, "others", false, "show untracked files")
+	flags.BoolVar(&options.Others, "o", false, "show untracked files")
+
+	if err := parseFlags(flags, args); err != nil || flags.NArg() != 0 {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	cmd, err := NewLsFilesCommand(r.repository, options)
+	if err != nil {
+		return 1, fmt.Errorf("init ls-files cmd: %w", err)
+	}
+
+	out, err := cmd.Run()
+
+	return cmd.Output(out, err, output)
+}
+
+func (r *Runner) diffCmd(args []string, output io.Writer) (int, error) {
+	usage := `usage: ggit diff [-U<n> | --unified=<n>]
+   or: ggit diff [-U<n> | --unified=<n>] --cached [<commit>]
+   or: ggit diff [-U<n> | --unified=<n>] <commit> <commit>
+   or: ggit diff [-U<n> | --unified=<n>] <commit>..<commit>
+`
+
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	options := DiffOptions{}
+	flags.IntVar(&options.Context, "unified", diff.DefaultContext, "generate diffs with <n> lines of context")
+	flags.IntVar(&options.Context, "U", diff.DefaultContext, "generate diffs with <n> lines of context")
+	flags.BoolVar(&options.Cached, "cached", false, "compare staged changes with HEAD or given commit")
+	flags.BoolVar(&options.Cached, "staged", false, "compare staged changes with HEAD or given commit")
+
+	// -U takes its value without separator, e.g. -U1
+	if err := parseFlags(flags, attachedValues(flags, args, "U")); err != nil || !validDiffRevisions(options.Cached, flags.Args()) || options.Context < 0 {
+		fmt.Fprint(output, usage)
+
+		return 129, nil
+	}
+
+	options.Revisions = flags.Args()
+
+	cmd, err := NewDiffCommand(r.repository, options)
+	if err != nil {
+		return 1, fmt.Errorf("init diff cmd: %w", err)
+	}
+
+	out, err := cmd.Run()
+
+	return cmd.Output(out, err, output)
+}
+
+// validDiffRevisions accepts at most one commit compared with the index, or two commits either as separate
+// arguments or as a range.
+func validDiffRevisions(cached bool, revisions []string) bool {
+	switch len(revisions) {
+	case 0:
+		return true
+	case 1:
+		return cached || strings.Contains(revisions[0], "..")
+	case 2:
+		return !cached
+	default:
+		return false
+	}
+}
+
+// parseFlags parses options given anywhere among the arguments like git does, e.g. "tag v1.0 -m msg",
+// flag package alone stops at the first positional argument. Grouped short options like -sb are split
+// as well. Arguments after "--" are always positional.
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	options := make([]string, 0, len(args))
+	positional := make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+
+			break
+		}
+
+		if len(arg) < 2 || arg[0] != '-' {
+			positional = append(positional, arg)
+
+			continue
+		}
+
+		group := splitShortFlags(flags, arg)
+		options = append(options, group...)
+
+		// the last flag of the group takes the next argument as its value unless it has one already
+		last := strings.TrimLeft(group[len(group)-1], "-")
+		if f := flags.Lookup(last); f != nil && !isBoolFlag(f) && i+1 < len(args) {
+			options = append(options, args[i+1])
+			i++
+		}
+	}
+
+	if len(positional) > 0 {
+		options = append(append(options, "--"), positional...)
+	}
+
+	//nolint:wrapcheck
+	return flags.Parse(options)
+}
+
+// attachedValues returns copy of args where short option written together with its value, e.g. -uno,
+// is turned into -u=no. Other options starting with the same letter, like -untracked-files, are kept.
+func attachedValues(flags *flag.FlagSet, args []string, short string) []string {
+	rewritten := make([]string, len(args))
+	copy(rewritten, args)
+
+	for i, arg := range rewritten {
+		if arg == "--" {
+			break
+		}
+
+		value, ok := strings.CutPrefix(arg, "-"+short)
+		if !ok || value == "" || value[0] == '=' {
+			continue
+		}
+
+		name, _, _ := strings.Cut(arg[1:], "=")
+		if flags.Lookup(name) != nil {
+			continue
+		}
+
+		rewritten[i] = "-" + short + "=" + value
+	}
+
+	return rewritten
+}
+
+// splitShortFlags turns -sb into -s -b when every letter is a known flag and all but the last one are boolean.
+func splitShortFlags(flags *flag.FlagSet, arg string) []string {
+	name := arg[1:]
+	if strings.HasPrefix(name, "-") || strings.Contains(name, "=") || len(name) < 2 || flags.Lookup(name) != nil {
+		return []string{arg}
+	}
+
+	group := make([]string, 0, len(name))
+
+	for i, letter := range name {
+		f := flags.Lookup(string(letter))
+		if f == nil || (i < len(name)-1 && !isBoolFlag(f)) {
+			return []string{arg}
+		}
+
+		group = append(group, "-"+string(letter))
+	}
+
+	return group
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+
+	return ok && boolFlag.IsBoolFlag()
+}
@@ -0,0 +1,23 @@
+package command_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/command"
+	"github.com/LukasJenicek/ggit/internal/repository"
+)
+
+// runCmd runs the command through the runner like the ggit binary does, it returns exit code and output.
+func runCmd(t *testing.T, repo *repository.Repository, name string, args ...string) (int, string) {
+	t.Helper()
+
+	buf := bytes.NewBuffer(nil)
+
+	exit, err := command.NewRunner(repo, nil).RunCmd(t.Context(), name, args, buf)
+	require.NoError(t, err)
+
+	return exit, buf.String()
+}
@@ -0,0 +1,201 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/repository"
+)
+
+var (
+	errNoMatchingRefs = errors.New("no matching refs")
+	errNotValidRef    = errors.New("not a valid ref")
+)
+
+type ShowRefOptions struct {
+	// Show only branches.
+	Heads bool
+	// Show only tags.
+	Tags bool
+	// Show HEAD as well.
+	Head bool
+	// Print also objects annotated tags point to as <ref>^{}.
+	Dereference bool
+	// Print only object ids.
+	Hash bool
+	// Patterns are exact ref names which all must exist.
+	Verify bool
+}
+
+// ShowRefCommand lists refs with object ids they point to.
+type ShowRefCommand struct {
+	repository *repository.Repository
+	patterns   []string
+	options    ShowRefOptions
+
+	failed string
+}
+
+func NewShowRefCommand(repo *repository.Repository, patterns []string, options ShowRefOptions) (*ShowRefCommand, error) {
+	if repo == nil {
+		return nil, errors.New("repository is nil")
+	}
+
+	if options.Verify && len(patterns) == 0 {
+		return nil, errors.New("--verify requires a reference")
+	}
+
+	return &ShowRefCommand{
+		repository: repo,
+		patterns:   patterns,
+		options:    options,
+	}, nil
+}
+
+func (s *ShowRefCommand) Run() ([]byte, error) {
+	refs, err := s.refs()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	for _, ref := range refs {
+		s.writeRef(buf, ref.OID, ref.Name)
+
+		if !s.options.Dereference {
+			continue
+		}
+
+		peeled := ref.Peeled
+		if peeled == "" {
+			peeled, err = s.repository.Database.PeelTag(ref.OID)
+			if err != nil {
+				return nil, fmt.Errorf("peel %s: %w", ref.Name, err)
+			}
+		}
+
+		if peeled != ref.OID {
+			s.writeRef(buf, peeled, ref.Name+"^{}")
+		}
+	}
+
+	if len(refs) == 0 {
+		return nil, errNoMatchingRefs
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (s *ShowRefCommand) refs() ([]*database.Ref, error) {
+	if s.options.Verify {
+		return s.verify()
+	}
+
+	var refs []*database.Ref
+
+	if s.options.Head {
+		oid, err := s.repository.Refs.ReadHead()
+		if err != nil {
+			return nil, fmt.Errorf("read HEAD: %w", err)
+		}
+
+		if oid != "" {
+			refs = append(refs, &database.Ref{Name: database.HEAD, OID: oid})
+		}
+	}
+
+	all, err := s.repository.Refs.ListRefs("refs")
+	if err != nil {
+		return nil, fmt.Errorf("list refs: %w", err)
+	}
+
+	for _, ref := range all {
+		if s.selected(ref.Name) {
+			refs = append(refs, ref)
+		}
+	}
+
+	return refs, nil
+}
+
+// selected filters refs by --heads, --tags and patterns matching the whole name or its trailing components.
+func (s *ShowRefCommand) selected(name string) bool {
+	if (s.options.Heads || s.options.Tags) &&
+		!(s.options.Heads && strings.HasPrefix(name, database.HeadsDir+"/")) &&
+		!(s.options.Tags && strings.HasPrefix(name, tagsDir+"/")) {
+		return false
+	}
+
+	if len(s.patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range s.patterns {
+		if name == pattern || strings.HasSuffix(name, "/"+pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *ShowRefCommand) verify() ([]*database.Ref, error) {
+	refs := make([]*database.Ref, 0, len(s.patterns))
+
+	for _, name := range s.patterns {
+		if name != database.HEAD && !strings.HasPrefix(name, "refs/") {
+			s.failed = name
+
+			return nil, fmt.Errorf("%w: %s", errNotValidRef, name)
+		}
+
+		oid, err := s.repository.Refs.ReadRef(name)
+		if err != nil {
+			return nil, fmt.Errorf("read ref %s: %w", name, err)
+		}
+
+		if oid == "" {
+			s.failed = name
+
+			return nil, fmt.Errorf("%w: %s", errNotValidRef, name)
+		}
+
+		refs = append(refs, &database.Ref{Name: name, OID: oid})
+	}
+
+	return refs, nil
+}
+
+func (s *ShowRefCommand) writeRef(buf *bytes.Buffer, oid, name string) {
+	if s.options.Hash {
+		buf.WriteString(oid + "\n")
+
+		return
+	}
+
+	buf.WriteString(oid + " " + name + "\n")
+}
+
+func (s *ShowRefCommand) Output(msg []byte, err error, stdout io.Writer) (int, error) {
+	if err != nil {
+		switch {
+		case errors.Is(err, errNoMatchingRefs):
+			return 1, nil
+		case errors.Is(err, errNotValidRef):
+			fmt.Fprintf(stdout, "fatal: '%s' - not a valid ref\n", s.failed)
+
+			return 128, nil
+		default:
+			return 1, fmt.Errorf("show-ref cmd: %w", err)
+		}
+	}
+
+	fmt.Fprint(stdout, string(msg))
+
+	return 0, nil
+}
@@ -0,0 +1,104 @@
+package command_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShowRef(t *testing.T) {
+	t.Parallel()
+
+	repo := committedRepository(t)
+
+	head, err := repo.Refs.ReadHead()
+	require.NoError(t, err)
+
+	for _, args := range [][]string{{"v1.0"}, {"-m", "release", "v2.0"}} {
+		exit, _ := runCmd(t, repo, "tag", args...)
+		require.Equal(t, 0, exit)
+	}
+
+	exit, _ := runCmd(t, repo, "branch", "feature/master")
+	require.Equal(t, 0, exit)
+
+	annotated, err := repo.Refs.ReadRef("v2.0")
+	require.NoError(t, err)
+
+	exit, _ = runCmd(t, repo, "pack-refs")
+	require.Equal(t, 0, exit)
+
+	tests := []struct {
+		name     string
+		args     []string
+		exit     int
+		expected string
+	}{
+		{
+			name: "all refs",
+			exit: 0,
+			expected: head + " refs/heads/feature/master\n" +
+				head + " refs/heads/master\n" +
+				head + " refs/tags/v1.0\n" +
+				annotated + " refs/tags/v2.0\n",
+		},
+		{
+			name:     "pattern matches trailing components",
+			args:     []string{"master"},
+			exit:     0,
+			expected: head + " refs/heads/feature/master\n" + head + " refs/heads/master\n",
+		},
+		{
+			name:     "heads with head",
+			args:     []string{"--head", "--heads"},
+			exit:     0,
+			expected: head + " HEAD\n" + head + " refs/heads/feature/master\n" + head + " refs/heads/master\n",
+		},
+		{
+			name:     "dereference tags",
+			args:     []string{"--tags", "-d"},
+			exit:     0,
+			expected: head + " refs/tags/v1.0\n" + annotated + " refs/tags/v2.0\n" + head + " refs/tags/v2.0^{}\n",
+		},
+		{
+			name:     "hash",
+			args:     []string{"-s", "v2.0"},
+			exit:     0,
+			expected: annotated + "\n",
+		},
+		{
+			name:     "verify",
+			args:     []string{"--verify", "refs/heads/master", "HEAD"},
+			exit:     0,
+			expected: head + " refs/heads/master\n" + head + " HEAD\n",
+		},
+		{
+			name:     "verify requires full name",
+			args:     []string{"--verify", "master"},
+			exit:     128,
+			expected: "fatal: 'master' - not a valid ref\n",
+		},
+		{
+			name:     "verify missing ref",
+			args:     []string{"--verify", "refs/heads/missing"},
+			exit:     128,
+			expected: "fatal: 'refs/heads/missing' - not a valid ref\n",
+		},
+		{
+			name:     "no match",
+			args:     []string{"missing"},
+			exit:     1,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			exit, out := runCmd(t, repo, "show-ref", tt.args...)
+			require.Equal(t, tt.exit, exit)
+			require.Equal(t, tt.expected, out)
+		})
+	}
+}
@@ -2,46 +2,540 @@ package command
 
 import (
 	"bytes"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/LukasJenicek/ggit/internal/database"
 	"github.com/LukasJenicek/ggit/internal/ds"
 	"github.com/LukasJenicek/ggit/internal/index"
 	"github.com/LukasJenicek/ggit/internal/repository"
 )
 
+type StatusOptions struct {
+	// Use the short format instead of the long one.
+	Short bool
+	// Version of the machine readable format, 0 when not requested.
+	Porcelain int
+	// Show the branch and tracking info header in the short and porcelain formats.
+	Branch bool
+	// Terminate entries with NUL and do not quote paths.
+	NullTerminated bool
+	// How untracked files are listed, one of untrackedNo, untrackedNormal and untrackedAll.
+	// Empty value uses status.showUntrackedFiles config.
+	UntrackedFiles string
+}
+
+// Untracked files modes.
+const (
+	// untracked files are not listed
+	untrackedNo = "no"
+	// fully untracked directories are collapsed into a single entry
+	untrackedNormal = "normal"
+	// every untracked file is listed
+	untrackedAll = "all"
+)
+
+var errInvalidUntrackedMode = errors.New("invalid untracked files mode")
+
+// Status codes of the short format.
+const (
+	statusUnmodified = ' '
+	statusAdded      = 'A'
+	statusModified   = 'M'
+	statusDeleted    = 'D'
+	statusTypeChange = 'T'
+)
+
+// statusLabels describe status codes in the long format.
+var statusLabels = map[byte]string{
+	statusAdded:      "new file:",
+	statusModified:   "modified:",
+	statusDeleted:    "deleted:",
+	statusTypeChange: "typechange:",
+}
+
+// unmergedCodes are short format codes of conflicted paths indexed by mask of their stages,
+// bit 0 is the common ancestor, bit 1 our side and bit 2 their side.
+var unmergedCodes = [8]string{1: "DD", 2: "AU", 3: "UD", 4: "UA", 5: "DU", 6: "AA", 7: "UU"}
+
+// unmergedLabels describe unmerged codes in the long format.
+var unmergedLabels = map[string]string{
+	"DD": "both deleted:",
+	"AU": "added by us:",
+	"UD": "deleted by them:",
+	"UA": "added by them:",
+	"DU": "deleted by us:",
+	"AA": "both added:",
+	"UU": "both modified:",
+}
+
 // Shows difference between the tree of the HEAD commit, the entries in the index and workspace content.
 type StatusCommand struct {
-	repo *repository.Repository
+	repo    *repository.Repository
+	options StatusOptions
+
+	// stats of tracked files found in the workspace
+	stats      map[string]os.FileInfo
+	headFiles  map[string]*repository.TreeFile
+	indexFiles map[string]*repository.TreeFile
+	// indexChanges holds status code of files staged to be committed
+	indexChanges map[string]byte
+	// workspaceChanges holds status code of tracked files which differ from the index
+	workspaceChanges map[string]byte
+	// conflicts holds index entries of unmerged paths by stage
+	conflicts      map[string][]*index.Entry
+	untrackedFiles []string
+	untrackedMode  string
+
+	failed string
 }
 
-func NewStatusCommand(repo *repository.Repository) (*StatusCommand, error) {
-	return &StatusCommand{repo}, nil
+func NewStatusCommand(repo *repository.Repository, options StatusOptions) (*StatusCommand, error) {
+	return &StatusCommand{repo: repo, options: options}, nil
 }
 
 func (s *StatusCommand) Run() ([]byte, error) {
+	if err := s.collect(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case s.options.Porcelain == 2:
+		return s.printPorcelainV2()
+	case s.options.Short || s.options.Porcelain == 1:
+		return s.printShort()
+	default:
+		return s.printLong(true)
+	}
+}
+
+// commitTemplate returns long format without hints commented out for the commit message template.
+func (s *StatusCommand) commitTemplate() (string, error) {
+	if err := s.collect(); err != nil {
+		return "", err
+	}
+
+	long, err := s.printLong(false)
+	if err != nil {
+		return "", err
+	}
+
+	var template strings.Builder
+
+	for _, line := range strings.SplitAfter(string(long), "\n") {
+		switch {
+		case line == "":
+		case line == "\n":
+			template.WriteString("#\n")
+		case strings.HasPrefix(line, "\t"):
+			template.WriteString("#" + line)
+		default:
+			template.WriteString("# " + line)
+		}
+	}
+
+	return template.String(), nil
+}
+
+// collect compares HEAD, index and workspace and stores the differences.
+func (s *StatusCommand) collect() error {
 	// Load entries into memory
 	index, err := s.repo.Index.Load()
 	if err != nil {
-		return nil, fmt.Errorf("load index entries: %w", err)
+		return fmt.Errorf("load index entries: %w", err)
 	}
 
-	untrackedFiles, err := s.scanWorkspace(index, "")
+	s.untrackedMode, err = s.resolveUntrackedMode()
 	if err != nil {
-		return nil, fmt.Errorf("scan workspace: %w", err)
+		return err
 	}
 
+	s.stats = make(map[string]os.FileInfo)
+	s.indexChanges = make(map[string]byte)
+	s.workspaceChanges = make(map[string]byte)
+
+	s.untrackedFiles, err = s.scanWorkspace(index, "")
+	if err != nil {
+		return fmt.Errorf("scan workspace: %w", err)
+	}
+
+	if err := s.checkIndexEntries(index); err != nil {
+		return fmt.Errorf("check index entries: %w", err)
+	}
+
+	s.headFiles, err = s.repo.HeadFiles()
+	if err != nil {
+		return fmt.Errorf("read head tree: %w", err)
+	}
+
+	s.indexFiles = repository.IndexFiles(index)
+
+	for _, change := range repository.CompareFiles(s.headFiles, s.indexFiles) {
+		s.indexChanges[change.Path] = byte(change.Type)
+	}
+
+	// unmerged paths are missing in index files, they are reported on their own instead of as deleted
+	s.conflicts = index.Conflicts
+	for path := range s.conflicts {
+		delete(s.indexChanges, path)
+	}
+
+	return nil
+}
+
+func (s *StatusCommand) printShort() ([]byte, error) {
 	buf := bytes.NewBuffer(nil)
-	for _, f := range untrackedFiles {
-		buf.WriteString(fmt.Sprintf("?? %s\n", f))
+
+	if s.options.Branch {
+		header, err := s.branchHeader()
+		if err != nil {
+			return nil, err
+		}
+
+		buf.WriteString(strings.TrimSuffix(header, "\n") + s.terminator())
+	}
+
+	for _, path := range s.changedPaths() {
+		if code := s.unmergedCode(path); code != "" {
+			buf.WriteString(code + " " + s.formatPath(path) + s.terminator())
+
+			continue
+		}
+
+		fmt.Fprintf(buf, "%c%c %s%s", s.code(s.indexChanges, path), s.code(s.workspaceChanges, path), s.formatPath(path), s.terminator())
+	}
+
+	for _, f := range s.untrackedFiles {
+		buf.WriteString("?? " + s.formatPath(f) + s.terminator())
 	}
 
 	return buf.Bytes(), nil
 }
 
+// printPorcelainV2 writes "# branch.*" headers followed by one line per changed and untracked path:
+// 1 <XY> <sub> <mH> <mI> <mW> <hH> <hI> <path>, where unchanged status is printed as a dot, and
+// u <XY> <sub> <m1> <m2> <m3> <mW> <h1> <h2> <h3> <path> for unmerged paths with modes and ids of the stages.
+// Renames are not detected, so there are no "2" lines.
+func (s *StatusCommand) printPorcelainV2() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+
+	if s.options.Branch {
+		if err := s.writeBranchHeadersV2(buf); err != nil {
+			return nil, err
+		}
+	}
+
+	paths := s.changedPaths()
+
+	for _, path := range paths {
+		if s.unmergedCode(path) != "" {
+			continue
+		}
+
+		x, y := s.code(s.indexChanges, path), s.code(s.workspaceChanges, path)
+		if x == statusUnmodified {
+			x = '.'
+		}
+
+		if y == statusUnmodified {
+			y = '.'
+		}
+
+		headMode, headOID := "000000", database.NullOID
+		if file, ok := s.headFiles[path]; ok {
+			headMode, headOID = file.Mode, file.OID
+		}
+
+		indexMode, indexOID := "000000", database.NullOID
+		if file, ok := s.indexFiles[path]; ok {
+			indexMode, indexOID = file.Mode, file.OID
+		}
+
+		fmt.Fprintf(buf, "1 %c%c N... %06s %06s %06s %s %s %s%s",
+			x, y, headMode, indexMode, s.workspaceMode(path), headOID, indexOID, s.formatPath(path), s.terminator())
+	}
+
+	// unmerged paths follow the changed ones like in git
+	for _, path := range paths {
+		code := s.unmergedCode(path)
+		if code == "" {
+			continue
+		}
+
+		modes := [3]string{"000000", "000000", "000000"}
+		oids := [3]string{database.NullOID, database.NullOID, database.NullOID}
+
+		for _, entry := range s.conflicts[path] {
+			modes[entry.Stage()-1] = fmt.Sprintf("%o", entry.Mode)
+			oids[entry.Stage()-1] = hex.EncodeToString(entry.OID)
+		}
+
+		fmt.Fprintf(buf, "u %s N... %06s %06s %06s %06s %s %s %s %s%s",
+			code, modes[0], modes[1], modes[2], s.workspaceMode(path), oids[0], oids[1], oids[2], s.formatPath(path), s.terminator())
+	}
+
+	for _, path := range s.untrackedFiles {
+		buf.WriteString("? " + s.formatPath(path) + s.terminator())
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (s *StatusCommand) writeBranchHeadersV2(buf *bytes.Buffer) error {
+	head, err := s.repo.Refs.Head()
+	if err != nil {
+		return fmt.Errorf("read HEAD: %w", err)
+	}
+
+	oid := head.OID
+	if oid == "" {
+		oid = "(initial)"
+	}
+
+	branch := "(detached)"
+	if !head.Detached() {
+		branch = head.Branch()
+	}
+
+	buf.WriteString("# branch.oid " + oid + s.terminator())
+	buf.WriteString("# branch.head " + branch + s.terminator())
+
+	if head.Detached() {
+		return nil
+	}
+
+	upstream, err := s.repo.Upstream(database.HeadsDir + "/" + head.Branch())
+	if err != nil || upstream == "" {
+		return err
+	}
+
+	buf.WriteString("# branch.upstream " + (&database.Ref{Name: upstream}).ShortName() + s.terminator())
+
+	upstreamOID, err := s.repo.Refs.ReadRef(upstream)
+	if err != nil {
+		return fmt.Errorf("read upstream: %w", err)
+	}
+
+	// ahead and behind counts are known only when both sides exist
+	if upstreamOID == "" || head.OID == "" {
+		return nil
+	}
+
+	ahead, behind, err := s.repo.AheadBehind(head.OID, upstreamOID)
+	if err != nil {
+		return fmt.Errorf("count ahead and behind: %w", err)
+	}
+
+	fmt.Fprintf(buf, "# branch.ab +%d -%d%s", ahead, behind, s.terminator())
+
+	return nil
+}
+
+// workspaceMode returns octal mode of the workspace file, zeros when it was deleted.
+func (s *StatusCommand) workspaceMode(path string) string {
+	stat, ok := s.stats[path]
+
+	switch {
+	case !ok:
+		return "000000"
+	case stat.Mode()&os.ModeSymlink != 0:
+		return "120000"
+	default:
+		return fmt.Sprintf("%o", index.ModeFromFileInfo(stat))
+	}
+}
+
+func (s *StatusCommand) terminator() string {
+	if s.options.NullTerminated {
+		return "\x00"
+	}
+
+	return "\n"
+}
+
+// formatPath quotes paths with special characters like git does, NUL terminated output keeps them verbatim.
+// Short format quotes also paths with spaces as they would be ambiguous.
+func (s *StatusCommand) formatPath(path string) string {
+	if s.options.NullTerminated {
+		return path
+	}
+
+	quoted := quotePath(path)
+	if quoted == path && s.options.Porcelain != 2 && strings.Contains(path, " ") {
+		return `"` + path + `"`
+	}
+
+	return quoted
+}
+
+// printLong writes human readable sections with hints how to change the state of listed files.
+// Without hints, as in the commit message template, the final summary is left out as well.
+func (s *StatusCommand) printLong(hints bool) ([]byte, error) {
+	head, err := s.repo.Refs.Head()
+	if err != nil {
+		return nil, fmt.Errorf("read HEAD: %w", err)
+	}
+
+	description, err := headDescription(s.repo)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBuffer(nil)
+	buf.WriteString(description + "\n")
+
+	unborn := head.OID == ""
+	switch {
+	case unborn && hints:
+		buf.WriteString("\nNo commits yet\n\n")
+	case unborn:
+		buf.WriteString("\nInitial commit\n\n")
+	}
+
+	// ggit has no commands to unstage or discard changes, hints mention only add
+	if len(s.indexChanges) > 0 {
+		buf.WriteString("Changes to be committed:\n")
+		s.printChanges(buf, s.indexChanges)
+	}
+
+	if len(s.conflicts) > 0 {
+		s.printUnmerged(buf, hints)
+	}
+
+	if len(s.workspaceChanges) > 0 {
+		// deleted files cannot be staged by add
+		deleted := false
+		for _, code := range s.workspaceChanges {
+			if code == statusDeleted {
+				deleted = true
+			}
+		}
+
+		buf.WriteString("Changes not staged for commit:\n")
+
+		if hints && !deleted {
+			buf.WriteString("  (use \"ggit add <file>...\" to update what will be committed)\n")
+		}
+
+		s.printChanges(buf, s.workspaceChanges)
+	}
+
+	if len(s.untrackedFiles) > 0 {
+		buf.WriteString("Untracked files:\n")
+
+		if hints {
+			buf.WriteString("  (use \"ggit add <file>...\" to include in what will be committed)\n")
+		}
+
+		for _, path := range s.untrackedFiles {
+			buf.WriteString("\t" + quotePath(path) + "\n")
+		}
+
+		buf.WriteString("\n")
+	} else if s.untrackedMode == untrackedNo && len(s.indexChanges) > 0 {
+		if hints {
+			buf.WriteString("Untracked files not listed (use -u option to show untracked files)\n")
+		} else {
+			buf.WriteString("Untracked files not listed\n")
+		}
+	}
+
+	switch {
+	case !hints:
+	case len(s.indexChanges) > 0:
+	case len(s.workspaceChanges) > 0 || len(s.conflicts) > 0:
+		buf.WriteString("no changes added to commit (use \"ggit add\")\n")
+	case len(s.untrackedFiles) > 0:
+		buf.WriteString("nothing added to commit but untracked files present (use \"ggit add\" to track)\n")
+	case unborn:
+		buf.WriteString("nothing to commit (create/copy files and use \"ggit add\" to track)\n")
+	case s.untrackedMode == untrackedNo:
+		buf.WriteString("nothing to commit (use -u to show untracked files)\n")
+	default:
+		buf.WriteString("nothing to commit, working tree clean\n")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (s *StatusCommand) printChanges(buf *bytes.Buffer, changes map[string]byte) {
+	paths := make([]string, 0, len(changes))
+	for path := range changes {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		fmt.Fprintf(buf, "\t%-12s%s\n", statusLabels[changes[path]], quotePath(path))
+	}
+
+	buf.WriteString("\n")
+}
+
+// printUnmerged writes conflicted paths with a hint how to resolve them. Paths deleted by either side
+// need git rm which ggit does not have, so the add hint is given only when no side deleted the path.
+func (s *StatusCommand) printUnmerged(buf *bytes.Buffer, hints bool) {
+	paths := make([]string, 0, len(s.conflicts))
+	deleted := false
+
+	for path := range s.conflicts {
+		paths = append(paths, path)
+
+		if strings.Contains(s.unmergedCode(path), "D") {
+			deleted = true
+		}
+	}
+
+	sort.Strings(paths)
+
+	buf.WriteString("Unmerged paths:\n")
+
+	if hints && !deleted {
+		buf.WriteString("  (use \"ggit add <file>...\" to mark resolution)\n")
+	}
+
+	for _, path := range paths {
+		fmt.Fprintf(buf, "\t%-17s%s\n", unmergedLabels[s.unmergedCode(path)], quotePath(path))
+	}
+
+	buf.WriteString("\n")
+}
+
+// unmergedCode returns short format code of conflicted path, empty string for paths without conflict.
+func (s *StatusCommand) unmergedCode(path string) string {
+	mask := 0
+	for _, entry := range s.conflicts[path] {
+		mask |= 1 << (entry.Stage() - 1)
+	}
+
+	return unmergedCodes[mask]
+}
+
+// branchHeader
+// Short format of the branch line: "## master", "## No commits yet on master" or "## HEAD (no branch)".
+func (s *StatusCommand) branchHeader() (string, error) {
+	head, err := s.repo.Refs.Head()
+	if err != nil {
+		return "", fmt.Errorf("read HEAD: %w", err)
+	}
+
+	switch {
+	case head.Detached():
+		return "## HEAD (no branch)\n", nil
+	case head.OID == "":
+		return "## No commits yet on " + head.Branch() + "\n", nil
+	default:
+		return "## " + head.Branch() + "\n", nil
+	}
+}
+
 func (s *StatusCommand) scanWorkspace(index *index.Index, dirPrefix string) ([]string, error) {
 	stats, err := s.repo.Workspace.ListDir(dirPrefix)
 	if err != nil {
@@ -51,12 +545,10 @@ func (s *StatusCommand) scanWorkspace(index *index.Index, dirPrefix string) ([]s
 	untrackedFiles := ds.Set[string]{}
 
 	for _, stat := range stats {
-		path := stat.RelPath
+		path := filepath.Join(dirPrefix, stat.RelPath)
 
 		if index.Tracked(path) {
 			if stat.FileInfo.IsDir() {
-				path = filepath.Join(dirPrefix, path)
-
 				files, err := s.scanWorkspace(index, path)
 				if err != nil {
 					return nil, fmt.Errorf("scan workspace: %w", err)
@@ -65,13 +557,15 @@ func (s *StatusCommand) scanWorkspace(index *index.Index, dirPrefix string) ([]s
 				for _, file := range files {
 					untrackedFiles.Add(file)
 				}
+			} else {
+				s.stats[path] = stat.FileInfo
 			}
 
 			continue
 		}
 
-		if dirPrefix != "" {
-			path = filepath.Join(dirPrefix, path)
+		if s.untrackedMode == untrackedNo {
+			continue
 		}
 
 		trackable, err := s.trackableFile(index, path, stat.FileInfo)
@@ -80,6 +574,19 @@ func (s *StatusCommand) scanWorkspace(index *index.Index, dirPrefix string) ([]s
 		}
 
 		if trackable {
+			if stat.FileInfo.IsDir() && s.untrackedMode == untrackedAll {
+				files, err := s.scanWorkspace(index, path)
+				if err != nil {
+					return nil, fmt.Errorf("scan workspace: %w", err)
+				}
+
+				for _, file := range files {
+					untrackedFiles.Add(file)
+				}
+
+				continue
+			}
+
 			if stat.FileInfo.IsDir() {
 				path += string(os.PathSeparator)
 			} else {
@@ -99,6 +606,72 @@ func (s *StatusCommand) scanWorkspace(index *index.Index, dirPrefix string) ([]s
 	}), nil
 }
 
+// checkIndexEntries compares index entries with the workspace files. Content is hashed only when stat data
+// do not tell whether the file changed.
+func (s *StatusCommand) checkIndexEntries(index *index.Index) error {
+	for _, entry := range index.Entries.SortedValues() {
+		path := string(entry.Path)
+
+		stat, ok := s.stats[path]
+
+		switch {
+		case !ok:
+			s.workspaceChanges[path] = statusDeleted
+		case !stat.Mode().IsRegular():
+			s.workspaceChanges[path] = statusTypeChange
+		case !entry.StatMatch(stat):
+			s.workspaceChanges[path] = statusModified
+		case entry.TimesMatch(stat):
+			continue
+		default:
+			content, err := s.repo.Workspace.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("read workspace file: %w", err)
+			}
+
+			oid, err := database.Hash(database.NewBlob(content))
+			if err != nil {
+				return fmt.Errorf("hash %s: %w", path, err)
+			}
+
+			if !bytes.Equal(oid, entry.OID) {
+				s.workspaceChanges[path] = statusModified
+			}
+		}
+	}
+
+	return nil
+}
+
+// changedPaths returns sorted paths which are staged or changed in the workspace.
+func (s *StatusCommand) changedPaths() []string {
+	paths := ds.Set[string]{}
+
+	for path := range s.indexChanges {
+		paths.Add(path)
+	}
+
+	for path := range s.workspaceChanges {
+		paths.Add(path)
+	}
+
+	for path := range s.conflicts {
+		paths.Add(path)
+	}
+
+	return paths.SortedValues(func(a, b string) bool {
+		return a < b
+	})
+}
+
+func (s *StatusCommand) code(changes map[string]byte, path string) byte {
+	if code, ok := changes[path]; ok {
+		return code
+	}
+
+	return statusUnmodified
+}
+
 func (s *StatusCommand) trackableFile(index *index.Index, path string, stat os.FileInfo) (bool, error) {
 	if stat == nil {
 		return false, errors.New("stat is nil")
@@ -131,8 +704,43 @@ func (s *StatusCommand) trackableFile(index *index.Index, path string, stat os.F
 	return false, nil
 }
 
+// resolveUntrackedMode prefers the option over status.showUntrackedFiles from repository and user config.
+func (s *StatusCommand) resolveUntrackedMode() (string, error) {
+	mode := s.options.UntrackedFiles
+
+	if mode == "" {
+		values, err := s.repo.LocalConfig()
+		if err != nil {
+			return "", err
+		}
+
+		mode, _ = values["status"]["showuntrackedfiles"].(string)
+	}
+
+	if mode == "" && s.repo.GitConfig != nil {
+		mode = s.repo.GitConfig.Status.ShowUntrackedFiles
+	}
+
+	switch mode {
+	case "":
+		return untrackedNormal, nil
+	case untrackedNo, untrackedNormal, untrackedAll:
+		return mode, nil
+	default:
+		s.failed = mode
+
+		return "", fmt.Errorf("%w: %s", errInvalidUntrackedMode, mode)
+	}
+}
+
 func (s *StatusCommand) Output(msg []byte, err error, stdout io.Writer) (int, error) {
 	if err != nil {
+		if errors.Is(err, errInvalidUntrackedMode) {
+			fmt.Fprintf(stdout, "fatal: Invalid untracked files mode '%s'\n", s.failed)
+
+			return 128, nil
+		}
+
 		return 1, fmt.Errorf("output: %w", err)
 	}
 
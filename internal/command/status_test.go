@@ -1,7 +1,12 @@
 package command_test
 
 import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
 	"os"
+	"path/filepath"
+	"syscall"
 	"testing"
 	"testing/fstest"
 	"time"
@@ -10,7 +15,9 @@ import (
 
 	"github.com/LukasJenicek/ggit/internal/clock"
 	"github.com/LukasJenicek/ggit/internal/command"
+	"github.com/LukasJenicek/ggit/internal/database"
 	"github.com/LukasJenicek/ggit/internal/filesystem/memory"
+	"github.com/LukasJenicek/ggit/internal/index"
 	"github.com/LukasJenicek/ggit/internal/repository"
 )
 
@@ -45,7 +52,7 @@ func TestListingUntrackedFiles(t *testing.T) {
 	_, err = initCmd.Run()
 	require.NoError(t, err)
 
-	statCmd, err := command.NewStatusCommand(repo)
+	statCmd, err := command.NewStatusCommand(repo, command.StatusOptions{Short: true})
 	require.NoError(t, err)
 
 	addCmd, err := command.NewAddCommand([]string{"hello.txt"}, repo)
@@ -57,7 +64,7 @@ func TestListingUntrackedFiles(t *testing.T) {
 	output, err := statCmd.Run()
 	require.NoError(t, err)
 
-	require.EqualValues(t, "?? world.txt\n", string(output))
+	require.EqualValues(t, "A  hello.txt\n?? world.txt\n", string(output))
 }
 
 func TestListingUntrackedDirectories(t *testing.T) {
@@ -115,7 +122,7 @@ func TestListingUntrackedDirectories(t *testing.T) {
 	_, err = initCmd.Run()
 	require.NoError(t, err)
 
-	statCmd, err := command.NewStatusCommand(repo)
+	statCmd, err := command.NewStatusCommand(repo, command.StatusOptions{Short: true})
 	require.NoError(t, err)
 
 	addCmd, err := command.NewAddCommand([]string{"hello.txt", "internal/hello.txt"}, repo)
@@ -127,5 +134,601 @@ func TestListingUntrackedDirectories(t *testing.T) {
 	output, err := statCmd.Run()
 	require.NoError(t, err)
 
-	require.EqualValues(t, "?? docs/\n?? internal/help/\n?? internal/world.txt\n", string(output))
+	require.EqualValues(t, "A  hello.txt\nA  internal/hello.txt\n?? docs/\n?? internal/help/\n?? internal/world.txt\n", string(output))
+}
+
+func TestStatusBranchHeader(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unborn branch", func(t *testing.T) {
+		t.Parallel()
+
+		statCmd, err := command.NewStatusCommand(stagedRepository(t), command.StatusOptions{Short: true, Branch: true})
+		require.NoError(t, err)
+
+		output, err := statCmd.Run()
+		require.NoError(t, err)
+		require.Equal(t, "## No commits yet on master\nA  hello.txt\n", string(output))
+	})
+
+	t.Run("branch", func(t *testing.T) {
+		t.Parallel()
+
+		statCmd, err := command.NewStatusCommand(committedRepository(t), command.StatusOptions{Short: true, Branch: true})
+		require.NoError(t, err)
+
+		output, err := statCmd.Run()
+		require.NoError(t, err)
+		require.Equal(t, "## master\n", string(output))
+	})
+
+	t.Run("grouped and trailing options", func(t *testing.T) {
+		t.Parallel()
+
+		repo := stagedRepository(t)
+
+		for _, args := range [][]string{{"-sb"}, {"-s", "-b"}, {"-bs"}} {
+			exit, out := runCmd(t, repo, "status", args...)
+			require.Equal(t, 0, exit, args)
+			require.Equal(t, "## No commits yet on master\nA  hello.txt\n", out, args)
+		}
+
+		exit, out := runCmd(t, repo, "status", "-sx")
+		require.Equal(t, 129, exit)
+		require.Contains(t, out, "usage: ggit status")
+	})
+
+	t.Run("detached head", func(t *testing.T) {
+		t.Parallel()
+
+		repo := committedRepository(t)
+
+		head, err := repo.Refs.ReadHead()
+		require.NoError(t, err)
+		require.NoError(t, repo.Refs.DetachHead(head))
+
+		statCmd, err := command.NewStatusCommand(repo, command.StatusOptions{Short: true, Branch: true})
+		require.NoError(t, err)
+
+		output, err := statCmd.Run()
+		require.NoError(t, err)
+		require.Equal(t, "## HEAD (no branch)\n", string(output))
+	})
+}
+
+func TestStatusWorkspaceChanges(t *testing.T) {
+	t.Parallel()
+
+	touched := func(mode uint32, size int64) *syscall.Stat_t {
+		stat := defaultStat(mode, size)
+		stat.Mtim.Sec++
+		stat.Ctim.Sec++
+
+		return stat
+	}
+
+	tests := []struct {
+		name     string
+		change   func(fs fstest.MapFS)
+		expected string
+	}{
+		{
+			name:     "unchanged",
+			change:   func(fstest.MapFS) {},
+			expected: "",
+		},
+		{
+			name: "touched with same content",
+			change: func(fs fstest.MapFS) {
+				fs["tmp/test/hello.txt"].Sys = touched(0o644, 5)
+			},
+			expected: "",
+		},
+		{
+			name: "same size different content",
+			change: func(fs fstest.MapFS) {
+				fs["tmp/test/hello.txt"] = &fstest.MapFile{Data: []byte("HELLO"), Mode: 0o644, Sys: touched(0o644, 5)}
+			},
+			expected: " M hello.txt\n",
+		},
+		{
+			name: "size changed",
+			change: func(fs fstest.MapFS) {
+				fs["tmp/test/docs/readme.md"] = &fstest.MapFile{Data: []byte("docs!"), Mode: 0o644, Sys: defaultStat(0o644, 5)}
+			},
+			expected: " M docs/readme.md\n",
+		},
+		{
+			name: "mode changed",
+			change: func(fs fstest.MapFS) {
+				fs["tmp/test/hello.txt"] = &fstest.MapFile{Data: []byte("hello"), Mode: 0o755, Sys: defaultStat(0o755, 5)}
+			},
+			expected: " M hello.txt\n",
+		},
+		{
+			name: "deleted",
+			change: func(fs fstest.MapFS) {
+				delete(fs, "tmp/test/world.txt")
+				delete(fs, "tmp/test/docs/readme.md")
+				delete(fs, "tmp/test/docs")
+			},
+			expected: " D docs/readme.md\n D world.txt\n",
+		},
+		{
+			name: "replaced by symlink",
+			change: func(fs fstest.MapFS) {
+				fs["tmp/test/world.txt"] = &fstest.MapFile{Data: []byte("hello.txt"), Mode: os.ModeSymlink | 0o777}
+				fs["tmp/test/new.txt"] = &fstest.MapFile{Data: []byte("new"), Mode: 0o644, Sys: defaultStat(0o644, 3)}
+			},
+			expected: " T world.txt\n?? new.txt\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo, fs := committedStatusRepository(t)
+
+			tt.change(fs)
+
+			statCmd, err := command.NewStatusCommand(repo, command.StatusOptions{Short: true})
+			require.NoError(t, err)
+
+			output, err := statCmd.Run()
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(output))
+		})
+	}
+}
+
+func TestStatusIndexChanges(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no commits yet", func(t *testing.T) {
+		t.Parallel()
+
+		statCmd, err := command.NewStatusCommand(stagedRepository(t), command.StatusOptions{Short: true})
+		require.NoError(t, err)
+
+		output, err := statCmd.Run()
+		require.NoError(t, err)
+		require.Equal(t, "A  hello.txt\n", string(output))
+	})
+
+	t.Run("staged and workspace changes", func(t *testing.T) {
+		t.Parallel()
+
+		repo, fs := committedStatusRepository(t)
+
+		fs["tmp/test/hello.txt"] = &fstest.MapFile{Data: []byte("hello!"), Mode: 0o644, Sys: defaultStat(0o644, 6)}
+		fs["tmp/test/new.txt"] = &fstest.MapFile{Data: []byte("new"), Mode: 0o644, Sys: defaultStat(0o644, 3)}
+		fs["tmp/test/docs/readme.md"] = &fstest.MapFile{Data: []byte("docs"), Mode: 0o755, Sys: defaultStat(0o755, 4)}
+		require.NoError(t, repo.Add([]string{"hello.txt", "new.txt", "docs/readme.md"}))
+
+		fs["tmp/test/hello.txt"] = &fstest.MapFile{Data: []byte("hello!!"), Mode: 0o644, Sys: defaultStat(0o644, 7)}
+		delete(fs, "tmp/test/new.txt")
+
+		statCmd, err := command.NewStatusCommand(repo, command.StatusOptions{Short: true})
+		require.NoError(t, err)
+
+		output, err := statCmd.Run()
+		require.NoError(t, err)
+		require.Equal(t, "M  docs/readme.md\nMM hello.txt\nAD new.txt\n", string(output))
+	})
+}
+
+// committedStatusRepository creates repository with hello.txt, world.txt and docs/readme.md committed,
+// the returned file system can be changed to simulate workspace edits.
+func committedStatusRepository(t *testing.T) (*repository.Repository, fstest.MapFS) {
+	t.Helper()
+
+	fs := fstest.MapFS{
+		"tmp/test/":               &fstest.MapFile{Mode: os.ModeDir},
+		"tmp/test/hello.txt":      &fstest.MapFile{Data: []byte("hello"), Mode: 0o644, Sys: defaultStat(0o644, 5)},
+		"tmp/test/world.txt":      &fstest.MapFile{Data: []byte("world"), Mode: 0o644, Sys: defaultStat(0o644, 5)},
+		"tmp/test/docs":           &fstest.MapFile{Mode: os.ModeDir},
+		"tmp/test/docs/readme.md": &fstest.MapFile{Data: []byte("docs"), Mode: 0o644, Sys: defaultStat(0o644, 4)},
+	}
+
+	repo, err := repository.New(memory.New(fs), clock.NewFakeClock(time.Date(2000, 12, 15, 17, 8, 0o0, 0, time.UTC)), "tmp/test")
+	require.NoError(t, err)
+	require.NoError(t, repo.Init())
+	require.NoError(t, repo.Add([]string{"."}))
+
+	_, err = repo.Commit("all", repository.CommitOptions{})
+	require.NoError(t, err)
+
+	return repo, fs
+}
+
+func TestStatusLongFormat(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no commits yet", func(t *testing.T) {
+		t.Parallel()
+
+		exit, out := runCmd(t, stagedRepository(t), "status")
+		require.Equal(t, 0, exit)
+		require.Equal(t, "On branch master\n"+
+			"\n"+
+			"No commits yet\n"+
+			"\n"+
+			"Changes to be committed:\n"+
+			"\tnew file:   hello.txt\n"+
+			"\n", out)
+	})
+
+	t.Run("working tree clean", func(t *testing.T) {
+		t.Parallel()
+
+		exit, out := runCmd(t, committedRepository(t), "status")
+		require.Equal(t, 0, exit)
+		require.Equal(t, "On branch master\nnothing to commit, working tree clean\n", out)
+	})
+
+	t.Run("all sections", func(t *testing.T) {
+		t.Parallel()
+
+		repo, fs := committedStatusRepository(t)
+
+		fs["tmp/test/new.txt"] = &fstest.MapFile{Data: []byte("new"), Mode: 0o644, Sys: defaultStat(0o644, 3)}
+		require.NoError(t, repo.Add([]string{"new.txt"}))
+
+		fs["tmp/test/hello.txt"] = &fstest.MapFile{Data: []byte("hello!"), Mode: 0o644, Sys: defaultStat(0o644, 6)}
+		fs["tmp/test/untracked.txt"] = &fstest.MapFile{Data: []byte("?"), Mode: 0o644, Sys: defaultStat(0o644, 1)}
+		delete(fs, "tmp/test/world.txt")
+
+		head, err := repo.Refs.ReadHead()
+		require.NoError(t, err)
+		require.NoError(t, repo.Refs.DetachHead(head))
+
+		exit, out := runCmd(t, repo, "status")
+		require.Equal(t, 0, exit)
+		require.Equal(t, "HEAD detached at "+head[:7]+"\n"+
+			"Changes to be committed:\n"+
+			"\tnew file:   new.txt\n"+
+			"\n"+
+			"Changes not staged for commit:\n"+
+			"\tmodified:   hello.txt\n"+
+			"\tdeleted:    world.txt\n"+
+			"\n"+
+			"Untracked files:\n"+
+			"  (use \"ggit add <file>...\" to include in what will be committed)\n"+
+			"\tuntracked.txt\n"+
+			"\n", out)
+
+		exit, out = runCmd(t, repo, "status", "-s")
+		require.Equal(t, 0, exit)
+		require.Equal(t, " M hello.txt\nA  new.txt\n D world.txt\n?? untracked.txt\n", out)
+	})
+
+	t.Run("only workspace changes", func(t *testing.T) {
+		t.Parallel()
+
+		repo, fs := committedStatusRepository(t)
+		fs["tmp/test/hello.txt"] = &fstest.MapFile{Data: []byte("hello!"), Mode: 0o644, Sys: defaultStat(0o644, 6)}
+
+		exit, out := runCmd(t, repo, "status")
+		require.Equal(t, 0, exit)
+		require.Equal(t, "On branch master\n"+
+			"Changes not staged for commit:\n"+
+			"  (use \"ggit add <file>...\" to update what will be committed)\n"+
+			"\tmodified:   hello.txt\n"+
+			"\n"+
+			"no changes added to commit (use \"ggit add\")\n", out)
+	})
+}
+
+func TestStatusPorcelain(t *testing.T) {
+	t.Parallel()
+
+	repo, fs := committedStatusRepository(t)
+
+	first, err := repo.Refs.ReadHead()
+	require.NoError(t, err)
+
+	root := headCommit(t, repo)
+	second := storeCommit(t, repo, root.RootOID, []string{first}, "second", time.Unix(2000000000, 0))
+	require.NoError(t, repo.Refs.UpdateHead(second, nil, ""))
+	require.NoError(t, repo.Refs.CreateRef("refs/remotes/origin/master", first))
+
+	require.NoError(t, repo.FS.WriteFile(filepath.Join(repo.GitPath, "config"), []byte(
+		"[remote \"origin\"]\n"+
+			"\tfetch = +refs/heads/*:refs/remotes/origin/*\n"+
+			"[branch \"master\"]\n"+
+			"\tremote = origin\n"+
+			"\tmerge = refs/heads/master\n",
+	), 0o644))
+
+	fs["tmp/test/new file.txt"] = &fstest.MapFile{Data: []byte("new"), Mode: 0o755, Sys: defaultStat(0o755, 3)}
+	require.NoError(t, repo.Add([]string{"new file.txt"}))
+
+	fs["tmp/test/hello.txt"] = &fstest.MapFile{Data: []byte("hello!"), Mode: 0o644, Sys: defaultStat(0o644, 6)}
+	fs["tmp/test/tab\there.txt"] = &fstest.MapFile{Data: []byte("?"), Mode: 0o644, Sys: defaultStat(0o644, 1)}
+	delete(fs, "tmp/test/world.txt")
+
+	hello := blobOID(t, "hello")
+	world := blobOID(t, "world")
+	added := blobOID(t, "new")
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name: "v1",
+			args: []string{"--porcelain", "-b"},
+			expected: "## master\n" +
+				" M hello.txt\n" +
+				"A  \"new file.txt\"\n" +
+				" D world.txt\n" +
+				"?? \"tab\\there.txt\"\n",
+		},
+		{
+			name:     "v1 NUL terminated",
+			args:     []string{"-z"},
+			expected: " M hello.txt\x00A  new file.txt\x00 D world.txt\x00?? tab\there.txt\x00",
+		},
+		{
+			name: "v2",
+			args: []string{"--porcelain=v2", "--branch"},
+			expected: "# branch.oid " + second + "\n" +
+				"# branch.head master\n" +
+				"# branch.upstream origin/master\n" +
+				"# branch.ab +1 -0\n" +
+				"1 .M N... 100644 100644 100644 " + hello + " " + hello + " hello.txt\n" +
+				"1 A. N... 000000 100755 100755 " + database.NullOID + " " + added + " new file.txt\n" +
+				"1 .D N... 100644 100644 000000 " + world + " " + world + " world.txt\n" +
+				"? \"tab\\there.txt\"\n",
+		},
+		{
+			name: "v2 NUL terminated",
+			args: []string{"--porcelain=v2", "-z"},
+			expected: "1 .M N... 100644 100644 100644 " + hello + " " + hello + " hello.txt\x00" +
+				"1 A. N... 000000 100755 100755 " + database.NullOID + " " + added + " new file.txt\x00" +
+				"1 .D N... 100644 100644 000000 " + world + " " + world + " world.txt\x00" +
+				"? tab\there.txt\x00",
+		},
+		{
+			name:     "unsupported version",
+			args:     []string{"--porcelain=v3"},
+			expected: "fatal: unsupported porcelain version 'v3'\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, out := runCmd(t, repo, "status", tt.args...)
+			require.Equal(t, tt.expected, out)
+		})
+	}
+
+	t.Run("unborn branch headers", func(t *testing.T) {
+		t.Parallel()
+
+		exit, out := runCmd(t, stagedRepository(t), "status", "--porcelain=v2", "-b")
+		require.Equal(t, 0, exit)
+		require.Equal(t, "# branch.oid (initial)\n"+
+			"# branch.head master\n"+
+			"1 A. N... 000000 100644 100644 "+database.NullOID+" "+blobOID(t, "hello")+" hello.txt\n", out)
+	})
+}
+
+func TestStatusUnmergedPaths(t *testing.T) {
+	t.Parallel()
+
+	repo, fs := committedStatusRepository(t)
+
+	conflict := func(path, content string, stage uint16) *index.Entry {
+		oid, err := hex.DecodeString(blobOID(t, content))
+		require.NoError(t, err)
+
+		return &index.Entry{Ctime: 1739287401, Mode: 0o100644, OID: oid, Flags: stage<<12 | uint16(len(path)), Path: []byte(path)}
+	}
+
+	idx, err := repo.Index.Load()
+	require.NoError(t, err)
+
+	readme, _ := idx.Entries.Get("docs/readme.md")
+	entries := []*index.Entry{
+		readme,
+		conflict("hello.txt", "base", 1),
+		conflict("hello.txt", "ours", 2),
+		conflict("hello.txt", "theirs", 3),
+		conflict("world.txt", "base", 1),
+		conflict("world.txt", "ours", 2),
+	}
+
+	content := []byte{'D', 'I', 'R', 'C', 0, 0, 0, 2, 0, 0, 0, byte(len(entries))}
+
+	for _, entry := range entries {
+		data, err := entry.Content()
+		require.NoError(t, err)
+
+		content = append(content, data...)
+	}
+
+	checksum := sha1.Sum(content)
+	fs["tmp/test/.git/index"] = &fstest.MapFile{Data: append(content, checksum[:]...)}
+
+	exit, out := runCmd(t, repo, "status", "--porcelain=v2")
+	require.Equal(t, 0, exit)
+	require.Equal(t, "u UU N... 100644 100644 100644 100644 "+
+		blobOID(t, "base")+" "+blobOID(t, "ours")+" "+blobOID(t, "theirs")+" hello.txt\n"+
+		"u UD N... 100644 100644 000000 100644 "+
+		blobOID(t, "base")+" "+blobOID(t, "ours")+" "+database.NullOID+" world.txt\n", out)
+
+	exit, out = runCmd(t, repo, "status", "-s")
+	require.Equal(t, 0, exit)
+	require.Equal(t, "UU hello.txt\nUD world.txt\n", out)
+
+	exit, out = runCmd(t, repo, "status")
+	require.Equal(t, 0, exit)
+	require.Equal(t, "On branch master\n"+
+		"Unmerged paths:\n"+
+		"\tboth modified:   hello.txt\n"+
+		"\tdeleted by them: world.txt\n"+
+		"\n"+
+		"no changes added to commit (use \"ggit add\")\n", out)
+
+	exit, out = runCmd(t, repo, "commit", "-m", "merge")
+	require.Equal(t, 128, exit)
+	require.Equal(t, "U\thello.txt\nU\tworld.txt\n"+
+		"error: Committing is not possible because you have unmerged files.\n"+
+		"hint: Fix them up in the work tree, and then use 'ggit add <file>'\n"+
+		"hint: to mark resolution and make a commit.\n"+
+		"fatal: Exiting because of an unresolved conflict.\n", out)
+
+	// adding the file resolves its conflict
+	require.NoError(t, repo.Add([]string{"hello.txt"}))
+
+	exit, out = runCmd(t, repo, "status", "-s")
+	require.Equal(t, 0, exit)
+	require.Equal(t, "UD world.txt\n", out)
+}
+
+func blobOID(t *testing.T, content string) string {
+	t.Helper()
+
+	oid, err := database.Hash(database.NewBlob([]byte(content)))
+	require.NoError(t, err)
+
+	return hex.EncodeToString(oid)
+}
+
+func TestStatusUntrackedFiles(t *testing.T) {
+	t.Parallel()
+
+	untrackedRepository := func(t *testing.T) *repository.Repository {
+		t.Helper()
+
+		repo, fs := committedStatusRepository(t)
+
+		fs["tmp/test/build"] = &fstest.MapFile{Mode: os.ModeDir}
+		fs["tmp/test/build/app.o"] = &fstest.MapFile{Data: []byte("o"), Mode: 0o644, Sys: defaultStat(0o644, 1)}
+		fs["tmp/test/build/lib/lib.o"] = &fstest.MapFile{Data: []byte("o"), Mode: 0o644, Sys: defaultStat(0o644, 1)}
+		fs["tmp/test/docs/draft.md"] = &fstest.MapFile{Data: []byte("d"), Mode: 0o644, Sys: defaultStat(0o644, 1)}
+
+		return repo
+	}
+
+	tests := []struct {
+		name     string
+		config   string
+		args     []string
+		exit     int
+		expected string
+	}{
+		{
+			name:     "normal by default",
+			args:     []string{"-s"},
+			expected: "?? build/\n?? docs/draft.md\n",
+		},
+		{
+			name:     "all",
+			args:     []string{"-s", "-uall"},
+			expected: "?? build/app.o\n?? build/lib/lib.o\n?? docs/draft.md\n",
+		},
+		{
+			name:     "flag without mode lists all",
+			args:     []string{"--porcelain", "--untracked-files"},
+			expected: "?? build/app.o\n?? build/lib/lib.o\n?? docs/draft.md\n",
+		},
+		{
+			name:     "no",
+			args:     []string{"-s", "--untracked-files=no"},
+			expected: "",
+		},
+		{
+			name:     "long option with single dash",
+			args:     []string{"-s", "-untracked-files=no"},
+			expected: "",
+		},
+		{
+			name:     "config",
+			config:   "[status]\n\tshowUntrackedFiles = all\n",
+			args:     []string{"-s"},
+			expected: "?? build/app.o\n?? build/lib/lib.o\n?? docs/draft.md\n",
+		},
+		{
+			name: "config with comments and keys without value",
+			config: "# written by hand\n[core]\n\tbare = false\n\tfilemode\n; untracked files\n" +
+				"[Status]\n\tShowUntrackedFiles = \"all\" # everything\n",
+			args:     []string{"-s"},
+			expected: "?? build/app.o\n?? build/lib/lib.o\n?? docs/draft.md\n",
+		},
+		{
+			name:     "option overrides config",
+			config:   "[status]\n\tshowUntrackedFiles = no\n",
+			args:     []string{"-s", "-unormal"},
+			expected: "?? build/\n?? docs/draft.md\n",
+		},
+		{
+			name:     "long format hides untracked files",
+			config:   "[status]\n\tshowUntrackedFiles = no\n",
+			expected: "On branch master\nnothing to commit (use -u to show untracked files)\n",
+		},
+		{
+			name:     "invalid mode",
+			args:     []string{"-ubad"},
+			exit:     128,
+			expected: "fatal: Invalid untracked files mode 'bad'\n",
+		},
+		{
+			name:     "invalid config",
+			config:   "[status]\n\tshowUntrackedFiles = maybe\n",
+			exit:     128,
+			expected: "fatal: Invalid untracked files mode 'maybe'\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := untrackedRepository(t)
+
+			if tt.config != "" {
+				require.NoError(t, repo.FS.WriteFile(filepath.Join(repo.GitPath, "config"), []byte(tt.config), 0o644))
+			}
+
+			exit, out := runCmd(t, repo, "status", tt.args...)
+			require.Equal(t, tt.exit, exit)
+			require.Equal(t, tt.expected, out)
+		})
+	}
+
+	t.Run("staged changes without untracked files", func(t *testing.T) {
+		t.Parallel()
+
+		repo, fs := committedStatusRepository(t)
+
+		fs["tmp/test/hello.txt"] = &fstest.MapFile{Data: []byte("hello!"), Mode: 0o644, Sys: defaultStat(0o644, 6)}
+		fs["tmp/test/notes.txt"] = &fstest.MapFile{Data: []byte("n"), Mode: 0o644, Sys: defaultStat(0o644, 1)}
+		require.NoError(t, repo.Add([]string{"hello.txt"}))
+
+		exit, out := runCmd(t, repo, "status", "-uno")
+		require.Equal(t, 0, exit)
+		require.Equal(t, "On branch master\n"+
+			"Changes to be committed:\n"+
+			"\tmodified:   hello.txt\n"+
+			"\n"+
+			"Untracked files not listed (use -u option to show untracked files)\n", out)
+	})
+
+	t.Run("arguments are not modified", func(t *testing.T) {
+		t.Parallel()
+
+		repo := untrackedRepository(t)
+		args := []string{"-s", "-uno"}
+
+		exit, err := command.NewRunner(repo, nil).RunCmd(t.Context(), "status", args, bytes.NewBuffer(nil))
+		require.NoError(t, err)
+		require.Equal(t, 0, exit)
+		require.Equal(t, []string{"-s", "-uno"}, args)
+	})
 }
@@ -0,0 +1,306 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/repository"
+	"github.com/LukasJenicek/ggit/internal/revision"
+)
+
+const (
+	tagsDir = "refs/tags"
+
+	tagTemplate = `
+#
+# Write a message for tag:
+#   %s
+# Lines starting with '#' will be ignored.
+`
+)
+
+// errTagNotDeleted is returned when at least one of the tags given to -d could not be deleted.
+var errTagNotDeleted = errors.New("tag not deleted")
+
+type TagOptions struct {
+	// List tags, arguments are treated as patterns.
+	List bool
+	// Delete given tags.
+	Delete bool
+	// Create annotated tag object, implied by Messages and File.
+	Annotate bool
+	// Replace existing tag.
+	Force bool
+	// Messages given by -m, each one becomes a separate paragraph.
+	Messages []string
+	// File given by -F, "-" reads the message from stdin.
+	File   string
+	Tagger repository.Signature
+}
+
+// TagCommand lists, creates and deletes tags.
+type TagCommand struct {
+	repository *repository.Repository
+	args       []string
+	options    TagOptions
+	stdin      io.Reader
+	editor     EditorFunc
+
+	failed string
+}
+
+func NewTagCommand(
+	repo *repository.Repository,
+	args []string,
+	options TagOptions,
+	stdin io.Reader,
+	editor EditorFunc,
+) (*TagCommand, error) {
+	if repo == nil {
+		return nil, errors.New("repository is nil")
+	}
+
+	if options.Delete && len(args) == 0 {
+		return nil, errors.New("tag name required")
+	}
+
+	if !options.List && !options.Delete && len(args) > 2 {
+		return nil, errors.New("too many arguments")
+	}
+
+	if len(options.Messages) > 0 && options.File != "" {
+		return nil, errors.New("only one of -m and -F can be used")
+	}
+
+	return &TagCommand{
+		repository: repo,
+		args:       args,
+		options:    options,
+		stdin:      stdin,
+		editor:     editor,
+	}, nil
+}
+
+func (t *TagCommand) Run() ([]byte, error) {
+	switch {
+	case t.options.Delete:
+		return t.delete()
+	case t.options.List || len(t.args) == 0:
+		return t.list()
+	default:
+		return t.create()
+	}
+}
+
+func (t *TagCommand) list() ([]byte, error) {
+	refs, err := t.repository.Refs.ListRefs(tagsDir)
+	if err != nil {
+		return nil, fmt.Errorf("list tags: %w", err)
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	for _, ref := range refs {
+		name := ref.ShortName()
+
+		if t.matches(name) {
+			buf.WriteString(name + "\n")
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (t *TagCommand) matches(name string) bool {
+	if len(t.args) == 0 {
+		return true
+	}
+
+	for _, pattern := range t.args {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *TagCommand) create() ([]byte, error) {
+	name := t.args[0]
+	ref := tagsDir + "/" + name
+
+	target := "HEAD"
+	if len(t.args) == 2 {
+		target = t.args[1]
+	}
+
+	if err := database.CheckBranchName(name); err != nil {
+		t.failed = name
+
+		return nil, err
+	}
+
+	resolver, err := revision.New(t.repository.Database, t.repository.Refs, t.repository.Clock)
+	if err != nil {
+		return nil, fmt.Errorf("init revision resolver: %w", err)
+	}
+
+	oid, err := resolver.Resolve(target)
+	if err != nil {
+		t.failed = target
+
+		return nil, fmt.Errorf("resolve %s: %w", target, err)
+	}
+
+	previous, err := t.repository.Refs.ReadRef(ref)
+	if err != nil {
+		return nil, fmt.Errorf("read tag %s: %w", name, err)
+	}
+
+	if previous != "" && !t.options.Force {
+		t.failed = name
+
+		return nil, fmt.Errorf("%w: %s", database.ErrRefExists, ref)
+	}
+
+	if t.options.Annotate || len(t.options.Messages) > 0 || t.options.File != "" {
+		tag, err := t.annotate(name, oid)
+		if err != nil {
+			return nil, err
+		}
+
+		oid = tag.OID
+	}
+
+	// tag changed since it was read is not overwritten
+	expected := previous
+	if expected == "" {
+		expected = database.NullOID
+	}
+
+	transaction := t.repository.Refs.Transaction()
+	transaction.Update(ref, oid, expected)
+
+	if err := transaction.Commit(nil, ""); err != nil {
+		return nil, fmt.Errorf("write tag %s: %w", name, err)
+	}
+
+	if previous == "" || previous == oid {
+		return nil, nil
+	}
+
+	short, err := t.repository.Database.ShortOID(previous)
+	if err != nil {
+		short = previous
+	}
+
+	return []byte(fmt.Sprintf("Updated tag '%s' (was %s)\n", name, short)), nil
+}
+
+func (t *TagCommand) annotate(name, oid string) (*database.Tag, error) {
+	source := &messageSource{
+		repository:  t.repository,
+		messages:    t.options.Messages,
+		file:        t.options.File,
+		stdin:       t.stdin,
+		editor:      t.editor,
+		editMsgPath: filepath.Join(t.repository.GitPath, "TAG_EDITMSG"),
+	}
+
+	message, edited, err := source.read(func() (string, error) {
+		return fmt.Sprintf(tagTemplate, name), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	message = repository.CleanupMessage(message, repository.CleanupDefault, edited)
+	if edited && message == "" {
+		return nil, repository.ErrEmptyCommitMessage
+	}
+
+	tag, err := t.repository.Tag(name, oid, message, t.options.Tagger)
+	if err != nil {
+		return nil, fmt.Errorf("create tag object: %w", err)
+	}
+
+	return tag, nil
+}
+
+func (t *TagCommand) delete() ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	failed := false
+
+	for _, name := range t.args {
+		ref := tagsDir + "/" + name
+
+		oid, err := t.repository.Refs.ReadRef(ref)
+		if err != nil {
+			return nil, fmt.Errorf("read tag %s: %w", name, err)
+		}
+
+		if oid == "" {
+			fmt.Fprintf(buf, "error: tag '%s' not found.\n", name)
+
+			failed = true
+
+			continue
+		}
+
+		transaction := t.repository.Refs.Transaction()
+		transaction.Delete(ref, oid)
+
+		if err := transaction.Commit(nil, ""); err != nil {
+			return nil, fmt.Errorf("delete tag %s: %w", name, err)
+		}
+
+		short, err := t.repository.Database.ShortOID(oid)
+		if err != nil {
+			short = oid
+		}
+
+		fmt.Fprintf(buf, "Deleted tag '%s' (was %s)\n", name, short)
+	}
+
+	if failed {
+		return buf.Bytes(), errTagNotDeleted
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (t *TagCommand) Output(msg []byte, err error, stdout io.Writer) (int, error) {
+	if err != nil {
+		var lockErr *database.ErrRefLock
+
+		switch {
+		case errors.As(err, &lockErr):
+			fmt.Fprintf(stdout, "fatal: %s\n", lockErr.Error())
+		case errors.Is(err, errTagNotDeleted):
+			fmt.Fprint(stdout, string(msg))
+
+			return 1, nil
+		case errors.Is(err, database.ErrInvalidRefName):
+			fmt.Fprintf(stdout, "fatal: '%s' is not a valid tag name.\n", t.failed)
+		case errors.Is(err, database.ErrRefExists):
+			fmt.Fprintf(stdout, "fatal: tag '%s' already exists\n", t.failed)
+		case errors.Is(err, revision.ErrUnknownRevision) || errors.Is(err, revision.ErrInvalidRevision):
+			fmt.Fprintf(stdout, "fatal: Failed to resolve '%s' as a valid ref.\n", t.failed)
+		case errors.Is(err, repository.ErrEmptyCommitMessage):
+			fmt.Fprint(stdout, "fatal: no tag message?\n")
+		default:
+			return 1, fmt.Errorf("tag cmd: %w", err)
+		}
+
+		return 128, nil
+	}
+
+	fmt.Fprint(stdout, string(msg))
+
+	return 0, nil
+}
@@ -0,0 +1,180 @@
+package command_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/command"
+	"github.com/LukasJenicek/ggit/internal/database"
+)
+
+func TestTag(t *testing.T) {
+	t.Parallel()
+
+	t.Run("lightweight", func(t *testing.T) {
+		t.Parallel()
+
+		repo := committedRepository(t)
+
+		head, err := repo.Refs.ReadHead()
+		require.NoError(t, err)
+
+		exit, out := runCmd(t, repo, "tag", "v1.0")
+		require.Equal(t, 0, exit)
+		require.Empty(t, out)
+
+		oid, err := repo.Refs.ReadRef("refs/tags/v1.0")
+		require.NoError(t, err)
+		require.Equal(t, head, oid)
+
+		exit, out = runCmd(t, repo, "tag", "v1.0")
+		require.Equal(t, 128, exit)
+		require.Equal(t, "fatal: tag 'v1.0' already exists\n", out)
+
+		exit, out = runCmd(t, repo, "tag", "v2.0", "missing")
+		require.Equal(t, 128, exit)
+		require.Equal(t, "fatal: Failed to resolve 'missing' as a valid ref.\n", out)
+
+		exit, out = runCmd(t, repo, "tag", "bad..name")
+		require.Equal(t, 128, exit)
+		require.Equal(t, "fatal: 'bad..name' is not a valid tag name.\n", out)
+	})
+
+	t.Run("annotated", func(t *testing.T) {
+		t.Parallel()
+
+		repo := committedRepository(t)
+
+		head, err := repo.Refs.ReadHead()
+		require.NoError(t, err)
+
+		exit, out := runCmd(t, repo, "tag", "-m", "Release 1.0", "-m", "notes", "v1.0")
+		require.Equal(t, 0, exit)
+		require.Empty(t, out)
+
+		oid, err := repo.Refs.ReadRef("v1.0")
+		require.NoError(t, err)
+
+		tag, err := repo.Database.LoadTag(oid)
+		require.NoError(t, err)
+		require.Equal(t, head, tag.Object)
+		require.Equal(t, database.CommitType, tag.Type)
+		require.Equal(t, "v1.0", tag.Name)
+		require.Equal(t, "Lukas Jenicek <lukas.jenicek5@gmail.com> 976900080 +0000", tag.Tagger.String())
+		require.Equal(t, "Release 1.0\n\nnotes", tag.Message)
+
+		require.Equal(t, head, revParse(t, repo, "v1.0^{commit}"))
+	})
+
+	t.Run("options after tag name", func(t *testing.T) {
+		t.Parallel()
+
+		repo := committedRepository(t)
+
+		head, err := repo.Refs.ReadHead()
+		require.NoError(t, err)
+
+		exit, out := runCmd(t, repo, "tag", "-a", "v1.0", "-m", "Release 1.0")
+		require.Equal(t, 0, exit)
+		require.Empty(t, out)
+
+		exit, out = runCmd(t, repo, "tag", "v1.0", "HEAD", "-fam", "Release 1.0.1")
+		require.Equal(t, 0, exit)
+		require.Contains(t, out, "Updated tag 'v1.0'")
+
+		oid, err := repo.Refs.ReadRef("v1.0")
+		require.NoError(t, err)
+
+		tag, err := repo.Database.LoadTag(oid)
+		require.NoError(t, err)
+		require.Equal(t, head, tag.Object)
+		require.Equal(t, "Release 1.0.1", tag.Message)
+
+		// arguments after -- are never options
+		exit, out = runCmd(t, repo, "tag", "-d", "--", "-m")
+		require.Equal(t, 1, exit)
+		require.Equal(t, "error: tag '-m' not found.\n", out)
+	})
+
+	t.Run("annotated from editor", func(t *testing.T) {
+		t.Parallel()
+
+		repo := committedRepository(t)
+
+		editor := func(path string) error {
+			template, err := repo.FS.ReadFile(path)
+			if err != nil {
+				return err
+			}
+
+			require.Contains(t, string(template), "# Write a message for tag:\n#   v1.0\n")
+
+			return repo.FS.WriteFile(path, append([]byte("edited\n"), template...), 0o644)
+		}
+
+		cmd, err := command.NewTagCommand(repo, []string{"v1.0"}, command.TagOptions{Annotate: true}, nil, editor)
+		require.NoError(t, err)
+
+		_, err = cmd.Run()
+		require.NoError(t, err)
+
+		oid, err := repo.Refs.ReadRef("v1.0")
+		require.NoError(t, err)
+
+		tag, err := repo.Database.LoadTag(oid)
+		require.NoError(t, err)
+		require.Equal(t, "edited", tag.Message)
+	})
+
+	t.Run("force replaces tag", func(t *testing.T) {
+		t.Parallel()
+
+		repo := committedRepository(t)
+
+		head, err := repo.Refs.ReadHead()
+		require.NoError(t, err)
+
+		commit, err := repo.Database.LoadCommit(head)
+		require.NoError(t, err)
+
+		exit, _ := runCmd(t, repo, "tag", "v1.0", commit.RootOID)
+		require.Equal(t, 0, exit)
+
+		exit, out := runCmd(t, repo, "tag", "-f", "v1.0")
+		require.Equal(t, 0, exit)
+		require.Equal(t, "Updated tag 'v1.0' (was "+commit.RootOID[:7]+")\n", out)
+	})
+
+	t.Run("list and delete", func(t *testing.T) {
+		t.Parallel()
+
+		repo := committedRepository(t)
+
+		for _, name := range []string{"v1.0", "v1.1", "v2.0", "release/2024"} {
+			exit, _ := runCmd(t, repo, "tag", name)
+			require.Equal(t, 0, exit)
+		}
+
+		exit, out := runCmd(t, repo, "tag")
+		require.Equal(t, 0, exit)
+		require.Equal(t, "release/2024\nv1.0\nv1.1\nv2.0\n", out)
+
+		exit, out = runCmd(t, repo, "tag", "-l", "v1.*", "release/*")
+		require.Equal(t, 0, exit)
+		require.Equal(t, "release/2024\nv1.0\nv1.1\n", out)
+
+		head, err := repo.Refs.ReadHead()
+		require.NoError(t, err)
+
+		exit, out = runCmd(t, repo, "tag", "-d", "v1.0", "missing", "release/2024")
+		require.Equal(t, 1, exit)
+		require.Equal(t, "Deleted tag 'v1.0' (was "+head[:7]+")\n"+
+			"error: tag 'missing' not found.\n"+
+			"Deleted tag 'release/2024' (was "+head[:7]+")\n", out)
+
+		exit, out = runCmd(t, repo, "tag")
+		require.Equal(t, 0, exit)
+		require.Equal(t, "v1.1\nv2.0\n", out)
+	})
+}
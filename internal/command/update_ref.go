@@ -0,0 +1,272 @@
+package command
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/repository"
+	"github.com/LukasJenicek/ggit/internal/revision"
+)
+
+var (
+	errInvalidValue = errors.New("not a valid object name")
+	errInvalidBatch = errors.New("invalid update-ref input")
+)
+
+type UpdateRefOptions struct {
+	// Message recorded in reflog.
+	Message string
+	// Delete ref instead of updating it.
+	Delete bool
+	// Read batch of updates from stdin, they are applied in single transaction.
+	Stdin     bool
+	Committer repository.Signature
+}
+
+// UpdateRefCommand safely updates refs, new value is written only when the ref holds expected old value.
+type UpdateRefCommand struct {
+	repository *repository.Repository
+	args       []string
+	options    UpdateRefOptions
+	stdin      io.Reader
+	resolver   *revision.Resolver
+
+	failed string
+}
+
+func NewUpdateRefCommand(
+	repo *repository.Repository,
+	args []string,
+	options UpdateRefOptions,
+	stdin io.Reader,
+) (*UpdateRefCommand, error) {
+	if repo == nil {
+		return nil, errors.New("repository is nil")
+	}
+
+	switch {
+	case options.Stdin && len(args) != 0:
+		return nil, errors.New("--stdin does not take arguments")
+	case options.Stdin && stdin == nil:
+		return nil, errors.New("stdin is nil")
+	case options.Delete && (len(args) == 0 || len(args) > 2):
+		return nil, errors.New("delete requires ref and optional old value")
+	case !options.Stdin && !options.Delete && (len(args) < 2 || len(args) > 3):
+		return nil, errors.New("update requires ref, new value and optional old value")
+	}
+
+	resolver, err := revision.New(repo.Database, repo.Refs, repo.Clock)
+	if err != nil {
+		return nil, fmt.Errorf("init revision resolver: %w", err)
+	}
+
+	return &UpdateRefCommand{
+		repository: repo,
+		args:       args,
+		options:    options,
+		stdin:      stdin,
+		resolver:   resolver,
+	}, nil
+}
+
+func (u *UpdateRefCommand) Run() ([]byte, error) {
+	transaction := u.repository.Refs.Transaction()
+
+	switch {
+	case u.options.Stdin:
+		if err := u.readBatch(transaction); err != nil {
+			return nil, err
+		}
+	case u.options.Delete:
+		if err := u.checkRef(u.args[0]); err != nil {
+			return nil, err
+		}
+
+		old, err := u.optionalValue(u.args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		transaction.Delete(u.args[0], old)
+	default:
+		if err := u.checkRef(u.args[0]); err != nil {
+			return nil, err
+		}
+
+		newOID, err := u.value(u.args[1])
+		if err != nil {
+			return nil, err
+		}
+
+		old, err := u.optionalValue(u.args, 2)
+		if err != nil {
+			return nil, err
+		}
+
+		transaction.Update(u.args[0], newOID, old)
+	}
+
+	identity, err := reflogIdentity(u.repository, u.options.Committer)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := transaction.Commit(identity, u.options.Message); err != nil {
+		return nil, fmt.Errorf("update refs: %w", err)
+	}
+
+	return nil, nil
+}
+
+// readBatch reads commands of the --stdin format, one per line:
+//
+//	update <ref> <new> [<old>]
+//	create <ref> <new>
+//	delete <ref> [<old>]
+//	verify <ref> [<old>]
+func (u *UpdateRefCommand) readBatch(transaction *database.RefTransaction) error {
+	scanner := bufio.NewScanner(u.stdin)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Split(line, " ")
+		command, args := fields[0], fields[1:]
+
+		arity, ok := batchArity[command]
+		if !ok {
+			return fmt.Errorf("%w: unknown command: %s", errInvalidBatch, line)
+		}
+
+		if len(args) < arity[0] {
+			return fmt.Errorf("%w: %s: missing arguments", errInvalidBatch, command)
+		}
+
+		if len(args) > arity[1] {
+			return fmt.Errorf("%w: %s %s: extra input: %s", errInvalidBatch, command, args[0], strings.Join(args[arity[1]:], " "))
+		}
+
+		ref := args[0]
+		if err := u.checkRef(ref); err != nil {
+			return err
+		}
+
+		switch command {
+		case "update", "create":
+			newOID, err := u.value(args[1])
+			if err != nil {
+				return err
+			}
+
+			old := database.NullOID
+			if command == "update" {
+				if old, err = u.optionalValue(args, 2); err != nil {
+					return err
+				}
+			}
+
+			transaction.Update(ref, newOID, old)
+		case "delete", "verify":
+			old, err := u.optionalValue(args, 1)
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case command == "delete":
+				transaction.Delete(ref, old)
+			case old == "":
+				// verify without old value requires the ref to not exist
+				transaction.Verify(ref, database.NullOID)
+			default:
+				transaction.Verify(ref, old)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read stdin: %w", err)
+	}
+
+	return nil
+}
+
+// batchArity is minimal and maximal number of arguments of --stdin commands.
+var batchArity = map[string][2]int{
+	"update": {2, 3},
+	"create": {2, 2},
+	"delete": {1, 2},
+	"verify": {1, 2},
+}
+
+func (u *UpdateRefCommand) checkRef(ref string) error {
+	if err := database.CheckWritableRef(ref); err != nil {
+		u.failed = ref
+
+		return err
+	}
+
+	return nil
+}
+
+// value resolves revision given as new or old value, empty value and zero id mean the ref must not exist.
+func (u *UpdateRefCommand) value(rev string) (string, error) {
+	if rev == "" || rev == database.NullOID {
+		return database.NullOID, nil
+	}
+
+	oid, err := u.resolver.Resolve(rev)
+	if err != nil {
+		u.failed = rev
+
+		return "", fmt.Errorf("%w: %s: %w", errInvalidValue, rev, err)
+	}
+
+	return oid, nil
+}
+
+// optionalValue resolves old value at position i, missing one means the old value is not checked.
+func (u *UpdateRefCommand) optionalValue(args []string, i int) (string, error) {
+	if i >= len(args) {
+		return "", nil
+	}
+
+	return u.value(args[i])
+}
+
+func (u *UpdateRefCommand) Output(msg []byte, err error, stdout io.Writer) (int, error) {
+	if err != nil {
+		var (
+			lockErr *database.ErrRefLock
+			dateErr *database.ErrDateFormat
+		)
+
+		switch {
+		case errors.As(err, &lockErr):
+			fmt.Fprintf(stdout, "fatal: %s\n", lockErr.Error())
+		case errors.As(err, &dateErr):
+			fmt.Fprintf(stdout, "fatal: %s\n", dateErr.Error())
+		case errors.Is(err, database.ErrInvalidRefName):
+			fmt.Fprintf(stdout, "fatal: invalid ref format: %s\n", u.failed)
+		case errors.Is(err, errInvalidValue):
+			fmt.Fprintf(stdout, "fatal: %s: not a valid SHA1\n", u.failed)
+		case errors.Is(err, errInvalidBatch):
+			fmt.Fprintf(stdout, "fatal: %s\n", strings.TrimPrefix(err.Error(), errInvalidBatch.Error()+": "))
+		default:
+			return 1, fmt.Errorf("update-ref cmd: %w", err)
+		}
+
+		return 128, nil
+	}
+
+	fmt.Fprint(stdout, string(msg))
+
+	return 0, nil
+}
@@ -0,0 +1,105 @@
+package command_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/command"
+	"github.com/LukasJenicek/ggit/internal/repository"
+)
+
+func TestUpdateRef(t *testing.T) {
+	t.Parallel()
+
+	repo := committedRepository(t)
+
+	head, err := repo.Refs.ReadHead()
+	require.NoError(t, err)
+
+	root, err := repo.Database.LoadCommit(head)
+	require.NoError(t, err)
+
+	second := storeCommit(t, repo, root.RootOID, []string{head}, "second", time.Unix(2000000000, 0))
+
+	exit, out := runUpdateRef(t, repo, nil, "-m", "fast-forward", "refs/heads/master", second, head)
+	require.Equal(t, 0, exit)
+	require.Empty(t, out)
+
+	exit, out = runCmd(t, repo, "reflog")
+	require.Equal(t, 0, exit)
+	require.Equal(t, second[:7]+" HEAD@{0}: fast-forward\n"+head[:7]+" HEAD@{1}: commit (initial): all\n", out)
+
+	exit, out = runUpdateRef(t, repo, nil, "HEAD", "HEAD~1", head)
+	require.Equal(t, 128, exit)
+	require.Equal(t, "fatal: cannot lock ref 'HEAD': is at "+second+" but expected "+head+"\n", out)
+
+	exit, out = runUpdateRef(t, repo, nil, "refs/heads/topic", "missing")
+	require.Equal(t, 128, exit)
+	require.Equal(t, "fatal: missing: not a valid SHA1\n", out)
+
+	stdin := strings.NewReader("create refs/heads/topic " + head + "\n" +
+		"update refs/heads/master " + head + " " + second + "\n")
+
+	exit, out = runUpdateRef(t, repo, stdin, "--stdin")
+	require.Equal(t, 0, exit)
+	require.Empty(t, out)
+
+	require.Equal(t, head, revParse(t, repo, "topic"))
+	require.Equal(t, head, revParse(t, repo, "master"))
+
+	// failed batch leaves all refs untouched
+	stdin = strings.NewReader("update refs/heads/feature " + second + "\n" +
+		"verify HEAD " + head + "\n" +
+		"update refs/tags/v1.0 " + second + "\n" +
+		"create refs/heads/topic " + second + "\n")
+
+	exit, out = runUpdateRef(t, repo, stdin, "--stdin")
+	require.Equal(t, 128, exit)
+	require.Equal(t, "fatal: cannot lock ref 'refs/heads/topic': reference already exists\n", out)
+	require.Equal(t, head, revParse(t, repo, "master"))
+
+	for _, ref := range []string{"refs/heads/feature", "refs/tags/v1.0"} {
+		oid, err := repo.Refs.ReadRef(ref)
+		require.NoError(t, err)
+		require.Empty(t, oid)
+	}
+
+	exit, out = runUpdateRef(t, repo, strings.NewReader("verify refs/heads/feature\n"), "--stdin")
+	require.Equal(t, 0, exit)
+	require.Empty(t, out)
+
+	exit, out = runUpdateRef(t, repo, strings.NewReader("verify refs/heads/topic\n"), "--stdin")
+	require.Equal(t, 128, exit)
+	require.Equal(t, "fatal: cannot lock ref 'refs/heads/topic': reference already exists\n", out)
+
+	exit, out = runUpdateRef(t, repo, strings.NewReader("move refs/heads/topic\n"), "--stdin")
+	require.Equal(t, 128, exit)
+	require.Equal(t, "fatal: unknown command: move refs/heads/topic\n", out)
+
+	exit, _ = runUpdateRef(t, repo, nil, "-d", "refs/heads/topic", head)
+	require.Equal(t, 0, exit)
+
+	exit, out = runUpdateRef(t, repo, nil, "-d", "refs/heads/topic")
+	require.Equal(t, 128, exit)
+	require.Equal(t, "fatal: cannot lock ref 'refs/heads/topic': unable to resolve reference 'refs/heads/topic'\n", out)
+
+	exit, out = runUpdateRef(t, repo, nil, "refs/heads/topic")
+	require.Equal(t, 129, exit)
+	require.Contains(t, out, "usage: ggit update-ref")
+}
+
+func runUpdateRef(t *testing.T, repo *repository.Repository, stdin io.Reader, args ...string) (int, string) {
+	t.Helper()
+
+	buf := bytes.NewBuffer(nil)
+
+	exit, err := command.NewRunner(repo, stdin).RunCmd(t.Context(), "update-ref", args, buf)
+	require.NoError(t, err)
+
+	return exit, buf.String()
+}
@@ -7,10 +7,21 @@ import (
 	"path/filepath"
 	"reflect"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
-	User *User `config:"user"`
+	User   *User  `config:"user"`
+	Core   Core   `config:"core"`
+	Status Status `config:"status"`
+}
+
+type Core struct {
+	Editor string `config:"editor"`
+}
+
+type Status struct {
+	ShowUntrackedFiles string `config:"showUntrackedFiles"`
 }
 
 type User struct {
@@ -80,7 +91,8 @@ func setConfigValues(v reflect.Value, section string, values map[string]map[stri
 			return errors.New("section empty")
 		}
 
-		val, exists := values[section][tag]
+		// keys are case-insensitive, the parser stores them lowercased
+		val, exists := values[section][strings.ToLower(tag)]
 		if !exists {
 			continue
 		}
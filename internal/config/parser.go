@@ -5,6 +5,9 @@ import (
 	"strings"
 )
 
+// ParseIniConfig parses git config file into values keyed by section and key.
+// Section and key names are case-insensitive in git so they are lowercased, subsection names keep their case,
+// e.g. `[Branch "Main"]` is stored as `branch "Main"`. Key without value means boolean true.
 func ParseIniConfig(content []byte) (map[string]map[string]any, error) {
 	cfgStruct := map[string]map[string]any{}
 
@@ -14,7 +17,9 @@ func ParseIniConfig(content []byte) (map[string]map[string]any, error) {
 
 	lines := strings.Split(string(content), "\n")
 	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
+		line = strings.TrimSpace(line)
+
+		if line == "" || line[0] == '#' || line[0] == ';' {
 			continue
 		}
 
@@ -33,32 +38,79 @@ func ParseIniConfig(content []byte) (map[string]map[string]any, error) {
 			continue
 		}
 
-		key, value, err := loadSectionKeyAndValue(line)
-		if err != nil {
-			return nil, fmt.Errorf("parse section key and value: %w", err)
+		if section == "" {
+			return nil, fmt.Errorf("parse section key and value: key outside of section %s", line)
 		}
 
+		key, value := loadSectionKeyAndValue(line)
+
 		cfgStruct[section][key] = value
 	}
 
 	return cfgStruct, nil
 }
 
-func loadSectionKeyAndValue(line string) (string, any, error) {
+func loadSectionKeyAndValue(line string) (string, any) {
 	// load section values
-	eq := strings.Index(line, "=")
-	if eq == -1 {
-		return "", nil, fmt.Errorf("parse section values: invalid line %s", line)
+	key, value, found := strings.Cut(line, "=")
+	if !found {
+		if comment := strings.IndexAny(key, "#;"); comment != -1 {
+			key = key[:comment]
+		}
+
+		return strings.ToLower(strings.TrimSpace(key)), "true"
 	}
 
-	key := strings.TrimSpace(line[:eq])
-	value := strings.TrimSpace(line[eq+1:])
+	return strings.ToLower(strings.TrimSpace(key)), parseValue(value)
+}
+
+// parseValue removes quotes, escapes and inline comment from raw value, e.g. `"a b" ; comment` is `a b`.
+func parseValue(raw string) string {
+	raw = strings.TrimSpace(raw)
+
+	var value strings.Builder
+
+	quoted := false
+	// whitespace outside quotes is kept only between words
+	pending := ""
+
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
 
-	return key, value, nil
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == '\\' && i+1 < len(raw):
+			i++
+
+			value.WriteString(pending)
+			pending = ""
+
+			switch raw[i] {
+			case 'n':
+				value.WriteByte('\n')
+			case 't':
+				value.WriteByte('\t')
+			default:
+				value.WriteByte(raw[i])
+			}
+		case !quoted && (c == '#' || c == ';'):
+			return value.String()
+		case !quoted && (c == ' ' || c == '\t'):
+			pending += string(c)
+		default:
+			value.WriteString(pending)
+			value.WriteByte(c)
+
+			pending = ""
+		}
+	}
+
+	return value.String()
 }
 
 func loadSection(line string) (string, error) {
-	end := strings.Index(line, "]")
+	end := strings.LastIndex(line, "]")
 	if end == -1 {
 		return "", fmt.Errorf(`section end "%s" not found`, line)
 	}
@@ -69,5 +121,10 @@ func loadSection(line string) (string, error) {
 		return "", fmt.Errorf(`section %s is empty`, line)
 	}
 
-	return section, nil
+	name, subsection, found := strings.Cut(section, " ")
+	if !found {
+		return strings.ToLower(section), nil
+	}
+
+	return strings.ToLower(name) + " " + strings.TrimSpace(subsection), nil
 }
@@ -1,10 +1,28 @@
 package database
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
+var ErrInvalidDate = errors.New("invalid date format")
+
+// ErrDateFormat is returned by ParseDate for dates it does not understand, it matches ErrInvalidDate.
+type ErrDateFormat struct {
+	Date string
+}
+
+func (e *ErrDateFormat) Error() string {
+	return ErrInvalidDate.Error() + ": " + e.Date
+}
+
+func (e *ErrDateFormat) Is(target error) bool {
+	return target == ErrInvalidDate
+}
+
 type Author struct {
 	Email string
 	Name  string
@@ -19,6 +37,41 @@ func NewAuthor(email, name string, now *time.Time) *Author {
 	}
 }
 
+// ParseAuthor
+// Parses "Name <email> unix-timestamp timezone" as written by String.
+// The returned time keeps the time zone offset stored in the object.
+func ParseAuthor(value string) (*Author, error) {
+	emailStart := strings.Index(value, "<")
+	emailEnd := strings.LastIndex(value, ">")
+
+	if emailStart == -1 || emailEnd < emailStart {
+		return nil, fmt.Errorf("invalid identity %q", value)
+	}
+
+	fields := strings.Fields(value[emailEnd+1:])
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("invalid identity date %q", value)
+	}
+
+	timestamp, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q: %w", fields[0], err)
+	}
+
+	location, err := parseTimezone(fields[1])
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Unix(timestamp, 0).In(location)
+
+	return NewAuthor(
+		value[emailStart+1:emailEnd],
+		strings.TrimSpace(value[:emailStart]),
+		&now,
+	), nil
+}
+
 // Name <email> unix-timestamp timezone.
 func (a *Author) String() string {
 	return fmt.Sprintf(
@@ -29,3 +82,124 @@ func (a *Author) String() string {
 		a.Now.Format("-0700"),
 	)
 }
+
+// dateLayouts are formats accepted by ParseDate besides the raw "unix-timestamp timezone" one.
+var dateLayouts = []string{
+	// RFC 2822, e.g. Thu, 07 Apr 2005 22:13:13 +0200
+	"Mon, _2 Jan 2006 15:04:05 -0700",
+	"_2 Jan 2006 15:04:05 -0700",
+	// git log default, e.g. Thu Apr 7 22:13:13 2005 +0200
+	"Mon Jan _2 15:04:05 2006 -0700",
+	// ISO 8601
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05-07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+// ParseDate
+// Parses date given by user in --date or GIT_*_DATE the same way git does for the most common formats:
+// "unix-timestamp timezone" (optionally prefixed with @), RFC 2822 and ISO 8601.
+// The time zone given in the date is preserved, dates without time zone are interpreted in local time.
+func ParseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	fields := strings.Fields(strings.TrimPrefix(value, "@"))
+	if len(fields) > 0 && len(fields) <= 2 {
+		if timestamp, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+			location := time.UTC
+
+			if len(fields) == 2 {
+				location, err = parseTimezone(fields[1])
+				if err != nil {
+					return time.Time{}, &ErrDateFormat{Date: value}
+				}
+			}
+
+			return time.Unix(timestamp, 0).In(location), nil
+		}
+	}
+
+	for _, layout := range dateLayouts {
+		if date, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return date, nil
+		}
+	}
+
+	return time.Time{}, &ErrDateFormat{Date: value}
+}
+
+// relativeUnits are units understood by ParseApproxidate in expressions like "2.weeks.ago".
+var relativeUnits = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+	"month":  30 * 24 * time.Hour,
+	"year":   365 * 24 * time.Hour,
+}
+
+// ParseApproxidate
+// Parses dates used by reflog selectors and expiry, e.g. branch@{yesterday} or --expire=2.weeks.ago.
+// Besides formats of ParseDate it understands "now", "yesterday" and "{n}.{unit}.ago" relative to now.
+func ParseApproxidate(value string, now time.Time) (time.Time, error) {
+	fields := strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return r == '.' || r == ' ' || r == '_'
+	})
+
+	switch {
+	case len(fields) == 1 && fields[0] == "now":
+		return now, nil
+	case len(fields) == 1 && fields[0] == "yesterday":
+		return now.Add(-24 * time.Hour), nil
+	case len(fields) == 3 && fields[2] == "ago":
+		n, err := strconv.Atoi(fields[0])
+		if err != nil {
+			break
+		}
+
+		unit, ok := relativeUnits[strings.TrimSuffix(fields[1], "s")]
+		if !ok {
+			break
+		}
+
+		return now.Add(-time.Duration(n) * unit), nil
+	}
+
+	return ParseDate(value)
+}
+
+func parseTimezone(tz string) (*time.Location, error) {
+	// +hh:mm is accepted as well and stored as +hhmm
+	if len(tz) == 6 && tz[3] == ':' {
+		tz = tz[:3] + tz[4:]
+	}
+
+	if len(tz) != 5 || (tz[0] != '+' && tz[0] != '-') {
+		return nil, fmt.Errorf("invalid timezone %q", tz)
+	}
+
+	hours, err := strconv.Atoi(tz[1:3])
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", tz, err)
+	}
+
+	minutes, err := strconv.Atoi(tz[3:5])
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", tz, err)
+	}
+
+	if minutes >= 60 {
+		return nil, errors.New("invalid timezone minutes " + tz)
+	}
+
+	offset := hours*3600 + minutes*60
+	if tz[0] == '-' {
+		offset = -offset
+	}
+
+	return time.FixedZone("", offset), nil
+}
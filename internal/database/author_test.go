@@ -0,0 +1,90 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+)
+
+func TestParseDate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		date     string
+		unix     int64
+		timezone string
+	}{
+		{name: "raw", date: "1112911993 +0200", unix: 1112911993, timezone: "+0200"},
+		{name: "raw with at sign", date: "@1112911993 -0130", unix: 1112911993, timezone: "-0130"},
+		{name: "raw with colon in timezone", date: "1112911993 -05:30", unix: 1112911993, timezone: "-0530"},
+		{name: "raw without timezone", date: "@1112911993", unix: 1112911993, timezone: "+0000"},
+		{name: "rfc 2822", date: "Thu, 07 Apr 2005 22:13:13 +0200", unix: 1112904793, timezone: "+0200"},
+		{name: "git log", date: "Thu Apr 7 22:13:13 2005 -0700", unix: 1112937193, timezone: "-0700"},
+		{name: "iso 8601", date: "2005-04-07T22:13:13+09:00", unix: 1112879593, timezone: "+0900"},
+		{name: "iso 8601 with space", date: "2005-04-07 22:13:13 +0200", unix: 1112904793, timezone: "+0200"},
+		{name: "iso 8601 utc", date: "2005-04-07T22:13:13Z", unix: 1112911993, timezone: "+0000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			date, err := database.ParseDate(tt.date)
+			require.NoError(t, err)
+			require.Equal(t, tt.unix, date.Unix())
+			require.Equal(t, tt.timezone, date.Format("-0700"))
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := database.ParseDate("yesterday at noon")
+		require.ErrorIs(t, err, database.ErrInvalidDate)
+
+		_, err = database.ParseDate("1112911993 +2x00")
+		require.ErrorIs(t, err, database.ErrInvalidDate)
+
+		var dateErr *database.ErrDateFormat
+		require.ErrorAs(t, err, &dateErr)
+		require.Equal(t, "1112911993 +2x00", dateErr.Date)
+
+		_, err = database.ParseDate("1112911993 +05:3")
+		require.ErrorIs(t, err, database.ErrInvalidDate)
+	})
+}
+
+func TestParseApproxidate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1700000000, 0).UTC()
+
+	tests := []struct {
+		date     string
+		expected time.Time
+	}{
+		{date: "now", expected: now},
+		{date: "yesterday", expected: now.Add(-24 * time.Hour)},
+		{date: "2.weeks.ago", expected: now.Add(-14 * 24 * time.Hour)},
+		{date: "1 hour ago", expected: now.Add(-time.Hour)},
+		{date: "30.minutes.ago", expected: now.Add(-30 * time.Minute)},
+		{date: "@1112911993 +0200", expected: time.Unix(1112911993, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			t.Parallel()
+
+			date, err := database.ParseApproxidate(tt.date, now)
+			require.NoError(t, err)
+			require.True(t, tt.expected.Equal(date), "expected %s, got %s", tt.expected, date)
+		})
+	}
+
+	_, err := database.ParseApproxidate("2.fortnights.ago", now)
+	require.ErrorIs(t, err, database.ErrInvalidDate)
+}
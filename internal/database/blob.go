@@ -12,6 +12,11 @@ func NewBlob(content []byte) *Blob {
 	return &Blob{content: content}
 }
 
+// Data returns the blob payload without the object header.
+func (b *Blob) Data() []byte {
+	return b.content
+}
+
 func (b *Blob) Content() ([]byte, error) {
-	return []byte(fmt.Sprintf("%s %d\x00%s", "blob", len(b.content), b.content)), nil
+	return []byte(fmt.Sprintf("%s %d\x00%s", BlobType, len(b.content), b.content)), nil
 }
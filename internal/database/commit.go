@@ -1,20 +1,22 @@
 package database
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"strings"
 )
 
 type Commit struct {
-	RootOID string
-	OID     string
-	Author  *Author
-	Message string
-	Parent  string
+	RootOID   string
+	OID       string
+	Author    *Author
+	Committer *Author
+	Message   string
+	Parents   []string
 }
 
-func NewCommit(parent string, rootOID string, author *Author, message string) (*Commit, error) {
+func NewCommit(parents []string, rootOID string, author, committer *Author, message string) (*Commit, error) {
 	if strings.TrimSpace(rootOID) == "" {
 		return nil, errors.New("root oid must not be empty")
 	}
@@ -23,14 +25,28 @@ func NewCommit(parent string, rootOID string, author *Author, message string) (*
 		return nil, errors.New("commit message cannot be empty")
 	}
 
+	if author == nil || committer == nil {
+		return nil, errors.New("author and committer must not be nil")
+	}
+
 	return &Commit{
-		RootOID: rootOID,
-		Author:  author,
-		Message: message,
-		Parent:  parent,
+		RootOID:   rootOID,
+		Author:    author,
+		Committer: committer,
+		Message:   message,
+		Parents:   parents,
 	}, nil
 }
 
+// Parent returns the first parent of the commit or empty string for root commit.
+func (c *Commit) Parent() string {
+	if len(c.Parents) == 0 {
+		return ""
+	}
+
+	return c.Parents[0]
+}
+
 func (c *Commit) SetOID(oid string) error {
 	if len(oid) != 40 {
 		return fmt.Errorf("oid must be 40 characters long: %s", oid)
@@ -43,15 +59,65 @@ func (c *Commit) SetOID(oid string) error {
 
 func (c *Commit) Content() ([]byte, error) {
 	lines := []string{"tree " + c.RootOID}
-	if c.Parent != "" {
-		lines = append(lines, "parent "+c.Parent)
+	for _, parent := range c.Parents {
+		lines = append(lines, "parent "+parent)
 	}
 
-	lines = append(lines, "author "+c.Author.String(), "committer "+c.Author.String())
+	lines = append(lines, "author "+c.Author.String(), "committer "+c.Committer.String())
 	lines = append(lines, "", c.Message)
 
 	content := strings.Join(lines, "\n")
 	content += "\n"
 
-	return []byte(fmt.Sprintf("%s %d\x00%s", "commit", len(content), content)), nil
+	return []byte(fmt.Sprintf("%s %d\x00%s", CommitType, len(content), content)), nil
+}
+
+// parseCommit
+// Headers are separated from the message by the first empty line.
+// Unknown headers (gpgsig, encoding, ...) and their continuation lines are skipped.
+func parseCommit(oid string, data []byte) (*Commit, error) {
+	headers, message, found := bytes.Cut(data, []byte("\n\n"))
+	if !found {
+		return nil, errors.New("commit message separator not found")
+	}
+
+	c := &Commit{
+		OID:     oid,
+		Message: strings.TrimSuffix(string(message), "\n"),
+	}
+
+	for _, line := range strings.Split(string(headers), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+
+		switch key {
+		case "tree":
+			c.RootOID = value
+		case "parent":
+			c.Parents = append(c.Parents, value)
+		case "author":
+			author, err := ParseAuthor(value)
+			if err != nil {
+				return nil, fmt.Errorf("parse author: %w", err)
+			}
+
+			c.Author = author
+		case "committer":
+			committer, err := ParseAuthor(value)
+			if err != nil {
+				return nil, fmt.Errorf("parse committer: %w", err)
+			}
+
+			c.Committer = committer
+		}
+	}
+
+	if c.RootOID == "" {
+		return nil, errors.New("commit has no tree")
+	}
+
+	if c.Author == nil || c.Committer == nil {
+		return nil, errors.New("commit has no author or committer")
+	}
+
+	return c, nil
 }
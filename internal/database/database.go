@@ -6,14 +6,29 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/LukasJenicek/ggit/internal/ds"
 	"github.com/LukasJenicek/ggit/internal/filesystem"
 	"github.com/LukasJenicek/ggit/internal/hasher"
 )
 
+const (
+	BlobType   = "blob"
+	TreeType   = "tree"
+	CommitType = "commit"
+	TagType    = "tag"
+)
+
+var (
+	ErrObjectNotFound  = errors.New("object not found")
+	ErrInvalidObjectID = errors.New("invalid object id")
+)
+
 type Database struct {
 	fs filesystem.Fs
 
@@ -26,6 +41,25 @@ type Object interface {
 	Content() ([]byte, error)
 }
 
+// RawObject is an inflated loose object split into its header and payload.
+type RawObject struct {
+	Type string
+	Size int
+	Data []byte
+}
+
+func NewRawObject(objectType string, data []byte) *RawObject {
+	return &RawObject{
+		Type: objectType,
+		Size: len(data),
+		Data: data,
+	}
+}
+
+func (r *RawObject) Content() ([]byte, error) {
+	return []byte(fmt.Sprintf("%s %d\x00%s", r.Type, len(r.Data), r.Data)), nil
+}
+
 func New(fs filesystem.Fs, rootDir string) (*Database, error) {
 	if fs == nil {
 		return nil, errors.New("fs must not be nil")
@@ -79,13 +113,9 @@ func (d *Database) Store(o Object) ([]byte, error) {
 		return nil, fmt.Errorf("get object content: %w", err)
 	}
 
-	oid, err := hasher.SHA1HashContent(c)
+	oid, err := hashContent(c)
 	if err != nil {
-		return nil, fmt.Errorf("generate oid: %w", err)
-	}
-
-	if len(oid) != 20 {
-		return nil, fmt.Errorf("invalid object id: %s, sha1 hash must have 20 bytes", oid)
+		return nil, err
 	}
 
 	err = d.writeObject(hex.EncodeToString(oid), c)
@@ -96,6 +126,232 @@ func (d *Database) Store(o Object) ([]byte, error) {
 	return oid, nil
 }
 
+// Load reads the object identified by hex oid and parses it into *Blob, *Tree, *Commit or *Tag.
+func (d *Database) Load(oid string) (Object, error) {
+	raw, err := d.ReadObject(oid)
+	if err != nil {
+		return nil, err
+	}
+
+	switch raw.Type {
+	case BlobType:
+		return NewBlob(raw.Data), nil
+	case TreeType:
+		tree, err := parseTree(oid, raw.Data)
+		if err != nil {
+			return nil, fmt.Errorf("parse tree %s: %w", oid, err)
+		}
+
+		return tree, nil
+	case CommitType:
+		commit, err := parseCommit(oid, raw.Data)
+		if err != nil {
+			return nil, fmt.Errorf("parse commit %s: %w", oid, err)
+		}
+
+		return commit, nil
+	case TagType:
+		tag, err := parseTag(oid, raw.Data)
+		if err != nil {
+			return nil, fmt.Errorf("parse tag %s: %w", oid, err)
+		}
+
+		return tag, nil
+	}
+
+	return nil, fmt.Errorf("object %s has unsupported type %q", oid, raw.Type)
+}
+
+// LoadCommit loads the object identified by oid and checks it is a commit.
+func (d *Database) LoadCommit(oid string) (*Commit, error) {
+	obj, err := d.Load(oid)
+	if err != nil {
+		return nil, err
+	}
+
+	commit, ok := obj.(*Commit)
+	if !ok {
+		return nil, fmt.Errorf("object %s is not a commit", oid)
+	}
+
+	return commit, nil
+}
+
+// LoadTag loads the object identified by oid and checks it is an annotated tag.
+func (d *Database) LoadTag(oid string) (*Tag, error) {
+	obj, err := d.Load(oid)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, ok := obj.(*Tag)
+	if !ok {
+		return nil, fmt.Errorf("object %s is not a tag", oid)
+	}
+
+	return tag, nil
+}
+
+// PeelTag follows annotated tags until it reaches other object type and returns its id.
+// Ids of other objects are returned unchanged.
+func (d *Database) PeelTag(oid string) (string, error) {
+	for {
+		raw, err := d.ReadObject(oid)
+		if err != nil {
+			return oid, err
+		}
+
+		if raw.Type != TagType {
+			return oid, nil
+		}
+
+		tag, err := parseTag(oid, raw.Data)
+		if err != nil {
+			return "", fmt.Errorf("parse tag %s: %w", oid, err)
+		}
+
+		oid = tag.Object
+	}
+}
+
+// ReadObject inflates .git/objects/xx/yyyy and validates the "type size\x00" header.
+func (d *Database) ReadObject(oid string) (*RawObject, error) {
+	if err := validateOID(oid); err != nil {
+		return nil, err
+	}
+
+	compressed, err := d.fs.ReadFile(d.objectPath(oid))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, oid)
+		}
+
+		return nil, fmt.Errorf("read object %s: %w", oid, err)
+	}
+
+	zlibReader, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("create zlib reader %s: %w", oid, err)
+	}
+	defer zlibReader.Close()
+
+	content, err := io.ReadAll(zlibReader)
+	if err != nil {
+		return nil, fmt.Errorf("inflate object %s: %w", oid, err)
+	}
+
+	return parseRawObject(oid, content)
+}
+
+// Exists reports whether the loose object with given hex oid is stored in the database.
+func (d *Database) Exists(oid string) bool {
+	if validateOID(oid) != nil {
+		return false
+	}
+
+	_, err := d.fs.Stat(d.objectPath(oid))
+
+	return err == nil
+}
+
+func parseRawObject(oid string, content []byte) (*RawObject, error) {
+	nul := bytes.IndexByte(content, 0)
+	if nul == -1 {
+		return nil, fmt.Errorf("object %s: header not terminated", oid)
+	}
+
+	objectType, sizeField, ok := bytes.Cut(content[:nul], []byte(" "))
+	if !ok {
+		return nil, fmt.Errorf("object %s: malformed header %q", oid, content[:nul])
+	}
+
+	size, err := strconv.Atoi(string(sizeField))
+	if err != nil || size < 0 {
+		return nil, fmt.Errorf("object %s: invalid size %q", oid, sizeField)
+	}
+
+	data := content[nul+1:]
+	if len(data) != size {
+		return nil, fmt.Errorf("object %s: size mismatch, header says %d got %d", oid, size, len(data))
+	}
+
+	return &RawObject{
+		Type: string(objectType),
+		Size: size,
+		Data: data,
+	}, nil
+}
+
+func validateOID(oid string) error {
+	if len(oid) != 40 {
+		return fmt.Errorf("%w %q: expected 40 hex characters", ErrInvalidObjectID, oid)
+	}
+
+	if _, err := hex.DecodeString(oid); err != nil {
+		return fmt.Errorf("%w %q: %w", ErrInvalidObjectID, oid, err)
+	}
+
+	return nil
+}
+
+func (d *Database) objectPath(oid string) string {
+	return fmt.Sprintf("%s/%s/%s", d.objectsPath, oid[:2], oid[2:])
+}
+
+// Hash computes the object id without writing the object into the database.
+func Hash(o Object) ([]byte, error) {
+	c, err := o.Content()
+	if err != nil {
+		return nil, fmt.Errorf("get object content: %w", err)
+	}
+
+	return hashContent(c)
+}
+
+// Validate checks that data can be parsed as an object of given type.
+func Validate(objectType string, data []byte) error {
+	switch objectType {
+	case BlobType:
+		return nil
+	case TreeType:
+		_, err := parseTree(strings.Repeat("0", 40), data)
+		if err != nil {
+			return fmt.Errorf("invalid tree: %w", err)
+		}
+
+		return nil
+	case CommitType:
+		_, err := parseCommit("", data)
+		if err != nil {
+			return fmt.Errorf("invalid commit: %w", err)
+		}
+
+		return nil
+	case TagType:
+		_, err := parseTag("", data)
+		if err != nil {
+			return fmt.Errorf("invalid tag: %w", err)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unknown object type %q", objectType)
+}
+
+func hashContent(content []byte) ([]byte, error) {
+	oid, err := hasher.SHA1HashContent(content)
+	if err != nil {
+		return nil, fmt.Errorf("generate oid: %w", err)
+	}
+
+	if len(oid) != 20 {
+		return nil, fmt.Errorf("invalid object id: %s, sha1 hash must have 20 bytes", oid)
+	}
+
+	return oid, nil
+}
+
 func (d *Database) SaveBlobs(filePaths ds.Set[string]) ([]*Entry, error) {
 	entries := make([]*Entry, 0, len(filePaths))
 
@@ -140,7 +396,7 @@ func (d *Database) saveBlob(filePath string) (*Entry, error) {
 }
 
 func (d *Database) writeObject(oid string, content []byte) error {
-	realPath := fmt.Sprintf("%s/%s/%s", d.objectsPath, oid[:2], oid[2:])
+	realPath := d.objectPath(oid)
 	dir := filepath.Dir(realPath)
 
 	// object already exist do not overwrite
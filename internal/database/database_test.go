@@ -1,13 +1,16 @@
 package database_test
 
 import (
+	"encoding/hex"
 	"testing"
 	"testing/fstest"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/LukasJenicek/ggit/internal/database"
 	"github.com/LukasJenicek/ggit/internal/filesystem/memory"
+	"github.com/LukasJenicek/ggit/internal/hasher"
 )
 
 func TestDatabase_StoreRootTree(t *testing.T) {
@@ -48,3 +51,205 @@ func TestDatabase_StoreRootTree(t *testing.T) {
 	require.NoError(t, err)
 	require.NotEmpty(t, oid, "object ID should not be empty")
 }
+
+func TestDatabase_Load(t *testing.T) {
+	t.Parallel()
+
+	d, err := database.New(memory.New(fstest.MapFS{}), "tmp")
+	require.NoError(t, err)
+
+	blobID, err := d.Store(database.NewBlob([]byte("hello")))
+	require.NoError(t, err)
+
+	root := database.NewRootTree()
+
+	entry, err := database.NewEntry("hello.txt", "tmp/hello.txt", blobID, false)
+	require.NoError(t, err)
+	root.AddEntry(entry)
+
+	entry, err = database.NewEntry("run.sh", "tmp/run.sh", blobID, true)
+	require.NoError(t, err)
+
+	libsTree := database.NewTree(root, "libs")
+	libsTree.AddEntry(entry)
+	root.AddEntry(libsTree)
+
+	treeID, err := d.StoreTree(root)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 12, 15, 17, 8, 0, 0, time.FixedZone("", 3600))
+	author := database.NewAuthor("lukas.jenicek5@gmail.com", "Lukas Jenicek", &now)
+
+	commit, err := database.NewCommit(nil, hex.EncodeToString(treeID), author, author, "initial commit")
+	require.NoError(t, err)
+
+	commitID, err := d.Store(commit)
+	require.NoError(t, err)
+
+	t.Run("blob", func(t *testing.T) {
+		t.Parallel()
+
+		obj, err := d.Load(hex.EncodeToString(blobID))
+		require.NoError(t, err)
+
+		blob, ok := obj.(*database.Blob)
+		require.True(t, ok)
+		require.Equal(t, []byte("hello"), blob.Data())
+	})
+
+	t.Run("tree", func(t *testing.T) {
+		t.Parallel()
+
+		obj, err := d.Load(hex.EncodeToString(treeID))
+		require.NoError(t, err)
+
+		tree, ok := obj.(*database.Tree)
+		require.True(t, ok)
+		require.Len(t, tree.Entries(), 2)
+
+		file, ok := tree.Entries()[0].(*database.Entry)
+		require.True(t, ok)
+		require.Equal(t, "hello.txt", file.Name)
+		require.Equal(t, "100644", file.Mode())
+		require.Equal(t, blobID, file.OID)
+
+		subtree, ok := tree.Entries()[1].(*database.Tree)
+		require.True(t, ok)
+		require.Equal(t, "libs", subtree.Name())
+
+		obj, err = d.Load(hex.EncodeToString(subtree.OID()))
+		require.NoError(t, err)
+
+		libs, ok := obj.(*database.Tree)
+		require.True(t, ok)
+
+		script, ok := libs.Entries()[0].(*database.Entry)
+		require.True(t, ok)
+		require.Equal(t, "100755", script.Mode())
+		require.True(t, script.Executable)
+
+		// loaded tree must serialize back into identical object
+		content, err := tree.Content()
+		require.NoError(t, err)
+
+		oid, err := hasher.SHA1HashContent(content)
+		require.NoError(t, err)
+		require.Equal(t, treeID, oid)
+	})
+
+	t.Run("commit", func(t *testing.T) {
+		t.Parallel()
+
+		obj, err := d.Load(hex.EncodeToString(commitID))
+		require.NoError(t, err)
+
+		loaded, ok := obj.(*database.Commit)
+		require.True(t, ok)
+		require.Equal(t, hex.EncodeToString(commitID), loaded.OID)
+		require.Equal(t, hex.EncodeToString(treeID), loaded.RootOID)
+		require.Empty(t, loaded.Parents)
+		require.Equal(t, "initial commit", loaded.Message)
+		require.Equal(t, author.String(), loaded.Author.String())
+		require.Equal(t, author.String(), loaded.Committer.String())
+	})
+
+	t.Run("missing object", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := d.Load("0000000000000000000000000000000000000000")
+		require.ErrorIs(t, err, database.ErrObjectNotFound)
+	})
+
+	t.Run("invalid oid", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := d.Load("abc")
+		require.Error(t, err)
+	})
+}
+
+func TestDatabase_ResolvePrefix(t *testing.T) {
+	t.Parallel()
+
+	fs := fstest.MapFS{
+		"tmp/.git/objects/ab/cdef0000000000000000000000000000000001": &fstest.MapFile{},
+		"tmp/.git/objects/ab/cdef0000000000000000000000000000000002": &fstest.MapFile{},
+		"tmp/.git/objects/ab/cd12340000000000000000000000000000000f": &fstest.MapFile{},
+		"tmp/.git/objects/12/34567890000000000000000000000000000000": &fstest.MapFile{},
+	}
+
+	d, err := database.New(memory.New(fs), "tmp")
+	require.NoError(t, err)
+
+	oid, err := d.ResolvePrefix("ABCD12")
+	require.NoError(t, err)
+	require.Equal(t, "abcd12340000000000000000000000000000000f", oid)
+
+	_, err = d.ResolvePrefix("abcdef")
+
+	var ambiguous *database.ErrAmbiguousObjectID
+	require.ErrorAs(t, err, &ambiguous)
+	require.Equal(t, []string{
+		"abcdef0000000000000000000000000000000001",
+		"abcdef0000000000000000000000000000000002",
+	}, ambiguous.Candidates)
+
+	_, err = d.ResolvePrefix("abc")
+	require.ErrorIs(t, err, database.ErrInvalidObjectID)
+
+	_, err = d.ResolvePrefix("ffff")
+	require.ErrorIs(t, err, database.ErrObjectNotFound)
+
+	short, err := d.ShortOID("1234567890000000000000000000000000000000")
+	require.NoError(t, err)
+	require.Equal(t, "1234567", short)
+
+	short, err = d.ShortOID("abcdef0000000000000000000000000000000001")
+	require.NoError(t, err)
+	require.Equal(t, "abcdef0000000000000000000000000000000001", short)
+
+	short, err = d.ShortOID("abcd12340000000000000000000000000000000f")
+	require.NoError(t, err)
+	require.Equal(t, "abcd123", short)
+}
+
+func TestDatabase_LoadTag(t *testing.T) {
+	t.Parallel()
+
+	d, err := database.New(memory.New(fstest.MapFS{}), "tmp")
+	require.NoError(t, err)
+
+	blobID, err := d.Store(database.NewBlob([]byte("hello")))
+	require.NoError(t, err)
+
+	now := time.Date(2024, 12, 15, 17, 8, 0, 0, time.FixedZone("", -5*3600))
+	tagger := database.NewAuthor("lukas.jenicek5@gmail.com", "Lukas Jenicek", &now)
+
+	tag, err := database.NewTag(hex.EncodeToString(blobID), database.BlobType, "v1.0", tagger, "release\n\nnotes")
+	require.NoError(t, err)
+
+	content, err := tag.Content()
+	require.NoError(t, err)
+	require.Equal(t, "tag 148\x00"+
+		"object b6fc4c620b67d95f953a5c1c1230aaab5db5a1b0\n"+
+		"type blob\n"+
+		"tag v1.0\n"+
+		"tagger Lukas Jenicek <lukas.jenicek5@gmail.com> 1734300480 -0500\n"+
+		"\n"+
+		"release\n\nnotes\n", string(content))
+
+	tagID, err := d.Store(tag)
+	require.NoError(t, err)
+
+	loaded, err := d.LoadTag(hex.EncodeToString(tagID))
+	require.NoError(t, err)
+	require.Equal(t, hex.EncodeToString(tagID), loaded.OID)
+	require.Equal(t, hex.EncodeToString(blobID), loaded.Object)
+	require.Equal(t, database.BlobType, loaded.Type)
+	require.Equal(t, "v1.0", loaded.Name)
+	require.Equal(t, tagger.String(), loaded.Tagger.String())
+	require.Equal(t, "release\n\nnotes", loaded.Message)
+
+	_, err = d.LoadTag(hex.EncodeToString(blobID))
+	require.Error(t, err)
+}
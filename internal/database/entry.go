@@ -14,6 +14,9 @@ type Entry struct {
 	// Calculated object id
 	OID        []byte
 	Executable bool
+
+	// mode as read from a stored tree, empty for entries created from the workspace
+	mode string
 }
 
 func NewEntry(filename string, absFilePath string, oid []byte, executable bool) (*Entry, error) {
@@ -37,13 +40,30 @@ func (e *Entry) GetRelativeFilePath(rootDir string) string {
 	return strings.Replace(e.AbsFilePath, rootDir+"/", "", 1)
 }
 
-func (e *Entry) Content() ([]byte, error) {
-	mode := regularMode
+// Mode returns the octal file mode written to the tree object.
+func (e *Entry) Mode() string {
+	if e.mode != "" {
+		return e.mode
+	}
+
 	if e.Executable {
-		mode = executableMode
+		return executableMode
 	}
 
+	return regularMode
+}
+
+// Type returns the type of the object the entry points to.
+func (e *Entry) Type() string {
+	if e.Mode() == gitlinkMode {
+		return CommitType
+	}
+
+	return BlobType
+}
+
+func (e *Entry) Content() ([]byte, error) {
 	path := strings.Split(e.Name, "/")
 
-	return []byte(fmt.Sprintf("%s %s\x00%s", mode, path[len(path)-1], e.OID)), nil
+	return []byte(fmt.Sprintf("%s %s\x00%s", e.Mode(), path[len(path)-1], e.OID)), nil
 }
@@ -0,0 +1,321 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/LukasJenicek/ggit/internal/filesystem"
+)
+
+const (
+	packedRefsFile   = "packed-refs"
+	packedRefsHeader = "# pack-refs with: peeled fully-peeled sorted \n"
+)
+
+// readPackedRefs parses .git/packed-refs, missing file means there are no packed refs.
+// Lines starting with ^ hold the peeled object id of the annotated tag on the previous line.
+func (r *Refs) readPackedRefs() ([]*Ref, error) {
+	content, err := r.fs.ReadFile(filepath.Join(r.gitDir, packedRefsFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("read packed refs: %w", err)
+	}
+
+	var refs []*Ref
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "^"):
+			if len(refs) == 0 {
+				return nil, errors.New("packed refs: peeled line without ref")
+			}
+
+			refs[len(refs)-1].Peeled = line[1:]
+		default:
+			oid, name, found := strings.Cut(line, " ")
+			if !found || len(oid) != 40 {
+				return nil, fmt.Errorf("packed refs: invalid line %q", line)
+			}
+
+			refs = append(refs, &Ref{Name: name, OID: oid})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scan packed refs: %w", err)
+	}
+
+	return refs, nil
+}
+
+func (r *Refs) packedRef(name string) (*Ref, error) {
+	refs, err := r.readPackedRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ref := range refs {
+		if ref.Name == name {
+			return ref, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// lockPackedRefs takes packed-refs.lock, it is held for the whole read, change and write of packed-refs
+// so concurrent writers do not overwrite each other's changes.
+func (r *Refs) lockPackedRefs() (*filesystem.LockFile, error) {
+	lock, err := r.fileWriter.Lock(filepath.Join(r.gitDir, packedRefsFile))
+	if err != nil {
+		return nil, fmt.Errorf("lock packed refs: %w", err)
+	}
+
+	return lock, nil
+}
+
+// writePackedRefs replaces packed-refs, the caller must hold the lock from lockPackedRefs.
+func (r *Refs) writePackedRefs(refs []*Ref) error {
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Name < refs[j].Name
+	})
+
+	buf := bytes.NewBufferString(packedRefsHeader)
+
+	for _, ref := range refs {
+		fmt.Fprintf(buf, "%s %s\n", ref.OID, ref.Name)
+
+		if ref.Peeled != "" {
+			fmt.Fprintf(buf, "^%s\n", ref.Peeled)
+		}
+	}
+
+	if err := r.fileWriter.WriteLocked(filepath.Join(r.gitDir, packedRefsFile), buf.Bytes()); err != nil {
+		return fmt.Errorf("write packed refs: %w", err)
+	}
+
+	return nil
+}
+
+// deletePackedRef rewrites packed-refs without the ref, it reports whether the ref was packed.
+func (r *Refs) deletePackedRef(name string) (bool, error) {
+	lock, err := r.lockPackedRefs()
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		_ = r.fileWriter.Unlock(lock)
+	}()
+
+	refs, err := r.readPackedRefs()
+	if err != nil {
+		return false, err
+	}
+
+	kept := make([]*Ref, 0, len(refs))
+
+	for _, ref := range refs {
+		if ref.Name != name {
+			kept = append(kept, ref)
+		}
+	}
+
+	if len(kept) == len(refs) {
+		return false, nil
+	}
+
+	return true, r.writePackedRefs(kept)
+}
+
+// looseRef is loose ref locked by PackRefs until it is packed and removed.
+type looseRef struct {
+	name string
+	oid  string
+	lock *filesystem.LockFile
+}
+
+// PackRefs moves loose refs into packed-refs, without all only tags are packed as git does by default.
+// Symbolic refs stay loose. Annotated tags are stored together with the commit they peel to.
+// Loose refs stay locked until they are removed, so updates made meanwhile are not lost. Refs which are
+// locked by someone else or do not hold a valid object id are left loose.
+func (r *Refs) PackRefs(db *Database, all bool) error {
+	packedLock, err := r.lockPackedRefs()
+	if err != nil {
+		return err
+	}
+
+	var moved []*looseRef
+
+	defer func() {
+		for _, ref := range moved {
+			_ = r.fileWriter.Unlock(ref.lock)
+		}
+
+		_ = r.fileWriter.Unlock(packedLock)
+
+		// directories of removed refs can be removed only once their lock files are gone
+		for _, ref := range moved {
+			r.removeEmptyDirs(filepath.Dir(filepath.Join(r.gitDir, ref.name)))
+		}
+	}()
+
+	packed, err := r.readPackedRefs()
+	if err != nil {
+		return err
+	}
+
+	byName := make(map[string]*Ref, len(packed))
+	for _, ref := range packed {
+		byName[ref.Name] = ref
+	}
+
+	loose, err := r.looseRefs("refs")
+	if err != nil {
+		return err
+	}
+
+	for _, name := range loose {
+		if !all && !strings.HasPrefix(name, "refs/tags/") {
+			continue
+		}
+
+		ref, err := r.lockLooseRef(name)
+		if err != nil {
+			return err
+		}
+
+		if ref == nil {
+			continue
+		}
+
+		byName[name] = &Ref{Name: name, OID: ref.oid}
+		moved = append(moved, ref)
+	}
+
+	refs := make([]*Ref, 0, len(byName))
+
+	for _, ref := range byName {
+		peeled, err := db.PeelTag(ref.OID)
+		if err != nil && !errors.Is(err, ErrObjectNotFound) {
+			return fmt.Errorf("peel %s: %w", ref.Name, err)
+		}
+
+		ref.Peeled = ""
+		if peeled != ref.OID {
+			ref.Peeled = peeled
+		}
+
+		refs = append(refs, ref)
+	}
+
+	if err := r.writePackedRefs(refs); err != nil {
+		return err
+	}
+
+	for _, ref := range moved {
+		path := filepath.Join(r.gitDir, ref.name)
+
+		// the lock is held, still the ref is removed only when it holds the packed value
+		content, err := r.fs.ReadFile(path)
+		if err != nil || strings.TrimSpace(string(content)) != ref.oid {
+			continue
+		}
+
+		if err := r.fileWriter.RemoveLocked(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove loose ref %s: %w", ref.name, err)
+		}
+	}
+
+	return nil
+}
+
+// lockLooseRef locks loose ref and reads its value. It returns nil for refs which are not packed:
+// symbolic refs, refs with invalid object id and refs locked by another writer.
+func (r *Refs) lockLooseRef(name string) (*looseRef, error) {
+	path := filepath.Join(r.gitDir, name)
+
+	lock, err := r.fileWriter.Lock(path)
+	if err != nil {
+		if errors.Is(err, filesystem.ErrLockAcquired) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("lock ref %s: %w", name, err)
+	}
+
+	content, err := r.fs.ReadFile(path)
+	if err != nil {
+		_ = r.fileWriter.Unlock(lock)
+
+		if missing(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("read ref %s: %w", name, err)
+	}
+
+	oid := strings.TrimSpace(string(content))
+	if validateOID(oid) != nil {
+		_ = r.fileWriter.Unlock(lock)
+
+		return nil, nil
+	}
+
+	return &looseRef{name: name, oid: oid, lock: lock}, nil
+}
+
+// looseRefs returns names of ref files stored under prefix.
+func (r *Refs) looseRefs(prefix string) ([]string, error) {
+	dir := filepath.Join(r.gitDir, prefix)
+
+	if _, err := r.fs.Stat(dir); err != nil {
+		if missing(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("stat %s: %w", dir, err)
+	}
+
+	var names []string
+
+	err := r.fs.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// skip directories and files of in-flight writes
+		if d.IsDir() || strings.HasSuffix(p, ".lock") || strings.HasSuffix(p, ".tmp") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(r.gitDir, p)
+		if err != nil {
+			return fmt.Errorf("relative ref path: %w", err)
+		}
+
+		names = append(names, filepath.ToSlash(rel))
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", dir, err)
+	}
+
+	return names, nil
+}
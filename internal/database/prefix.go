@@ -0,0 +1,138 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	// MinPrefixLength is the shortest abbreviation accepted as object name.
+	MinPrefixLength = 4
+	// DefaultAbbrevLength is the length used for printed object ids unless it is ambiguous.
+	DefaultAbbrevLength = 7
+)
+
+type ErrAmbiguousObjectID struct {
+	Prefix     string
+	Candidates []string
+}
+
+func (e *ErrAmbiguousObjectID) Error() string {
+	return fmt.Sprintf("short object ID %s is ambiguous, candidates: %s", e.Prefix, strings.Join(e.Candidates, ", "))
+}
+
+// ResolvePrefix
+// Expands unique hex prefix (at least MinPrefixLength characters) into full object id.
+// Objects are looked up in the .git/objects/xx fan-out directory selected by first two characters.
+func (d *Database) ResolvePrefix(prefix string) (string, error) {
+	prefix = strings.ToLower(prefix)
+
+	if len(prefix) < MinPrefixLength || len(prefix) > 40 {
+		return "", fmt.Errorf("%w %q: prefix must have %d-40 characters", ErrInvalidObjectID, prefix, MinPrefixLength)
+	}
+
+	if !isHex(prefix) {
+		return "", fmt.Errorf("%w %q: not a hex string", ErrInvalidObjectID, prefix)
+	}
+
+	if len(prefix) == 40 {
+		if !d.Exists(prefix) {
+			return "", fmt.Errorf("%w: %s", ErrObjectNotFound, prefix)
+		}
+
+		return prefix, nil
+	}
+
+	oids, err := d.listFanOut(prefix[:2])
+	if err != nil {
+		return "", err
+	}
+
+	var candidates []string
+
+	for _, oid := range oids {
+		if strings.HasPrefix(oid, prefix) {
+			candidates = append(candidates, oid)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return "", fmt.Errorf("%w: %s", ErrObjectNotFound, prefix)
+	case 1:
+		return candidates[0], nil
+	}
+
+	return "", &ErrAmbiguousObjectID{Prefix: prefix, Candidates: candidates}
+}
+
+// ShortOID returns the shortest abbreviation of oid which is unique in the database
+// and has at least DefaultAbbrevLength characters.
+func (d *Database) ShortOID(oid string) (string, error) {
+	if err := validateOID(oid); err != nil {
+		return "", err
+	}
+
+	oids, err := d.listFanOut(oid[:2])
+	if err != nil {
+		return "", err
+	}
+
+	length := DefaultAbbrevLength
+
+	for _, other := range oids {
+		if other == oid {
+			continue
+		}
+
+		common := 0
+		for common < len(oid) && oid[common] == other[common] {
+			common++
+		}
+
+		length = max(length, common+1)
+	}
+
+	return oid[:min(length, len(oid))], nil
+}
+
+// listFanOut returns sorted object ids stored in .git/objects/{dir}.
+func (d *Database) listFanOut(dir string) ([]string, error) {
+	entries, err := d.fs.ReadDir(filepath.Join(d.objectsPath, dir))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("read objects dir %s: %w", dir, err)
+	}
+
+	oids := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		oid := dir + entry.Name()
+		if entry.IsDir() || len(oid) != 40 || !isHex(oid) {
+			continue
+		}
+
+		oids = append(oids, oid)
+	}
+
+	slices.Sort(oids)
+
+	return oids, nil
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,199 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// NullOID stands for missing object, e.g. old value of newly created ref in reflog.
+const NullOID = "0000000000000000000000000000000000000000"
+
+// ReflogEntry is single line of .git/logs/<ref> file.
+type ReflogEntry struct {
+	OldOID   string
+	NewOID   string
+	Identity *Author
+	Message  string
+}
+
+func (e *ReflogEntry) String() string {
+	return e.OldOID + " " + e.NewOID + " " + e.Identity.String() + "\t" + e.Message + "\n"
+}
+
+// ParseReflogEntry
+// Parses line with format: {old oid} {new oid} {name} <{email}> {timestamp} {timezone}\t{message}.
+func ParseReflogEntry(line string) (*ReflogEntry, error) {
+	header, message, _ := strings.Cut(line, "\t")
+
+	oldOID, rest, found := strings.Cut(header, " ")
+	if !found {
+		return nil, fmt.Errorf("invalid reflog line %q", line)
+	}
+
+	newOID, identity, found := strings.Cut(rest, " ")
+	if !found {
+		return nil, fmt.Errorf("invalid reflog line %q", line)
+	}
+
+	author, err := ParseAuthor(identity)
+	if err != nil {
+		return nil, fmt.Errorf("parse reflog identity: %w", err)
+	}
+
+	return &ReflogEntry{
+		OldOID:   oldOID,
+		NewOID:   newOID,
+		Identity: author,
+		Message:  message,
+	}, nil
+}
+
+// ReadReflog returns entries of .git/logs/{ref} in the order they were written, oldest first.
+// Missing log file is not an error, the ref simply has no history. Invalid ref names are rejected.
+func (r *Refs) ReadReflog(ref string) ([]*ReflogEntry, error) {
+	logPath, err := r.reflogPath(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := r.fs.ReadFile(logPath)
+	if err != nil {
+		if missing(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("read reflog %s: %w", ref, err)
+	}
+
+	var entries []*ReflogEntry
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		entry, err := ParseReflogEntry(line)
+		if err != nil {
+			return nil, fmt.Errorf("reflog %s: %w", ref, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// AppendReflog records ref update in .git/logs/{ref}. Like git with core.logAllRefUpdates, only HEAD and branches
+// are logged unless the log file already exists. Missing identity means the update is not recorded.
+func (r *Refs) AppendReflog(ref, oldOID, newOID string, identity *Author, message string) error {
+	if identity == nil {
+		return nil
+	}
+
+	logPath, err := r.reflogPath(ref)
+	if err != nil {
+		return err
+	}
+
+	content, err := r.fs.ReadFile(logPath)
+	if err != nil && !missing(err) {
+		return fmt.Errorf("read reflog %s: %w", ref, err)
+	}
+
+	if err != nil && ref != HEAD && !strings.HasPrefix(ref, HeadsDir+"/") {
+		return nil
+	}
+
+	if oldOID == "" {
+		oldOID = NullOID
+	}
+
+	entry := &ReflogEntry{
+		OldOID:   oldOID,
+		NewOID:   newOID,
+		Identity: identity,
+		// reflog stores one entry per line
+		Message: strings.Join(strings.Fields(message), " "),
+	}
+
+	if err := r.mkdirAll(filepath.Dir(logPath)); err != nil {
+		return err
+	}
+
+	if err := r.fileWriter.Write(logPath, append(content, entry.String()...)); err != nil {
+		return fmt.Errorf("write reflog %s: %w", ref, err)
+	}
+
+	return nil
+}
+
+// WriteReflog replaces all entries of ref log, used when entries are expired or deleted.
+func (r *Refs) WriteReflog(ref string, entries []*ReflogEntry) error {
+	var content strings.Builder
+
+	for _, entry := range entries {
+		content.WriteString(entry.String())
+	}
+
+	logPath, err := r.reflogPath(ref)
+	if err != nil {
+		return err
+	}
+
+	if err := r.fileWriter.Write(logPath, []byte(content.String())); err != nil {
+		return fmt.Errorf("write reflog %s: %w", ref, err)
+	}
+
+	return nil
+}
+
+// ListReflogs returns names of all refs which have a log, HEAD first.
+func (r *Refs) ListReflogs() ([]string, error) {
+	var names []string
+
+	if _, err := r.fs.Stat(filepath.Join(r.gitDir, "logs", HEAD)); err == nil {
+		names = append(names, HEAD)
+	}
+
+	logs, err := r.looseRefs(filepath.Join("logs", "refs"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range logs {
+		names = append(names, strings.TrimPrefix(name, "logs/"))
+	}
+
+	return names, nil
+}
+
+// deleteReflog removes log of deleted ref, missing log is ignored.
+func (r *Refs) deleteReflog(ref string) error {
+	logPath, err := r.reflogPath(ref)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.fs.Stat(logPath); err != nil {
+		return nil
+	}
+
+	if err := r.fileWriter.Remove(logPath); err != nil {
+		return fmt.Errorf("delete reflog %s: %w", ref, err)
+	}
+
+	r.removeEmptyDirs(filepath.Dir(logPath))
+
+	return nil
+}
+
+// reflogPath returns path of the ref log, ref names are validated the same way as for the ref itself
+// so the path stays inside .git/logs.
+func (r *Refs) reflogPath(ref string) (string, error) {
+	if err := CheckWritableRef(ref); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(r.gitDir, "logs", ref), nil
+}
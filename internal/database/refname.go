@@ -0,0 +1,98 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+type RefFormatOptions struct {
+	// Accept names without slash like HEAD or ORIG_HEAD.
+	AllowOneLevel bool
+	// Accept single * as a component of refspec pattern, e.g. refs/heads/*.
+	RefspecPattern bool
+}
+
+// CheckRefFormat
+// Validates ref name using the rules of git check-ref-format:
+//   - components are separated by slashes, none of them is empty, begins with '.' or ends with ".lock"
+//   - name has at least two components unless AllowOneLevel is set
+//   - name does not contain "..", "@{", backslash, control characters, space or any of ~ ^ : ? * [
+//   - name does not begin or end with slash, does not end with dot and is not a single @
+func CheckRefFormat(name string, options RefFormatOptions) error {
+	if reason := refFormatViolation(name, options); reason != "" {
+		return fmt.Errorf("%w: %s: %s", ErrInvalidRefName, name, reason)
+	}
+
+	return nil
+}
+
+func refFormatViolation(name string, options RefFormatOptions) string {
+	switch {
+	case name == "":
+		return "empty name"
+	case name == "@":
+		return "single @"
+	case strings.HasSuffix(name, "."):
+		return "ends with dot"
+	case strings.Contains(name, ".."):
+		return "contains .."
+	case strings.Contains(name, "@{"):
+		return "contains @{"
+	}
+
+	for _, c := range name {
+		if c < 0x20 || c == 0x7f {
+			return "contains control character"
+		}
+
+		if strings.ContainsRune(" ~^:?[\\", c) {
+			return fmt.Sprintf("contains %q", c)
+		}
+	}
+
+	components := strings.Split(name, "/")
+	if len(components) < 2 && !options.AllowOneLevel {
+		return "one level name"
+	}
+
+	wildcards := 0
+
+	for _, component := range components {
+		switch {
+		case component == "":
+			return "empty component"
+		case strings.HasPrefix(component, "."):
+			return "component begins with dot"
+		case strings.HasSuffix(component, ".lock"):
+			return "component ends with .lock"
+		}
+
+		if strings.Contains(component, "*") {
+			wildcards++
+
+			if !options.RefspecPattern || wildcards > 1 {
+				return "contains '*'"
+			}
+		}
+	}
+
+	return ""
+}
+
+// NormalizeRefName removes leading slash and collapses consecutive slashes as check-ref-format --normalize does.
+func NormalizeRefName(name string) string {
+	components := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '/'
+	})
+
+	return strings.Join(components, "/")
+}
+
+// CheckBranchName reports whether name can be used as a branch name.
+func CheckBranchName(name string) error {
+	if name == HEAD || strings.HasPrefix(name, "-") {
+		return fmt.Errorf("%w: %s", ErrInvalidRefName, name)
+	}
+
+	return CheckRefFormat(BranchRef(name), RefFormatOptions{})
+}
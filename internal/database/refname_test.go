@@ -0,0 +1,81 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+)
+
+func TestCheckRefFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		options database.RefFormatOptions
+		valid   bool
+	}{
+		{name: "refs/heads/master", valid: true},
+		{name: "refs/heads/feature/login", valid: true},
+		{name: "refs/heads/-dash", valid: true},
+		{name: "refs/heads/a@b", valid: true},
+		{name: "master"},
+		{name: "master", options: database.RefFormatOptions{AllowOneLevel: true}, valid: true},
+		{name: "refs/heads/*", options: database.RefFormatOptions{RefspecPattern: true}, valid: true},
+		{name: "refs/*/*", options: database.RefFormatOptions{RefspecPattern: true}},
+		{name: "refs/heads/*"},
+		{name: ""},
+		{name: "@", options: database.RefFormatOptions{AllowOneLevel: true}},
+		{name: "refs/heads/a..b"},
+		{name: "refs/heads/../../config"},
+		{name: "refs/heads/a@{1}"},
+		{name: "refs/heads/a b"},
+		{name: "refs/heads/a\tb"},
+		{name: "refs/heads/a\x7f"},
+		{name: "refs/heads/a~1"},
+		{name: "refs/heads/a^"},
+		{name: "refs/heads/a:b"},
+		{name: "refs/heads/a?"},
+		{name: "refs/heads/[a]"},
+		{name: "refs/heads/a\\b"},
+		{name: "refs/heads/.hidden"},
+		{name: "refs/heads/x.lock"},
+		{name: "refs/heads/x.lock/y"},
+		{name: "refs/heads/x."},
+		{name: "refs/heads/"},
+		{name: "/refs/heads/x"},
+		{name: "refs//heads/x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := database.CheckRefFormat(tt.name, tt.options)
+			if tt.valid {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorIs(t, err, database.ErrInvalidRefName)
+		})
+	}
+}
+
+func TestCheckBranchName(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, database.CheckBranchName("feature/login"))
+	require.ErrorIs(t, database.CheckBranchName("HEAD"), database.ErrInvalidRefName)
+	require.ErrorIs(t, database.CheckBranchName("-dash"), database.ErrInvalidRefName)
+	require.ErrorIs(t, database.CheckBranchName("a..b"), database.ErrInvalidRefName)
+}
+
+func TestNormalizeRefName(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "refs/heads/x", database.NormalizeRefName("//refs//heads/x"))
+	require.Equal(t, "refs/heads/x", database.NormalizeRefName("refs/heads/x/"))
+}
@@ -3,14 +3,71 @@ package database
 import (
 	"errors"
 	"fmt"
-	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"syscall"
 
 	"github.com/LukasJenicek/ggit/internal/filesystem"
 )
 
+const (
+	maxSymrefDepth = 5
+
+	HEAD     = "HEAD"
+	HeadsDir = "refs/heads"
+)
+
+var (
+	ErrRefNotFound    = errors.New("ref not found")
+	ErrRefExists      = errors.New("ref already exists")
+	ErrInvalidRefName = errors.New("invalid ref name")
+)
+
+// Ref is a named pointer to an object.
+// Name is the full path relative to the git dir, e.g. refs/heads/master.
+type Ref struct {
+	Name string
+	OID  string
+	// Peeled is object id annotated tag points to, it is known only for packed refs.
+	Peeled string
+}
+
+// ShortName strips refs/heads/, refs/tags/, refs/remotes/ or refs/ prefix from the ref name.
+func (r *Ref) ShortName() string {
+	for _, prefix := range []string{"refs/heads/", "refs/tags/", "refs/remotes/", "refs/"} {
+		if short, ok := strings.CutPrefix(r.Name, prefix); ok {
+			return short
+		}
+	}
+
+	return r.Name
+}
+
+// BranchRef returns full ref name of branch.
+func BranchRef(branch string) string {
+	return HeadsDir + "/" + branch
+}
+
+// Head is either symbolic, pointing to a branch, or detached, pointing directly to a commit.
+type Head struct {
+	// Ref is full name of the ref HEAD points to, empty when HEAD is detached.
+	Ref string
+	// OID is commit id HEAD resolves to, empty for branch without commits.
+	OID string
+}
+
+func (h *Head) Detached() bool {
+	return h.Ref == ""
+}
+
+// Branch returns short name of the branch HEAD points to.
+func (h *Head) Branch() string {
+	return strings.TrimPrefix(h.Ref, HeadsDir+"/")
+}
+
 type Refs struct {
 	fs         filesystem.Fs
 	fileWriter *filesystem.AtomicFileWriter
@@ -56,57 +113,513 @@ func (r *Refs) InitRef(ref string) error {
 	return nil
 }
 
-func (r *Refs) UpdateHead(commitID string) error {
+// UpdateHead moves the branch HEAD points to, or HEAD itself when it is detached.
+func (r *Refs) UpdateHead(commitID string, identity *Author, message string) error {
+	if commitID == "" {
+		return errors.New("commit id is empty")
+	}
+
+	transaction := r.Transaction()
+	transaction.Update(HEAD, commitID, "")
+
+	return transaction.Commit(identity, message)
+}
+
+// DetachHead points HEAD directly to commit, the branch it pointed to is kept.
+func (r *Refs) DetachHead(commitID string) error {
 	if commitID == "" {
 		return errors.New("commit id is empty")
 	}
 
-	currentRef, err := r.CurrentRef()
+	transaction := r.Transaction()
+	transaction.UpdateNoDeref(HEAD, commitID, "")
+
+	return transaction.Commit(nil, "")
+}
+
+// ReadHead returns commit id HEAD resolves to, empty string for branch without commits.
+func (r *Refs) ReadHead() (string, error) {
+	head, err := r.Head()
+	if err != nil {
+		return "", err
+	}
+
+	return head.OID, nil
+}
+
+// Head reads HEAD and resolves it to commit id.
+func (r *Refs) Head() (*Head, error) {
+	content, err := r.fs.ReadFile(r.headFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("read HEAD: %w", err)
+	}
+
+	value := strings.TrimSpace(string(content))
+
+	target, symbolic := strings.CutPrefix(value, "ref: ")
+	if !symbolic {
+		return &Head{OID: value}, nil
+	}
+
+	oid, _, err := r.resolveRef(target, 1)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", target, err)
+	}
+
+	return &Head{Ref: target, OID: oid}, nil
+}
+
+// ReadRef
+// Resolves short or full ref name into object id using the lookup order of ExpandRef.
+// Symbolic refs are followed. Empty string is returned when no ref matches.
+func (r *Refs) ReadRef(name string) (string, error) {
+	ref, err := r.ExpandRef(name)
+	if err != nil || ref == "" {
+		return "", err
+	}
+
+	oid, _, err := r.resolveRef(ref, 0)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", ref, err)
+	}
+
+	return oid, nil
+}
+
+// ExpandRef
+// Finds full name of existing ref using the same lookup order as git:
+// $GIT_DIR/name, refs/name, refs/tags/name, refs/heads/name, refs/remotes/name and refs/remotes/name/HEAD.
+// $GIT_DIR/name is tried only for pseudo-refs like HEAD or ORIG_HEAD and for names under refs/, so other files
+// of the git dir are never read as refs. Empty string is returned when no ref matches.
+func (r *Refs) ExpandRef(name string) (string, error) {
+	if name == "" {
+		return "", errors.New("ref name is empty")
+	}
+
+	// invalid name cannot match any ref, the check also keeps candidates inside the git dir
+	if CheckRefFormat(name, RefFormatOptions{AllowOneLevel: true}) != nil {
+		return "", nil
+	}
+
+	candidates := []string{
+		name,
+		"refs/" + name,
+		"refs/tags/" + name,
+		"refs/heads/" + name,
+		"refs/remotes/" + name,
+		"refs/remotes/" + name + "/HEAD",
+	}
+
+	for _, candidate := range candidates {
+		_, found, err := r.resolveRef(candidate, 0)
+		if err != nil {
+			return "", fmt.Errorf("resolve %s: %w", candidate, err)
+		}
+
+		if found {
+			return candidate, nil
+		}
+	}
+
+	return "", nil
+}
+
+// resolveRef reads ref file relative to git dir and follows "ref: " pointers.
+// Refs missing as loose files are looked up in packed-refs.
+// Found is false when the ref does not exist, oid can be empty for unborn branch. Names which are neither
+// pseudo-refs nor under refs/ and files which hold neither object id nor "ref: " pointer are not refs.
+func (r *Refs) resolveRef(ref string, depth int) (string, bool, error) {
+	if depth > maxSymrefDepth {
+		return "", false, fmt.Errorf("symbolic ref %s nested too deep", ref)
+	}
+
+	if CheckWritableRef(ref) != nil {
+		return "", false, nil
+	}
+
+	path := filepath.Join(r.gitDir, ref)
+
+	stat, err := r.fs.Stat(path)
+	if err != nil && !missing(err) {
+		return "", false, fmt.Errorf("stat ref: %w", err)
+	}
+
+	// directory left after deleted refs like refs/heads/a/b does not hide packed refs/heads/a
+	if err != nil || stat.IsDir() {
+		packed, err := r.packedRef(ref)
+		if err != nil || packed == nil {
+			return "", false, err
+		}
+
+		return packed.OID, true, nil
+	}
+
+	content, err := r.fs.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("read ref: %w", err)
+	}
+
+	value := strings.TrimSpace(string(content))
+
+	if target, ok := strings.CutPrefix(value, "ref: "); ok {
+		oid, _, err := r.resolveRef(target, depth+1)
+		if err != nil {
+			return "", false, err
+		}
+
+		return oid, true, nil
+	}
+
+	// broken ref is ignored like git does
+	if validateOID(value) != nil {
+		return "", false, nil
+	}
+
+	return value, true, nil
+}
+
+// ListRefs returns loose and packed refs stored under prefix, e.g. refs/heads, sorted by name.
+// Loose ref takes precedence over packed one with the same name.
+func (r *Refs) ListRefs(prefix string) ([]*Ref, error) {
+	loose, err := r.looseRefs(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]*Ref, 0, len(loose))
+	seen := make(map[string]bool, len(loose))
+
+	for _, name := range loose {
+		oid, found, err := r.resolveRef(name, 0)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %s: %w", name, err)
+		}
+
+		if !found {
+			continue
+		}
+
+		refs = append(refs, &Ref{Name: name, OID: oid})
+		seen[name] = true
+	}
+
+	packed, err := r.readPackedRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ref := range packed {
+		if !seen[ref.Name] && strings.HasPrefix(ref.Name, strings.TrimSuffix(prefix, "/")+"/") {
+			refs = append(refs, ref)
+		}
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Name < refs[j].Name
+	})
+
+	return refs, nil
+}
+
+// CreateRef creates ref pointing to oid, it fails with ErrRefExists when the ref is already present.
+func (r *Refs) CreateRef(name, oid string) error {
+	if err := CheckWritableRef(name); err != nil {
+		return err
+	}
+
+	_, found, err := r.resolveRef(name, 0)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", name, err)
+	}
+
+	if found {
+		return fmt.Errorf("%w: %s", ErrRefExists, name)
+	}
+
+	// ref created meanwhile is caught by the transaction
+	transaction := r.Transaction()
+	transaction.Create(name, oid)
+
+	return transaction.Commit(nil, "")
+}
+
+// UpdateRef points ref to oid, the ref and its parent directories are created when missing.
+func (r *Refs) UpdateRef(name, oid string) error {
+	if oid == "" {
+		return errors.New("oid is empty")
+	}
+
+	transaction := r.Transaction()
+	transaction.Update(name, oid, "")
+
+	return transaction.Commit(nil, "")
+}
+
+// DeleteRef removes ref and returns object id it pointed to.
+// Directories left empty after removal are removed as well, up to the refs/ directory.
+func (r *Refs) DeleteRef(name string) (string, error) {
+	// ref with invalid name cannot exist, the check also keeps the path inside .git/refs
+	if CheckRefFormat(name, RefFormatOptions{}) != nil {
+		return "", fmt.Errorf("%w: %s", ErrRefNotFound, name)
+	}
+
+	oid, found, err := r.resolveRef(name, 0)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", name, err)
+	}
+
+	if !found {
+		return "", fmt.Errorf("%w: %s", ErrRefNotFound, name)
+	}
+
+	transaction := r.Transaction()
+	transaction.Delete(name, oid)
+
+	if err := transaction.Commit(nil, ""); err != nil {
+		return "", err
+	}
+
+	return oid, nil
+}
+
+// RenameRef moves ref to a new name, HEAD is updated when it points to the renamed branch.
+// The old ref is deleted only when it still points to the value read at the start and the new ref is created
+// only when it does not exist yet. Old and new names may conflict like refs/heads/a and refs/heads/a/b, so
+// it cannot be a single transaction. When the new ref cannot be created the old ref is restored.
+// The rename is recorded in reflog of the new ref, and of HEAD when it points to it, with identity and message.
+func (r *Refs) RenameRef(oldName, newName string, identity *Author, message string) error {
+	oid, found, err := r.resolveRef(oldName, 0)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", oldName, err)
+	}
+
+	current, err := r.CurrentRef()
+	if err != nil {
+		return fmt.Errorf("read current ref: %w", err)
+	}
+
+	// current branch without commits exists only in HEAD
+	unborn := !found && BranchRef(current) == oldName
+
+	if !found && !unborn {
+		return fmt.Errorf("%w: %s", ErrRefNotFound, oldName)
+	}
+
+	if oldName == newName {
+		return nil
+	}
+
+	_, exists, err := r.resolveRef(newName, 0)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", newName, err)
+	}
+
+	if exists {
+		return fmt.Errorf("%w: %s", ErrRefExists, newName)
+	}
+
+	checkedOut := BranchRef(current) == oldName
+
+	if unborn {
+		return r.InitRef("ref: " + newName)
+	}
+
+	entries, err := r.ReadReflog(oldName)
 	if err != nil {
-		return fmt.Errorf("parse current ref: %w", err)
+		return err
+	}
+
+	deletion := r.Transaction()
+	deletion.Delete(oldName, oid)
+
+	if err := deletion.Commit(nil, ""); err != nil {
+		return err
 	}
 
-	refPath := filepath.Join(r.gitDir, "refs", "heads", currentRef)
+	if err := r.moveRef(oldName, newName, oid, entries, checkedOut, identity, message); err != nil {
+		// best effort, the ref was deleted by us and nobody else should hold its name
+		_ = r.moveRef(newName, oldName, oid, entries, checkedOut, nil, "")
 
-	if err := r.fileWriter.Write(refPath, []byte(commitID)); err != nil {
-		return fmt.Errorf("update : %w", err)
+		return err
 	}
 
 	return nil
 }
 
-func (r *Refs) ReadHead() (string, error) {
-	currentRef, err := r.CurrentRef()
+// moveRef creates ref with history of the deleted one. HEAD is switched first so the transaction logs it as well.
+func (r *Refs) moveRef(
+	from, to, oid string,
+	entries []*ReflogEntry,
+	checkedOut bool,
+	identity *Author,
+	message string,
+) error {
+	if checkedOut {
+		if err := r.InitRef("ref: " + to); err != nil {
+			return fmt.Errorf("update HEAD: %w", err)
+		}
+	}
+
+	creation := r.Transaction()
+	creation.Create(to, oid)
+
+	if err := creation.Commit(identity, message); err != nil {
+		if checkedOut {
+			_ = r.InitRef("ref: " + from)
+		}
+
+		return err
+	}
+
+	if entries == nil {
+		return nil
+	}
+
+	// history of the old name goes before the entry of the rename
+	logged, err := r.ReadReflog(to)
 	if err != nil {
-		return "", fmt.Errorf("parse current ref: %w", err)
+		return err
+	}
+
+	if err := r.mkdirAll(filepath.Dir(filepath.Join(r.gitDir, "logs", to))); err != nil {
+		return err
+	}
+
+	return r.WriteReflog(to, append(entries, logged...))
+}
+
+// CheckWritableRef validates name of ref before it becomes a path under git dir, for reads and writes alike.
+// One level names are allowed only in upper case like HEAD or ORIG_HEAD, the rest must live under refs/.
+func CheckWritableRef(name string) error {
+	if err := CheckRefFormat(name, RefFormatOptions{AllowOneLevel: true}); err != nil {
+		return err
+	}
+
+	if !strings.Contains(name, "/") && strings.ToUpper(name) != name {
+		return fmt.Errorf("%w: %s: one level name", ErrInvalidRefName, name)
+	}
+
+	if strings.Contains(name, "/") && !strings.HasPrefix(name, "refs/") {
+		return fmt.Errorf("%w: %s: outside of refs/", ErrInvalidRefName, name)
+	}
+
+	return nil
+}
+
+// checkNameConflict fails when a loose or packed ref makes name impossible to create. Refs like refs/heads/a
+// and refs/heads/a/b cannot exist together, one of them would have to be both a file and a directory.
+func (r *Refs) checkNameConflict(name string) error {
+	components := strings.Split(name, "/")
+
+	for i := 1; i < len(components); i++ {
+		prefix := strings.Join(components[:i], "/")
+
+		_, found, err := r.resolveRef(prefix, 0)
+		if err != nil {
+			return fmt.Errorf("resolve %s: %w", prefix, err)
+		}
+
+		if found {
+			return nameConflict(name, prefix)
+		}
 	}
 
-	refPath := filepath.Join(r.gitDir, "refs", "heads", currentRef)
+	loose, err := r.looseRefs(name)
+	if err != nil {
+		return err
+	}
 
-	refFile, err := r.fs.Open(refPath)
+	packed, err := r.readPackedRefs()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return "", nil
-		} else {
-			return "", fmt.Errorf("open HEAD file: %w", err)
+		return err
+	}
+
+	for _, ref := range packed {
+		loose = append(loose, ref.Name)
+	}
+
+	for _, existing := range loose {
+		if strings.HasPrefix(existing, name+"/") {
+			return nameConflict(name, existing)
 		}
 	}
 
-	currentCID, err := io.ReadAll(refFile)
+	return nil
+}
+
+func nameConflict(name, existing string) error {
+	return &ErrRefLock{Ref: name, Reason: fmt.Sprintf("'%s' exists; cannot create '%s'", existing, name)}
+}
+
+func (r *Refs) mkdirAll(dir string) error {
+	rel, err := filepath.Rel(r.gitDir, dir)
 	if err != nil {
-		return "", fmt.Errorf("read from HEAD file: %w", err)
+		return fmt.Errorf("relative ref dir: %w", err)
+	}
+
+	current := r.gitDir
+
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+
+		stat, err := r.fs.Stat(current)
+		if err == nil {
+			if !stat.IsDir() {
+				return fmt.Errorf("%w: %s is a file", ErrRefExists, current)
+			}
+
+			continue
+		}
+
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("stat %s: %w", current, err)
+		}
+
+		if err := r.fs.Mkdir(current, 0o755); err != nil {
+			return fmt.Errorf("create %s: %w", current, err)
+		}
 	}
 
-	return strings.TrimSpace(string(currentCID)), nil
+	return nil
+}
+
+// missing reports whether ref file does not exist. File in place of a parent directory, like refs/heads/a
+// for refs/heads/a/b, means the ref does not exist either.
+func missing(err error) bool {
+	return errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ENOTDIR)
+}
+
+func (r *Refs) removeEmptyDirs(dir string) {
+	refsDir := filepath.Join(r.gitDir, "refs")
+	if logsDir := filepath.Join(r.gitDir, "logs", "refs"); strings.HasPrefix(dir, logsDir) {
+		refsDir = logsDir
+	}
+
+	// keep top level directories like refs/heads and refs/tags
+	for strings.HasPrefix(dir, refsDir+string(filepath.Separator)) && filepath.Dir(dir) != refsDir {
+		entries, err := r.fs.ReadDir(dir)
+		if err != nil || len(entries) > 0 {
+			return
+		}
+
+		if err := r.fs.Remove(dir); err != nil {
+			return
+		}
+
+		dir = filepath.Dir(dir)
+	}
 }
 
-// CurrentRef
-// TODO: CurrentRef() unconditionally replaces "ref: refs/heads/" with an empty string, which assumes HEAD is always a symbolic reference.
+// CurrentRef returns name of the current branch or HEAD when HEAD is detached.
 func (r *Refs) CurrentRef() (string, error) {
-	refs, err := r.fs.ReadFile(r.headFilePath)
+	head, err := r.Head()
 	if err != nil {
-		return "", fmt.Errorf("read refs: %w", err)
+		return "", err
+	}
+
+	if head.Detached() {
+		return HEAD, nil
 	}
 
-	return strings.Replace(string(refs), "ref: refs/heads/", "", 1), nil
+	return head.Branch(), nil
 }
@@ -0,0 +1,445 @@
+package database_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/filesystem"
+	"github.com/LukasJenicek/ggit/internal/filesystem/memory"
+)
+
+const (
+	firstOID  = "1111111111111111111111111111111111111111"
+	secondOID = "2222222222222222222222222222222222222222"
+)
+
+func TestRefs_Head(t *testing.T) {
+	t.Parallel()
+
+	t.Run("symbolic", func(t *testing.T) {
+		t.Parallel()
+
+		refs, fs := newRefs(t, "ref: refs/heads/master\n")
+
+		head, err := refs.Head()
+		require.NoError(t, err)
+		require.False(t, head.Detached())
+		require.Equal(t, "refs/heads/master", head.Ref)
+		require.Empty(t, head.OID)
+
+		require.NoError(t, refs.UpdateHead(firstOID, nil, ""))
+
+		content, err := fs.ReadFile("tmp/.git/refs/heads/master")
+		require.NoError(t, err)
+		require.Equal(t, firstOID+"\n", string(content))
+
+		head, err = refs.Head()
+		require.NoError(t, err)
+		require.Equal(t, &database.Head{Ref: "refs/heads/master", OID: firstOID}, head)
+
+		current, err := refs.CurrentRef()
+		require.NoError(t, err)
+		require.Equal(t, "master", current)
+	})
+
+	t.Run("detached", func(t *testing.T) {
+		t.Parallel()
+
+		refs, fs := newRefs(t, firstOID+"\n")
+
+		head, err := refs.Head()
+		require.NoError(t, err)
+		require.True(t, head.Detached())
+		require.Equal(t, firstOID, head.OID)
+
+		current, err := refs.CurrentRef()
+		require.NoError(t, err)
+		require.Equal(t, database.HEAD, current)
+
+		require.NoError(t, refs.UpdateHead(secondOID, nil, ""))
+
+		oid, err := refs.ReadHead()
+		require.NoError(t, err)
+		require.Equal(t, secondOID, oid)
+
+		// no branch is created for the detached commit
+		refList, err := refs.ListRefs(database.HeadsDir)
+		require.NoError(t, err)
+		require.Empty(t, refList)
+
+		content, err := fs.ReadFile("tmp/.git/HEAD")
+		require.NoError(t, err)
+		require.Equal(t, secondOID+"\n", string(content))
+	})
+
+	t.Run("detach symbolic head", func(t *testing.T) {
+		t.Parallel()
+
+		refs, _ := newRefs(t, "ref: refs/heads/master\n")
+		require.NoError(t, refs.UpdateHead(firstOID, nil, ""))
+		require.NoError(t, refs.DetachHead(secondOID))
+
+		head, err := refs.Head()
+		require.NoError(t, err)
+		require.True(t, head.Detached())
+		require.Equal(t, secondOID, head.OID)
+
+		master, err := refs.ReadRef("master")
+		require.NoError(t, err)
+		require.Equal(t, firstOID, master)
+	})
+}
+
+func TestRefs_PackedRefs(t *testing.T) {
+	t.Parallel()
+
+	refs, fs := newRefs(t, "ref: refs/heads/master\n")
+	require.NoError(t, fs.WriteFile("tmp/.git/packed-refs", []byte("# pack-refs with: peeled fully-peeled sorted \n"+
+		firstOID+" refs/heads/master\n"+
+		firstOID+" refs/heads/topic\n"+
+		secondOID+" refs/tags/v1.0\n"+
+		"^"+firstOID+"\n"), 0o644))
+
+	head, err := refs.ReadHead()
+	require.NoError(t, err)
+	require.Equal(t, firstOID, head)
+
+	// loose ref shadows the packed one
+	require.NoError(t, refs.UpdateRef("refs/heads/topic", secondOID))
+
+	branches, err := refs.ListRefs(database.HeadsDir)
+	require.NoError(t, err)
+	require.Equal(t, []*database.Ref{
+		{Name: "refs/heads/master", OID: firstOID},
+		{Name: "refs/heads/topic", OID: secondOID},
+	}, branches)
+
+	tags, err := refs.ListRefs("refs/tags")
+	require.NoError(t, err)
+	require.Equal(t, []*database.Ref{{Name: "refs/tags/v1.0", OID: secondOID, Peeled: firstOID}}, tags)
+
+	require.ErrorIs(t, refs.CreateRef("refs/tags/v1.0", firstOID), database.ErrRefExists)
+
+	oid, err := refs.DeleteRef("refs/heads/topic")
+	require.NoError(t, err)
+	require.Equal(t, secondOID, oid)
+
+	oid, err = refs.DeleteRef("refs/tags/v1.0")
+	require.NoError(t, err)
+	require.Equal(t, secondOID, oid)
+
+	oid, err = refs.ReadRef("topic")
+	require.NoError(t, err)
+	require.Empty(t, oid)
+
+	content, err := fs.ReadFile("tmp/.git/packed-refs")
+	require.NoError(t, err)
+	require.Equal(t, "# pack-refs with: peeled fully-peeled sorted \n"+firstOID+" refs/heads/master\n", string(content))
+}
+
+func TestRefs_PackedNameConflicts(t *testing.T) {
+	t.Parallel()
+
+	refs, fs := newRefs(t, "ref: refs/heads/master\n")
+	require.NoError(t, fs.WriteFile("tmp/.git/packed-refs", []byte(firstOID+" refs/heads/master\n"+
+		firstOID+" refs/heads/new/x\n"), 0o644))
+	// directory left behind by a deleted refs/heads/master/old
+	require.NoError(t, fs.Mkdir("tmp/.git/refs/heads/master", 0o755))
+
+	head, err := refs.ReadHead()
+	require.NoError(t, err)
+	require.Equal(t, firstOID, head)
+
+	var lockErr *database.ErrRefLock
+
+	err = refs.CreateRef("refs/heads/master/x", firstOID)
+	require.ErrorAs(t, err, &lockErr)
+	require.Equal(t, "cannot lock ref 'refs/heads/master/x': 'refs/heads/master' exists; cannot create 'refs/heads/master/x'", err.Error())
+
+	err = refs.CreateRef("refs/heads/new", firstOID)
+	require.ErrorAs(t, err, &lockErr)
+	require.Equal(t, "cannot lock ref 'refs/heads/new': 'refs/heads/new/x' exists; cannot create 'refs/heads/new'", err.Error())
+
+	tx := refs.Transaction()
+	tx.Create("refs/heads/master/y", firstOID)
+	require.ErrorAs(t, tx.Commit(nil, ""), &lockErr)
+
+	for _, path := range []string{"tmp/.git/refs/heads/master/x", "tmp/.git/refs/heads/master/y", "tmp/.git/refs/heads/new"} {
+		_, err = fs.Stat(path)
+		require.ErrorIs(t, err, os.ErrNotExist, path)
+	}
+}
+
+func TestRefs_PackRefs(t *testing.T) {
+	t.Parallel()
+
+	// lock files are created by absolute path which in-memory fs does not support
+	root := t.TempDir()
+	gitDir := filepath.Join(root, ".git")
+	require.NoError(t, os.MkdirAll(filepath.Join(gitDir, "refs", "heads"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/master\n"), 0o644))
+
+	loose := map[string]string{
+		"master": firstOID + "\n",
+		"topic":  secondOID + "\n",
+		"broken": "garbage\n",
+	}
+	for name, content := range loose {
+		require.NoError(t, os.WriteFile(filepath.Join(gitDir, "refs", "heads", name), []byte(content), 0o644))
+	}
+
+	fs := filesystem.New()
+	locker := filesystem.NewFileLocker(fs)
+
+	writer, err := filesystem.NewAtomicFileWriter(fs, locker)
+	require.NoError(t, err)
+
+	refs, err := database.NewRefs(fs, gitDir, writer)
+	require.NoError(t, err)
+
+	db, err := database.New(fs, root)
+	require.NoError(t, err)
+
+	packedLock, err := locker.Lock(filepath.Join(gitDir, "packed-refs"))
+	require.NoError(t, err)
+	require.ErrorIs(t, refs.PackRefs(db, true), filesystem.ErrLockAcquired)
+	require.NoError(t, locker.Unlock(packedLock))
+
+	// ref locked by another writer may be updated meanwhile, it is left loose
+	topicLock, err := locker.Lock(filepath.Join(gitDir, "refs", "heads", "topic"))
+	require.NoError(t, err)
+	require.NoError(t, refs.PackRefs(db, true))
+	require.NoError(t, locker.Unlock(topicLock))
+
+	content, err := os.ReadFile(filepath.Join(gitDir, "packed-refs"))
+	require.NoError(t, err)
+	require.Equal(t, "# pack-refs with: peeled fully-peeled sorted \n"+firstOID+" refs/heads/master\n", string(content))
+
+	_, err = os.Stat(filepath.Join(gitDir, "refs", "heads", "master"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// broken ref is not packed so packed-refs stays readable
+	for _, name := range []string{"topic", "broken"} {
+		_, err = os.Stat(filepath.Join(gitDir, "refs", "heads", name))
+		require.NoError(t, err, name)
+	}
+
+	branches, err := refs.ListRefs(database.HeadsDir)
+	require.NoError(t, err)
+	require.Equal(t, []*database.Ref{
+		{Name: "refs/heads/master", OID: firstOID},
+		{Name: "refs/heads/topic", OID: secondOID},
+	}, branches)
+}
+
+func TestRefs_Reflog(t *testing.T) {
+	t.Parallel()
+
+	refs, fs := newRefs(t, "ref: refs/heads/master\n")
+
+	now := time.Unix(976900080, 0).UTC()
+	identity := database.NewAuthor("lukas.jenicek5@gmail.com", "Lukas Jenicek", &now)
+
+	require.NoError(t, refs.UpdateHead(firstOID, identity, "commit (initial): first"))
+	require.NoError(t, refs.UpdateHead(secondOID, identity, "commit: second\n\nbody"))
+
+	first := database.NullOID + " " + firstOID + " Lukas Jenicek <lukas.jenicek5@gmail.com> 976900080 +0000\tcommit (initial): first\n"
+	second := firstOID + " " + secondOID + " Lukas Jenicek <lukas.jenicek5@gmail.com> 976900080 +0000\tcommit: second body\n"
+
+	for _, log := range []string{"tmp/.git/logs/HEAD", "tmp/.git/logs/refs/heads/master"} {
+		content, err := fs.ReadFile(log)
+		require.NoError(t, err)
+		require.Equal(t, first+second, string(content))
+	}
+
+	entries, err := refs.ReadReflog("refs/heads/master")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "commit: second body", entries[1].Message)
+
+	// detached HEAD is logged only in HEAD log
+	require.NoError(t, refs.DetachHead(firstOID))
+	require.NoError(t, refs.UpdateHead(secondOID, identity, "commit: detached"))
+
+	entries, err = refs.ReadReflog(database.HEAD)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	entries, err = refs.ReadReflog("refs/heads/master")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	// tags are not logged unless the log already exists
+	require.NoError(t, refs.UpdateRef("refs/tags/v1.0", firstOID))
+	require.NoError(t, refs.AppendReflog("refs/tags/v1.0", "", firstOID, identity, "tag"))
+
+	entries, err = refs.ReadReflog("refs/tags/v1.0")
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	require.NoError(t, refs.RenameRef("refs/heads/master", "refs/heads/main", identity, "Branch: renamed"))
+
+	logs, err := refs.ListReflogs()
+	require.NoError(t, err)
+	require.Equal(t, []string{database.HEAD, "refs/heads/main"}, logs)
+
+	// history is kept and the rename is logged after it
+	entries, err = refs.ReadReflog("refs/heads/main")
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Equal(t, "Branch: renamed", entries[2].Message)
+
+	_, err = refs.DeleteRef("refs/heads/main")
+	require.NoError(t, err)
+
+	logs, err = refs.ListReflogs()
+	require.NoError(t, err)
+	require.Equal(t, []string{database.HEAD}, logs)
+}
+
+func TestRefs_RenameRef(t *testing.T) {
+	t.Parallel()
+
+	refs, fs := newRefs(t, "ref: refs/heads/master\n")
+
+	now := time.Unix(976900080, 0).UTC()
+	identity := database.NewAuthor("lukas.jenicek5@gmail.com", "Lukas Jenicek", &now)
+
+	require.NoError(t, refs.UpdateHead(firstOID, identity, "commit (initial): first"))
+	require.NoError(t, refs.CreateRef("refs/heads/topic", secondOID))
+
+	// new name nested under the old one needs the old ref file removed first
+	require.NoError(t, refs.RenameRef("refs/heads/master", "refs/heads/master/x", identity, "Branch: renamed"))
+
+	current, err := refs.CurrentRef()
+	require.NoError(t, err)
+	require.Equal(t, "master/x", current)
+
+	for _, ref := range []string{database.HEAD, "refs/heads/master/x"} {
+		entries, err := refs.ReadReflog(ref)
+		require.NoError(t, err)
+		require.Len(t, entries, 2, ref)
+		require.Equal(t, "Branch: renamed", entries[1].Message, ref)
+	}
+
+	// failed rename keeps the old ref with its history
+	require.NoError(t, fs.WriteFile("tmp/.git/packed-refs", []byte(secondOID+" refs/heads/taken/y\n"), 0o644))
+
+	var lockErr *database.ErrRefLock
+	require.ErrorAs(t, refs.RenameRef("refs/heads/master/x", "refs/heads/taken", identity, "Branch: renamed"), &lockErr)
+
+	oid, err := refs.ReadRef("refs/heads/master/x")
+	require.NoError(t, err)
+	require.Equal(t, firstOID, oid)
+
+	entries, err := refs.ReadReflog("refs/heads/master/x")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	current, err = refs.CurrentRef()
+	require.NoError(t, err)
+	require.Equal(t, "master/x", current)
+
+	require.ErrorIs(t, refs.RenameRef("refs/heads/topic", "refs/heads/master/x", identity, ""), database.ErrRefExists)
+}
+
+func TestRefs_InvalidNames(t *testing.T) {
+	t.Parallel()
+
+	refs, _ := newRefs(t, "ref: refs/heads/master\n")
+
+	for _, name := range []string{"refs/heads/../../config", "refs/heads/a.lock", "master", "objects/info", "refs/heads/a b"} {
+		require.ErrorIs(t, refs.CreateRef(name, firstOID), database.ErrInvalidRefName, name)
+		require.ErrorIs(t, refs.UpdateRef(name, firstOID), database.ErrInvalidRefName, name)
+
+		transaction := refs.Transaction()
+		transaction.Update(name, firstOID, "")
+		require.ErrorIs(t, transaction.Commit(nil, ""), database.ErrInvalidRefName, name)
+
+		_, err := refs.DeleteRef(name)
+		require.ErrorIs(t, err, database.ErrRefNotFound, name)
+
+		_, err = refs.ReadReflog(name)
+		require.ErrorIs(t, err, database.ErrInvalidRefName, name)
+		require.ErrorIs(t, refs.WriteReflog(name, nil), database.ErrInvalidRefName, name)
+	}
+
+	require.NoError(t, refs.UpdateRef("ORIG_HEAD", firstOID))
+}
+
+func TestRefs_ReadOnlyRefs(t *testing.T) {
+	t.Parallel()
+
+	refs, fs := newRefs(t, "ref: refs/heads/master\n")
+
+	require.NoError(t, fs.WriteFile("tmp/.git/index", []byte("DIRC\x00\x00\x00\x02"), 0o644))
+	require.NoError(t, fs.WriteFile("tmp/.git/config", []byte("[core]\n"), 0o644))
+	require.NoError(t, fs.WriteFile("tmp/x", []byte(firstOID+"\n"), 0o644))
+	require.NoError(t, fs.WriteFile("tmp/.git/refs/heads/broken", []byte("not an object id\n"), 0o644))
+	require.NoError(t, fs.WriteFile("tmp/.git/refs/heads/escape", []byte("ref: ../x\n"), 0o644))
+	require.NoError(t, fs.WriteFile("tmp/.git/refs/heads/master", []byte(firstOID+"\n"), 0o644))
+
+	for _, name := range []string{"index", "config", "../x", "refs/../../x", "heads/../../../x", "broken", "refs/heads/broken"} {
+		ref, err := refs.ExpandRef(name)
+		require.NoError(t, err, name)
+		require.Empty(t, ref, name)
+
+		oid, err := refs.ReadRef(name)
+		require.NoError(t, err, name)
+		require.Empty(t, oid, name)
+	}
+
+	oid, err := refs.ReadRef("escape")
+	require.NoError(t, err)
+	require.Empty(t, oid)
+
+	list, err := refs.ListRefs(database.HeadsDir)
+	require.NoError(t, err)
+	require.Equal(t, []*database.Ref{
+		{Name: "refs/heads/escape"},
+		{Name: "refs/heads/master", OID: firstOID},
+	}, list)
+}
+
+func TestRefs_ReflogTraversal(t *testing.T) {
+	t.Parallel()
+
+	refs, fs := newRefs(t, "ref: refs/heads/master\n")
+
+	line := firstOID + " " + secondOID + " Lukas Jenicek <lukas.jenicek5@gmail.com> 1734282480 +0000\tcommit: x\n"
+	require.NoError(t, fs.WriteFile("tmp/.git/secret", []byte(line), 0o644))
+
+	for _, name := range []string{"../secret", "refs/../../secret", "refs/heads/../../../secret"} {
+		entries, err := refs.ReadReflog(name)
+		require.ErrorIs(t, err, database.ErrInvalidRefName, name)
+		require.Nil(t, entries, name)
+
+		require.ErrorIs(t, refs.WriteReflog(name, nil), database.ErrInvalidRefName, name)
+	}
+
+	content, err := fs.ReadFile("tmp/.git/secret")
+	require.NoError(t, err)
+	require.Equal(t, line, string(content))
+}
+
+func newRefs(t *testing.T, head string) (*database.Refs, *memory.Fs) {
+	t.Helper()
+
+	fs := memory.New(fstest.MapFS{
+		"tmp/.git/HEAD": &fstest.MapFile{Data: []byte(head)},
+	})
+
+	writer, err := filesystem.NewAtomicFileWriter(fs, filesystem.NewFileLocker(fs))
+	require.NoError(t, err)
+
+	refs, err := database.NewRefs(fs, "tmp/.git", writer)
+	require.NoError(t, err)
+
+	return refs, fs
+}
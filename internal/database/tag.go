@@ -0,0 +1,100 @@
+package database
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Tag is an annotated tag object pointing to another object.
+type Tag struct {
+	OID string
+	// Object is id of the tagged object.
+	Object string
+	// Type is type of the tagged object.
+	Type    string
+	Name    string
+	Tagger  *Author
+	Message string
+}
+
+func NewTag(object, objectType, name string, tagger *Author, message string) (*Tag, error) {
+	if strings.TrimSpace(object) == "" {
+		return nil, errors.New("tagged object must not be empty")
+	}
+
+	if objectType == "" {
+		return nil, errors.New("tagged object type must not be empty")
+	}
+
+	if name == "" {
+		return nil, errors.New("tag name must not be empty")
+	}
+
+	if tagger == nil {
+		return nil, errors.New("tagger must not be nil")
+	}
+
+	return &Tag{
+		Object:  object,
+		Type:    objectType,
+		Name:    name,
+		Tagger:  tagger,
+		Message: message,
+	}, nil
+}
+
+func (t *Tag) Content() ([]byte, error) {
+	content := strings.Join([]string{
+		"object " + t.Object,
+		"type " + t.Type,
+		"tag " + t.Name,
+		"tagger " + t.Tagger.String(),
+		"",
+	}, "\n")
+
+	content += "\n"
+	if t.Message != "" {
+		content += t.Message + "\n"
+	}
+
+	return []byte(fmt.Sprintf("%s %d\x00%s", TagType, len(content), content)), nil
+}
+
+// parseTag
+// Headers are separated from the message by the first empty line, unknown headers are skipped.
+func parseTag(oid string, data []byte) (*Tag, error) {
+	headers, message, _ := bytes.Cut(data, []byte("\n\n"))
+
+	t := &Tag{
+		OID:     oid,
+		Message: strings.TrimSuffix(string(message), "\n"),
+	}
+
+	for _, line := range strings.Split(string(headers), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+
+		switch key {
+		case "object":
+			t.Object = value
+		case "type":
+			t.Type = value
+		case "tag":
+			t.Name = value
+		case "tagger":
+			tagger, err := ParseAuthor(value)
+			if err != nil {
+				return nil, fmt.Errorf("parse tagger: %w", err)
+			}
+
+			t.Tagger = tagger
+		}
+	}
+
+	if t.Object == "" || t.Type == "" || t.Name == "" {
+		return nil, errors.New("tag has no object, type or name")
+	}
+
+	return t, nil
+}
@@ -0,0 +1,300 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/LukasJenicek/ggit/internal/filesystem"
+)
+
+// ErrRefLock is returned when transaction cannot lock ref or the ref does not hold expected value.
+type ErrRefLock struct {
+	Ref    string
+	Reason string
+}
+
+func (e *ErrRefLock) Error() string {
+	return fmt.Sprintf("cannot lock ref '%s': %s", e.Ref, e.Reason)
+}
+
+// RefUpdate is single change of ref within transaction.
+type RefUpdate struct {
+	Ref string
+	// New value of the ref, NullOID deletes the ref and empty value only verifies Old.
+	New string
+	// Old is expected current value, NullOID requires the ref to not exist and empty value skips the check.
+	Old string
+	// NoDeref writes symbolic ref itself instead of the ref it points to, e.g. to detach HEAD.
+	NoDeref bool
+}
+
+// RefTransaction updates several refs as a unit. All refs are locked and their old values verified
+// before anything is written, so concurrent writers cannot silently overwrite each other.
+type RefTransaction struct {
+	refs    *Refs
+	updates []*RefUpdate
+}
+
+func (r *Refs) Transaction() *RefTransaction {
+	return &RefTransaction{refs: r}
+}
+
+func (t *RefTransaction) Update(ref, newOID, oldOID string) {
+	t.updates = append(t.updates, &RefUpdate{Ref: ref, New: newOID, Old: oldOID})
+}
+
+func (t *RefTransaction) Create(ref, newOID string) {
+	t.Update(ref, newOID, NullOID)
+}
+
+func (t *RefTransaction) Delete(ref, oldOID string) {
+	t.Update(ref, NullOID, oldOID)
+}
+
+func (t *RefTransaction) Verify(ref, oldOID string) {
+	t.Update(ref, "", oldOID)
+}
+
+// UpdateNoDeref replaces symbolic ref with object id instead of moving the ref it points to.
+func (t *RefTransaction) UpdateNoDeref(ref, newOID, oldOID string) {
+	t.updates = append(t.updates, &RefUpdate{Ref: ref, New: newOID, Old: oldOID, NoDeref: true})
+}
+
+// lockedRef is ref held by transaction, target is the ref symbolic ref points to which is actually written.
+type lockedRef struct {
+	update  *RefUpdate
+	target  string
+	current string
+	// symref is content of symbolic ref replaced by NoDeref update, it is restored on rollback
+	symref string
+	lock   *filesystem.LockFile
+}
+
+// Commit locks all refs, verifies their old values and writes new ones.
+// When any write fails already written refs are restored, so either all updates are applied or none.
+// Updates are recorded in reflog with identity and message, nil identity skips the reflog.
+func (t *RefTransaction) Commit(identity *Author, message string) error {
+	locked, err := t.lock()
+	applied := false
+
+	defer func() {
+		for _, ref := range locked {
+			_ = t.refs.fileWriter.Unlock(ref.lock)
+
+			// directories of deleted refs can be removed only once their lock files are gone
+			if applied && ref.update.New == NullOID {
+				t.refs.removeEmptyDirs(filepath.Dir(filepath.Join(t.refs.gitDir, ref.target)))
+			}
+		}
+	}()
+
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range locked {
+		if err := ref.verify(); err != nil {
+			return err
+		}
+	}
+
+	head, err := t.refs.symrefTarget(HEAD, 0)
+	if err != nil {
+		return fmt.Errorf("resolve HEAD: %w", err)
+	}
+
+	for i, ref := range locked {
+		if err := t.refs.apply(ref); err != nil {
+			t.rollback(locked[:i])
+
+			return err
+		}
+	}
+
+	applied = true
+
+	for _, ref := range locked {
+		if ref.update.New == "" || ref.update.New == NullOID {
+			continue
+		}
+
+		if err := t.refs.AppendReflog(ref.target, ref.current, ref.update.New, identity, message); err != nil {
+			return err
+		}
+
+		// moving branch HEAD points to moves HEAD as well
+		if ref.target != HEAD && ref.target == head {
+			if err := t.refs.AppendReflog(HEAD, ref.current, ref.update.New, identity, message); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (t *RefTransaction) lock() ([]*lockedRef, error) {
+	locked := make([]*lockedRef, 0, len(t.updates))
+	seen := make(map[string]bool, len(t.updates))
+
+	for _, update := range t.updates {
+		if err := CheckWritableRef(update.Ref); err != nil {
+			return nil, err
+		}
+
+		target := update.Ref
+
+		if !update.NoDeref {
+			var err error
+
+			if target, err = t.refs.symrefTarget(update.Ref, 0); err != nil {
+				return nil, fmt.Errorf("resolve %s: %w", update.Ref, err)
+			}
+		}
+
+		if err := CheckWritableRef(target); err != nil {
+			return nil, err
+		}
+
+		if seen[target] {
+			return nil, &ErrRefLock{Ref: update.Ref, Reason: "multiple updates for ref not allowed"}
+		}
+
+		seen[target] = true
+
+		locked = append(locked, &lockedRef{update: update, target: target})
+	}
+
+	// lock in the same order as other transactions to not deadlock
+	sort.Slice(locked, func(i, j int) bool {
+		return locked[i].target < locked[j].target
+	})
+
+	for i, ref := range locked {
+		path := filepath.Join(t.refs.gitDir, ref.target)
+
+		if ref.update.New != "" && ref.update.New != NullOID {
+			if err := t.refs.checkNameConflict(ref.target); err != nil {
+				return locked[:i], err
+			}
+		}
+
+		if err := t.refs.mkdirAll(filepath.Dir(path)); err != nil {
+			return locked[:i], err
+		}
+
+		lock, err := t.refs.fileWriter.Lock(path)
+		if err != nil {
+			if errors.Is(err, filesystem.ErrLockAcquired) {
+				return locked[:i], &ErrRefLock{Ref: ref.update.Ref, Reason: "unable to create '" + path + ".lock': File exists"}
+			}
+
+			return locked[:i], fmt.Errorf("lock %s: %w", ref.target, err)
+		}
+
+		ref.lock = lock
+
+		// read value only once the ref is locked so nobody can change it until commit
+		ref.current, _, err = t.refs.resolveRef(ref.target, 0)
+		if err != nil {
+			return locked[:i+1], fmt.Errorf("resolve %s: %w", ref.target, err)
+		}
+
+		if ref.update.NoDeref {
+			if content, err := t.refs.fs.ReadFile(path); err == nil && strings.HasPrefix(string(content), "ref: ") {
+				ref.symref = string(content)
+			}
+		}
+	}
+
+	return locked, nil
+}
+
+func (l *lockedRef) verify() error {
+	switch {
+	case l.update.New == NullOID && l.current == "":
+		return &ErrRefLock{Ref: l.update.Ref, Reason: "unable to resolve reference '" + l.target + "'"}
+	case l.update.Old == "":
+		return nil
+	case l.update.Old == NullOID:
+		if l.current != "" {
+			return &ErrRefLock{Ref: l.update.Ref, Reason: "reference already exists"}
+		}
+	case l.current == "":
+		return &ErrRefLock{Ref: l.update.Ref, Reason: "unable to resolve reference '" + l.target + "'"}
+	case l.current != l.update.Old:
+		return &ErrRefLock{Ref: l.update.Ref, Reason: "is at " + l.current + " but expected " + l.update.Old}
+	}
+
+	return nil
+}
+
+func (r *Refs) apply(ref *lockedRef) error {
+	path := filepath.Join(r.gitDir, ref.target)
+
+	switch ref.update.New {
+	case "":
+		return nil
+	case NullOID:
+		if _, err := r.fs.Stat(path); err == nil {
+			if err := r.fileWriter.RemoveLocked(path); err != nil {
+				return fmt.Errorf("delete ref %s: %w", ref.target, err)
+			}
+		}
+
+		if _, err := r.deletePackedRef(ref.target); err != nil {
+			return fmt.Errorf("delete packed ref %s: %w", ref.target, err)
+		}
+
+		return r.deleteReflog(ref.target)
+	default:
+		if err := r.fileWriter.WriteLocked(path, []byte(ref.update.New+"\n")); err != nil {
+			return fmt.Errorf("write ref %s: %w", ref.target, err)
+		}
+
+		return nil
+	}
+}
+
+// rollback restores values of refs written before a failed write, it is best effort.
+func (t *RefTransaction) rollback(written []*lockedRef) {
+	for _, ref := range written {
+		path := filepath.Join(t.refs.gitDir, ref.target)
+
+		switch {
+		case ref.update.New == "":
+		case ref.symref != "":
+			_ = t.refs.fileWriter.WriteLocked(path, []byte(ref.symref))
+		case ref.current == "":
+			_ = t.refs.fileWriter.RemoveLocked(path)
+		default:
+			_ = t.refs.fileWriter.WriteLocked(path, []byte(ref.current+"\n"))
+		}
+	}
+}
+
+// symrefTarget follows symbolic refs and returns name of the ref which holds object id.
+func (r *Refs) symrefTarget(name string, depth int) (string, error) {
+	if depth > maxSymrefDepth {
+		return "", fmt.Errorf("too many levels of symbolic refs: %s", name)
+	}
+
+	content, err := r.fs.ReadFile(filepath.Join(r.gitDir, name))
+	if err != nil {
+		if missing(err) {
+			return name, nil
+		}
+
+		return "", fmt.Errorf("read ref %s: %w", name, err)
+	}
+
+	target, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "ref: ")
+	if !ok {
+		return name, nil
+	}
+
+	return r.symrefTarget(target, depth+1)
+}
@@ -0,0 +1,166 @@
+package database_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/filesystem"
+)
+
+func TestRefTransaction(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(976900080, 0).UTC()
+	identity := database.NewAuthor("lukas.jenicek5@gmail.com", "Lukas Jenicek", &now)
+
+	t.Run("compare and swap", func(t *testing.T) {
+		t.Parallel()
+
+		refs, fs := newRefs(t, "ref: refs/heads/master\n")
+
+		transaction := refs.Transaction()
+		transaction.Update(database.HEAD, firstOID, database.NullOID)
+		transaction.Create("refs/heads/topic", firstOID)
+		require.NoError(t, transaction.Commit(identity, "initial"))
+
+		master, err := refs.ReadRef("master")
+		require.NoError(t, err)
+		require.Equal(t, firstOID, master)
+
+		// HEAD points to master so both logs record the update
+		content, err := fs.ReadFile("tmp/.git/logs/HEAD")
+		require.NoError(t, err)
+		require.Equal(t, database.NullOID+" "+firstOID+" Lukas Jenicek <lukas.jenicek5@gmail.com> 976900080 +0000\tinitial\n", string(content))
+
+		entries, err := refs.ReadReflog("refs/heads/topic")
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+
+		// stale old value of topic rejects the whole transaction
+		transaction = refs.Transaction()
+		transaction.Update("refs/heads/master", secondOID, firstOID)
+		transaction.Update("refs/heads/topic", secondOID, secondOID)
+
+		var lockErr *database.ErrRefLock
+		require.ErrorAs(t, transaction.Commit(identity, "update"), &lockErr)
+		require.Equal(t, "cannot lock ref 'refs/heads/topic': is at "+firstOID+" but expected "+secondOID, lockErr.Error())
+
+		master, err = refs.ReadRef("master")
+		require.NoError(t, err)
+		require.Equal(t, firstOID, master)
+
+		transaction = refs.Transaction()
+		transaction.Create("refs/heads/topic", secondOID)
+		require.ErrorAs(t, transaction.Commit(identity, "create"), &lockErr)
+		require.Equal(t, "cannot lock ref 'refs/heads/topic': reference already exists", lockErr.Error())
+
+		transaction = refs.Transaction()
+		transaction.Verify("refs/heads/master", firstOID)
+		transaction.Delete("refs/heads/topic", firstOID)
+		require.NoError(t, transaction.Commit(identity, "delete"))
+
+		topic, err := refs.ReadRef("topic")
+		require.NoError(t, err)
+		require.Empty(t, topic)
+
+		transaction = refs.Transaction()
+		transaction.Delete("refs/heads/topic", "")
+		require.ErrorAs(t, transaction.Commit(identity, "delete"), &lockErr)
+		require.Equal(t, "cannot lock ref 'refs/heads/topic': unable to resolve reference 'refs/heads/topic'", lockErr.Error())
+	})
+
+	t.Run("locked ref", func(t *testing.T) {
+		t.Parallel()
+
+		// lock files are created by absolute path which in-memory fs does not support
+		gitDir := filepath.Join(t.TempDir(), ".git")
+		require.NoError(t, os.MkdirAll(filepath.Join(gitDir, "refs", "heads"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/master\n"), 0o644))
+
+		fs := filesystem.New()
+		locker := filesystem.NewFileLocker(fs)
+
+		writer, err := filesystem.NewAtomicFileWriter(fs, locker)
+		require.NoError(t, err)
+
+		refs, err := database.NewRefs(fs, gitDir, writer)
+		require.NoError(t, err)
+		require.NoError(t, refs.UpdateRef("refs/heads/master", firstOID))
+
+		lock, err := locker.Lock(filepath.Join(gitDir, "refs", "heads", "master"))
+		require.NoError(t, err)
+
+		transaction := refs.Transaction()
+		transaction.Update("refs/heads/topic", secondOID, "")
+		transaction.Update(database.HEAD, secondOID, firstOID)
+
+		var lockErr *database.ErrRefLock
+		require.ErrorAs(t, transaction.Commit(identity, "commit"), &lockErr)
+		require.Equal(t, database.HEAD, lockErr.Ref)
+
+		require.NoError(t, locker.Unlock(lock))
+
+		topic, err := refs.ReadRef("topic")
+		require.NoError(t, err)
+		require.Empty(t, topic)
+
+		// locks of the failed transaction were released
+		require.NoError(t, transaction.Commit(identity, "commit"))
+
+		master, err := refs.ReadHead()
+		require.NoError(t, err)
+		require.Equal(t, secondOID, master)
+	})
+
+	t.Run("no deref", func(t *testing.T) {
+		t.Parallel()
+
+		refs, fs := newRefs(t, "ref: refs/heads/master\n")
+
+		transaction := refs.Transaction()
+		transaction.Update(database.HEAD, firstOID, "")
+		require.NoError(t, transaction.Commit(nil, ""))
+
+		// expected value is compared with the commit HEAD resolves to
+		transaction = refs.Transaction()
+		transaction.UpdateNoDeref(database.HEAD, secondOID, firstOID)
+		require.NoError(t, transaction.Commit(identity, "checkout: moving from master to "+secondOID))
+
+		content, err := fs.ReadFile("tmp/.git/HEAD")
+		require.NoError(t, err)
+		require.Equal(t, secondOID+"\n", string(content))
+
+		master, err := refs.ReadRef("master")
+		require.NoError(t, err)
+		require.Equal(t, firstOID, master)
+
+		// only HEAD moved
+		entries, err := refs.ReadReflog(database.HEAD)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, firstOID, entries[0].OldOID)
+
+		entries, err = refs.ReadReflog("refs/heads/master")
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+
+	t.Run("duplicate ref", func(t *testing.T) {
+		t.Parallel()
+
+		refs, _ := newRefs(t, "ref: refs/heads/master\n")
+
+		transaction := refs.Transaction()
+		transaction.Update(database.HEAD, firstOID, "")
+		transaction.Update("refs/heads/master", secondOID, "")
+
+		var lockErr *database.ErrRefLock
+		require.ErrorAs(t, transaction.Commit(identity, "commit"), &lockErr)
+		require.Equal(t, "multiple updates for ref not allowed", lockErr.Reason)
+	})
+}
@@ -1,16 +1,20 @@
 package database
 
 import (
+	"bytes"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 )
 
 const (
 	regularMode    = "100644"
 	executableMode = "100755"
-	directoryMode  = "40000"
+	gitlinkMode    = "160000"
+	// DirectoryMode is the mode of subtree entries.
+	DirectoryMode = "40000"
 )
 
 type Tree struct {
@@ -39,6 +43,25 @@ func (t *Tree) AddEntry(entry Object) {
 	t.entries = append(t.entries, entry)
 }
 
+// Entries returns the direct children of the tree, each one is either *Entry or *Tree.
+// Subtrees of a tree returned by Database.Load carry only their name and oid.
+func (t *Tree) Entries() []Object {
+	return t.entries
+}
+
+func (t *Tree) Name() string {
+	return t.name
+}
+
+func (t *Tree) Mode() string {
+	return DirectoryMode
+}
+
+// OID returns the raw 20 byte object id, it is set once the tree is stored or loaded.
+func (t *Tree) OID() []byte {
+	return t.oid
+}
+
 func (t *Tree) Content() ([]byte, error) {
 	content := ""
 
@@ -49,7 +72,7 @@ func (t *Tree) Content() ([]byte, error) {
 				return nil, fmt.Errorf("tree %s has no SetOID", t.name)
 			}
 
-			content += fmt.Sprintf("%s %s\x00%s", directoryMode, tree.name, tree.oid)
+			content += fmt.Sprintf("%s %s\x00%s", DirectoryMode, tree.name, tree.oid)
 
 			continue
 		}
@@ -62,7 +85,56 @@ func (t *Tree) Content() ([]byte, error) {
 		content += string(contentPayload)
 	}
 
-	return []byte(fmt.Sprintf("tree %d\x00%s", len([]byte(content)), []byte(content))), nil
+	return []byte(fmt.Sprintf("%s %d\x00%s", TreeType, len([]byte(content)), []byte(content))), nil
+}
+
+// parseTree
+// Every record has format: {mode} {name}{null byte}{20 bytes object id}.
+func parseTree(oid string, data []byte) (*Tree, error) {
+	rawOID, err := hex.DecodeString(oid)
+	if err != nil {
+		return nil, fmt.Errorf("decode oid: %w", err)
+	}
+
+	tree := NewRootTree()
+	tree.oid = rawOID
+
+	for len(data) > 0 {
+		mode, rest, found := bytes.Cut(data, []byte(" "))
+		if !found {
+			return nil, errors.New("tree entry mode not terminated")
+		}
+
+		name, rest, found := bytes.Cut(rest, []byte{0})
+		if !found {
+			return nil, errors.New("tree entry name not terminated")
+		}
+
+		if len(rest) < 20 {
+			return nil, fmt.Errorf("tree entry %s has truncated object id", name)
+		}
+
+		entryOID := bytes.Clone(rest[:20])
+		data = rest[20:]
+
+		if string(mode) == DirectoryMode {
+			subtree := NewTree(tree, string(name))
+			subtree.oid = entryOID
+			tree.AddEntry(subtree)
+
+			continue
+		}
+
+		entry, err := NewEntry(string(name), "", entryOID, string(mode) == executableMode)
+		if err != nil {
+			return nil, fmt.Errorf("tree entry: %w", err)
+		}
+
+		entry.mode = string(mode)
+		tree.AddEntry(entry)
+	}
+
+	return tree, nil
 }
 
 func findTrees(root *Tree) []*Tree {
@@ -105,49 +177,39 @@ func findLeaves(parent *Tree) []*Tree {
 // It maintains parent-child relationships allowing traversal from leaf nodes to the root.
 // The entries must be sorted by name to ensure consistent tree building.
 func Build(root *Tree, entries []*Entry) (*Tree, error) {
-	treeCache := make(map[string]*Tree)
+	treeCache := map[string]*Tree{".": root}
 
 	for _, entry := range entries {
-		d := filepath.Dir(entry.AbsFilePath)
-		// root folder
-		if d == "." {
-			root.AddEntry(entry)
-
-			continue
+		t, err := buildParents(treeCache, filepath.Dir(entry.AbsFilePath))
+		if err != nil {
+			return nil, fmt.Errorf("build tree for %s: %w", entry.AbsFilePath, err)
 		}
 
-		t, ok := treeCache[d]
-		if !ok {
-			split := strings.Split(d, string(os.PathSeparator))
-
-			for i := range split {
-				folderParentPath := strings.Join(split[:i], string(os.PathSeparator))
-				folderPath := strings.Join(split[:i+1], string(os.PathSeparator))
-
-				if folderParentPath == "" {
-					treeCache[split[0]] = NewTree(root, split[0])
-					root.AddEntry(treeCache[split[0]])
-
-					continue
-				}
+		t.AddEntry(entry)
+	}
 
-				parent, ok := treeCache[folderParentPath]
-				if !ok {
-					return nil, fmt.Errorf("tree %s does not exist", folderParentPath)
-				}
+	return root, nil
+}
 
-				t, ok = treeCache[folderPath]
-				if !ok {
-					t = NewTree(parent, split[i])
-					treeCache[split[i]] = t
-				}
+// buildParents returns tree for given folder path, missing ancestors are created and cached
+// so every folder is added to its parent exactly once.
+func buildParents(treeCache map[string]*Tree, dir string) (*Tree, error) {
+	if t, ok := treeCache[dir]; ok {
+		return t, nil
+	}
 
-				parent.AddEntry(t)
-			}
-		}
+	if dir == "" || dir == string(os.PathSeparator) {
+		return nil, fmt.Errorf("invalid folder path %q", dir)
+	}
 
-		t.AddEntry(entry)
+	parent, err := buildParents(treeCache, filepath.Dir(dir))
+	if err != nil {
+		return nil, err
 	}
 
-	return root, nil
+	t := NewTree(parent, filepath.Base(dir))
+	parent.AddEntry(t)
+	treeCache[dir] = t
+
+	return t, nil
 }
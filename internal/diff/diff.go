@@ -0,0 +1,200 @@
+package diff
+
+import (
+	"bytes"
+	"slices"
+)
+
+type EditType byte
+
+// Edit types are prefixes of lines in unified diff.
+const (
+	Equal  EditType = ' '
+	Insert EditType = '+'
+	Delete EditType = '-'
+)
+
+// Line is a line of compared content, Text keeps the trailing newline so the last line without it differs
+// from the same line followed by newline.
+type Line struct {
+	// 1-based line number
+	Number int
+	Text   string
+}
+
+// Edit is a single step turning old content into new one. A is the old line, it is nil for insertions,
+// B is the new line, it is nil for deletions.
+type Edit struct {
+	Type EditType
+	A    *Line
+	B    *Line
+}
+
+// Text returns content of the line the edit refers to.
+func (e *Edit) Text() string {
+	if e.A != nil {
+		return e.A.Text
+	}
+
+	return e.B.Text
+}
+
+// Lines splits content into lines, the last one has no newline when the content does not end with it.
+func Lines(content []byte) []*Line {
+	var lines []*Line
+
+	for len(content) > 0 {
+		end := bytes.IndexByte(content, '\n') + 1
+		if end == 0 {
+			end = len(content)
+		}
+
+		lines = append(lines, &Line{Number: len(lines) + 1, Text: string(content[:end])})
+		content = content[end:]
+	}
+
+	return lines
+}
+
+// Diff returns the shortest edit script turning lines a into lines b found by Myers algorithm.
+// Deletions are listed before insertions of the same change.
+func Diff(a, b []*Line) []*Edit {
+	d := &differ{a: a, b: b}
+	d.compare(0, 0, len(a), len(b))
+
+	// parts of one change found by different recursive calls are joined, deletions first
+	for start := 0; start < len(d.edits); start++ {
+		end := start
+		for end < len(d.edits) && d.edits[end].Type != Equal {
+			end++
+		}
+
+		slices.SortStableFunc(d.edits[start:end], func(x, y *Edit) int {
+			return int(y.Type) - int(x.Type)
+		})
+
+		start = end
+	}
+
+	return d.edits
+}
+
+// differ runs linear space variant of Myers algorithm. Instead of recording the furthest reaching paths
+// of every step, which takes O((n+m)·D) memory, it looks for the middle snake of the shortest edit script
+// searching from both ends at once and recursively diffs the parts before and after it.
+type differ struct {
+	a, b  []*Line
+	edits []*Edit
+}
+
+// compare appends edits turning a[x1:x2] into b[y1:y2].
+func (d *differ) compare(x1, y1, x2, y2 int) {
+	// common prefix and suffix are matched directly, they do not need the search
+	for x1 < x2 && y1 < y2 && d.a[x1].Text == d.b[y1].Text {
+		d.equal(x1, y1)
+		x1, y1 = x1+1, y1+1
+	}
+
+	suffix := 0
+	for x1 < x2-suffix && y1 < y2-suffix && d.a[x2-suffix-1].Text == d.b[y2-suffix-1].Text {
+		suffix++
+	}
+
+	x2, y2 = x2-suffix, y2-suffix
+
+	switch {
+	case x1 == x2 || y1 == y2:
+		for x := x1; x < x2; x++ {
+			d.edits = append(d.edits, &Edit{Type: Delete, A: d.a[x]})
+		}
+
+		for y := y1; y < y2; y++ {
+			d.edits = append(d.edits, &Edit{Type: Insert, B: d.b[y]})
+		}
+	default:
+		startX, startY, endX, endY := d.middleSnake(x1, y1, x2, y2)
+
+		d.compare(x1, y1, startX, startY)
+
+		for x, y := startX, startY; x < endX; x, y = x+1, y+1 {
+			d.equal(x, y)
+		}
+
+		d.compare(endX, endY, x2, y2)
+	}
+
+	for i := range suffix {
+		d.equal(x2+i, y2+i)
+	}
+}
+
+func (d *differ) equal(x, y int) {
+	d.edits = append(d.edits, &Edit{Type: Equal, A: d.a[x], B: d.b[y]})
+}
+
+// middleSnake explores edit graph of a[x1:x2] and b[y1:y2] from the start and from the end by increasing
+// number of edits, for every diagonal k = x - y it keeps the furthest reaching x of both directions.
+// Once the paths overlap, the snake of the last step lies in the middle of the shortest edit script.
+// Both ranges must be non-empty and differ in their first and last lines.
+func (d *differ) middleSnake(x1, y1, x2, y2 int) (int, int, int, int) {
+	n, m := x2-x1, y2-y1
+	// diagonal of the end point, the backward search runs on diagonals reversed around it
+	delta := n - m
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+
+	// forward[k] is x of diagonal k reached from the start, backward[k] is distance from the end
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+
+	for step := 0; step <= maxD; step++ {
+		for k := -step; k <= step; k += 2 {
+			var x int
+
+			// move down from diagonal k+1 (insertion) or right from diagonal k-1 (deletion)
+			if k == -step || (k != step && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+
+			startX := x
+
+			for x < n && x-k < m && d.a[x1+x].Text == d.b[y1+x-k].Text {
+				x++
+			}
+
+			forward[offset+k] = x
+
+			// with odd delta the paths can meet only after forward step, backward one made step-1 steps
+			if delta%2 != 0 && delta-k >= -(step-1) && delta-k <= step-1 && x+backward[offset+delta-k] >= n {
+				return x1 + startX, y1 + startX - k, x1 + x, y1 + x - k
+			}
+		}
+
+		for k := -step; k <= step; k += 2 {
+			var x int
+
+			if k == -step || (k != step && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+
+			startX := x
+
+			for x < n && x-k < m && d.a[x2-x-1].Text == d.b[y2-x+k-1].Text {
+				x++
+			}
+
+			backward[offset+k] = x
+
+			if delta%2 == 0 && delta-k >= -step && delta-k <= step && x+forward[offset+delta-k] >= n {
+				return x2 - x, y2 - x + k, x2 - startX, y2 - startX + k
+			}
+		}
+	}
+
+	// unreachable, the paths always meet after at most (n+m+1)/2 steps in each direction
+	panic("diff: middle snake not found")
+}
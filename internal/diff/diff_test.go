@@ -0,0 +1,168 @@
+package diff_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/diff"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	a := diff.Lines([]byte("A\nB\nC\nA\nB\nB\nA\n"))
+	b := diff.Lines([]byte("C\nB\nA\nB\nA\nC\n"))
+
+	var script strings.Builder
+
+	for _, edit := range diff.Diff(a, b) {
+		script.WriteByte(byte(edit.Type))
+		script.WriteString(edit.Text())
+	}
+
+	// example from Myers paper, the shortest edit script has 5 edits
+	require.Equal(t, "-A\n+C\n B\n-C\n A\n B\n-B\n A\n+C\n", script.String())
+}
+
+func TestDiffLargeChange(t *testing.T) {
+	t.Parallel()
+
+	var old, updated strings.Builder
+
+	for i := range 2000 {
+		fmt.Fprintf(&old, "old %d\n", i)
+		fmt.Fprintf(&updated, "new %d\n", i)
+
+		if i%100 == 0 {
+			old.WriteString("kept\n")
+			updated.WriteString("kept\n")
+		}
+	}
+
+	edits := diff.Diff(diff.Lines([]byte(old.String())), diff.Lines([]byte(updated.String())))
+
+	counts := map[diff.EditType]int{}
+	for _, edit := range edits {
+		counts[edit.Type]++
+	}
+
+	require.Equal(t, map[diff.EditType]int{diff.Equal: 20, diff.Delete: 2000, diff.Insert: 2000}, counts)
+}
+
+func TestLines(t *testing.T) {
+	t.Parallel()
+
+	require.Empty(t, diff.Lines(nil))
+	require.Equal(t, []*diff.Line{
+		{Number: 1, Text: "one\n"},
+		{Number: 2, Text: "\n"},
+		{Number: 3, Text: "three"},
+	}, diff.Lines([]byte("one\n\nthree")))
+}
+
+func TestUnified(t *testing.T) {
+	t.Parallel()
+
+	old := "func main() {\n\tone\n\ttwo\n\tthree\n}\n\nfunc other() {\n\tfour\n\tfive\n\tsix\n\tseven\n}\n"
+
+	tests := []struct {
+		name     string
+		a, b     string
+		context  int
+		expected string
+	}{
+		{
+			name:     "no changes",
+			a:        old,
+			b:        old,
+			context:  3,
+			expected: "",
+		},
+		{
+			name:    "separate hunks with function context",
+			a:       old,
+			b:       strings.Replace(strings.Replace(old, "\tone\n", "\tuno\n", 1), "\tsix\n", "", 1),
+			context: 1,
+			expected: "@@ -1,3 +1,3 @@\n" +
+				" func main() {\n" +
+				"-\tone\n" +
+				"+\tuno\n" +
+				" \ttwo\n" +
+				"@@ -9,3 +9,2 @@ func other() {\n" +
+				" \tfive\n" +
+				"-\tsix\n" +
+				" \tseven\n",
+		},
+		{
+			name:    "close changes are merged",
+			a:       old,
+			b:       strings.Replace(strings.Replace(old, "\ttwo\n", "\tdos\n", 1), "\tfour\n", "\tcuatro\n", 1),
+			context: 2,
+			expected: "@@ -1,10 +1,10 @@\n" +
+				" func main() {\n" +
+				" \tone\n" +
+				"-\ttwo\n" +
+				"+\tdos\n" +
+				" \tthree\n" +
+				" }\n" +
+				" \n" +
+				" func other() {\n" +
+				"-\tfour\n" +
+				"+\tcuatro\n" +
+				" \tfive\n" +
+				" \tsix\n",
+		},
+		{
+			name:    "insertion without context",
+			a:       "a\nb\n",
+			b:       "a\nnew\nb\n",
+			context: 0,
+			expected: "@@ -1,0 +2 @@ a\n" +
+				"+new\n",
+		},
+		{
+			name:    "missing newline at end of file",
+			a:       "a\nb",
+			b:       "a\nb\nc\n",
+			context: 3,
+			expected: "@@ -1,2 +1,3 @@\n" +
+				" a\n" +
+				"-b\n" +
+				"\\ No newline at end of file\n" +
+				"+b\n" +
+				"+c\n",
+		},
+		{
+			name:     "new file",
+			a:        "",
+			b:        "a\nb\n",
+			context:  3,
+			expected: "@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:     "deleted file",
+			a:        "a\n",
+			b:        "",
+			context:  3,
+			expected: "@@ -1 +0,0 @@\n-a\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.expected, diff.Unified([]byte(tt.a), []byte(tt.b), tt.context))
+		})
+	}
+}
+
+func TestBinary(t *testing.T) {
+	t.Parallel()
+
+	require.False(t, diff.Binary([]byte("text\n")))
+	require.True(t, diff.Binary([]byte("bin\x00ary")))
+}
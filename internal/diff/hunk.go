@@ -0,0 +1,167 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultContext is number of unchanged lines shown around changes.
+const DefaultContext = 3
+
+// maxFuncNameLength limits the text after hunk header, git shows at most 80 bytes.
+const maxFuncNameLength = 80
+
+// Hunk is a group of changes close to each other together with surrounding context lines.
+type Hunk struct {
+	// AStart and BStart are 1-based numbers of the first old and new line, for empty side it is the line
+	// after which the change happens.
+	AStart int
+	BStart int
+	Edits  []*Edit
+}
+
+// Hunks groups edits into hunks with given number of context lines, changes separated by at most twice
+// the context are merged into a single hunk.
+func Hunks(edits []*Edit, context int) []*Hunk {
+	context = max(context, 0)
+
+	var hunks []*Hunk
+
+	// lines of both sides consumed before the current edit
+	aPos, bPos := 0, 0
+
+	for i := 0; i < len(edits); {
+		if edits[i].Type == Equal {
+			aPos, bPos = aPos+1, bPos+1
+			i++
+
+			continue
+		}
+
+		start := max(i-context, 0)
+		hunk := &Hunk{AStart: aPos - (i - start), BStart: bPos - (i - start)}
+
+		// extend the hunk while the next change is within the context of the previous one
+		end := i
+		for j := i; j < len(edits) && j-end <= 2*context+1; j++ {
+			if edits[j].Type != Equal {
+				end = j
+			}
+		}
+
+		end = min(end+context+1, len(edits))
+		hunk.Edits = edits[start:end]
+
+		for _, edit := range edits[i:end] {
+			if edit.A != nil {
+				aPos++
+			}
+
+			if edit.B != nil {
+				bPos++
+			}
+		}
+
+		if hunk.count(Delete) > 0 {
+			hunk.AStart++
+		}
+
+		if hunk.count(Insert) > 0 {
+			hunk.BStart++
+		}
+
+		hunks = append(hunks, hunk)
+		i = end
+	}
+
+	return hunks
+}
+
+// count returns number of lines of the old side for Delete and of the new side for Insert.
+func (h *Hunk) count(side EditType) int {
+	count := 0
+
+	for _, edit := range h.Edits {
+		if (side == Delete && edit.A != nil) || (side == Insert && edit.B != nil) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Header returns "@@ -a,count +b,count @@" line, count 1 is omitted.
+func (h *Hunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.AStart, h.count(Delete)), hunkRange(h.BStart, h.count(Insert)))
+}
+
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return strconv.Itoa(start)
+	}
+
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// Unified returns hunks of unified diff between old content a and new content b.
+func Unified(a, b []byte, context int) string {
+	aLines := Lines(a)
+
+	var out strings.Builder
+
+	for _, hunk := range Hunks(Diff(aLines, Lines(b)), context) {
+		out.WriteString(hunk.Header())
+
+		if name := funcName(aLines, hunk.aOffset()); name != "" {
+			out.WriteString(" " + name)
+		}
+
+		out.WriteString("\n")
+
+		for _, edit := range hunk.Edits {
+			out.WriteByte(byte(edit.Type))
+			out.WriteString(edit.Text())
+
+			if !strings.HasSuffix(edit.Text(), "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+
+	return out.String()
+}
+
+// aOffset returns 0-based index of the first old line of the hunk, or of the line following the change
+// when the hunk has no old lines.
+func (h *Hunk) aOffset() int {
+	if h.count(Delete) == 0 {
+		return h.AStart
+	}
+
+	return h.AStart - 1
+}
+
+// funcName finds the closest line before offset which starts with a letter, underscore or dollar sign,
+// it usually is the declaration of enclosing function.
+func funcName(lines []*Line, offset int) string {
+	for i := min(offset, len(lines)) - 1; i >= 0; i-- {
+		text := strings.TrimRight(lines[i].Text, "\r\n")
+		if text == "" {
+			continue
+		}
+
+		c := text[0]
+		if c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+			return text[:min(len(text), maxFuncNameLength)]
+		}
+	}
+
+	return ""
+}
+
+// Binary reports whether content looks binary, git checks for NUL in the first 8000 bytes.
+func Binary(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), 8000)], 0) != -1
+}
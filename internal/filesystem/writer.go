@@ -27,8 +27,6 @@ func NewAtomicFileWriter(fs Fs, locker Locker) (*AtomicFileWriter, error) {
 }
 
 func (a *AtomicFileWriter) Write(path string, content []byte) error {
-	tmpFilePath := path + ".tmp"
-
 	lock, err := a.locker.Lock(path)
 	if err != nil {
 		return fmt.Errorf("lock index: %w", err)
@@ -38,6 +36,13 @@ func (a *AtomicFileWriter) Write(path string, content []byte) error {
 		err = a.locker.Unlock(lock)
 	}()
 
+	return a.WriteLocked(path, content)
+}
+
+// WriteLocked replaces content of path through temporary file, the caller must hold the lock of path.
+func (a *AtomicFileWriter) WriteLocked(path string, content []byte) error {
+	tmpFilePath := path + ".tmp"
+
 	f, err := a.fs.OpenFile(tmpFilePath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
 	if err != nil {
 		return fmt.Errorf("open tmp file %q: %w", tmpFilePath, err)
@@ -58,3 +63,44 @@ func (a *AtomicFileWriter) Write(path string, content []byte) error {
 
 	return nil
 }
+
+// Remove deletes file at path while holding its lock so it does not race with Write.
+func (a *AtomicFileWriter) Remove(path string) error {
+	lock, err := a.locker.Lock(path)
+	if err != nil {
+		return fmt.Errorf("lock %s: %w", path, err)
+	}
+
+	defer func() {
+		err = a.locker.Unlock(lock)
+	}()
+
+	return a.RemoveLocked(path)
+}
+
+// RemoveLocked deletes file at path, the caller must hold the lock of path.
+func (a *AtomicFileWriter) RemoveLocked(path string) error {
+	if err := a.fs.Remove(path); err != nil {
+		return fmt.Errorf("remove %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Lock acquires lock of path for callers which keep several files locked at once, e.g. ref transactions.
+func (a *AtomicFileWriter) Lock(path string) (*LockFile, error) {
+	lock, err := a.locker.Lock(path)
+	if err != nil {
+		return nil, fmt.Errorf("lock %s: %w", path, err)
+	}
+
+	return lock, nil
+}
+
+func (a *AtomicFileWriter) Unlock(lock *LockFile) error {
+	if err := a.locker.Unlock(lock); err != nil {
+		return fmt.Errorf("unlock %s: %w", lock.Path, err)
+	}
+
+	return nil
+}
@@ -131,6 +131,57 @@ type Entry struct {
 	Path  []byte
 }
 
+// ModeFromFileInfo returns mode stored in the index for a workspace file.
+func ModeFromFileInfo(fInfo os.FileInfo) uint32 {
+	if fInfo.Mode().Perm()&0o100 != 0 {
+		return executableMode
+	}
+
+	return regularMode
+}
+
+// Stage returns merge stage stored in bits 12-13 of flags, 0 for regular entries.
+func (e *Entry) Stage() int {
+	return int(e.Flags>>12) & 0x3
+}
+
+// Executable reports whether the entry was staged with executable permissions.
+func (e *Entry) Executable() bool {
+	return e.Mode == executableMode
+}
+
+// StatMatch reports whether size and mode of the workspace file equal the entry, otherwise the file has changed
+// without need to compare its content.
+func (e *Entry) StatMatch(fInfo os.FileInfo) bool {
+	return e.Mode == ModeFromFileInfo(fInfo) && (e.FileSize == 0 || e.FileSize == fileSize(fInfo))
+}
+
+// TimesMatch reports whether the workspace file was not touched since it was added to the index,
+// when it was its content needs to be hashed to find out whether it changed.
+//
+//nolint:gosec
+func (e *Entry) TimesMatch(fInfo os.FileInfo) bool {
+	stat, ok := fInfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false
+	}
+
+	return e.Ctime == uint32(stat.Ctim.Sec) && e.CtimeNsec == uint32(stat.Ctim.Nsec) &&
+		e.Mtime == uint32(stat.Mtim.Sec) && e.MtimeNsec == uint32(stat.Mtim.Nsec) &&
+		e.Inode == uint32(stat.Ino)
+}
+
+// fileSize prefers size from stat data which is also stored in the index.
+//
+//nolint:gosec
+func fileSize(fInfo os.FileInfo) uint32 {
+	if stat, ok := fInfo.Sys().(*syscall.Stat_t); ok {
+		return uint32(stat.Size)
+	}
+
+	return uint32(fInfo.Size())
+}
+
 func (e *Entry) Content() ([]byte, error) {
 	buf := new(bytes.Buffer)
 
@@ -206,10 +257,7 @@ func NewEntry(pathname string, fInfo os.FileInfo, oid []byte) (*Entry, error) {
 		return nil, errors.New("not a syscall.Stat_t type")
 	}
 
-	mode := regularMode
-	if fInfo.Mode().Perm()&0o100 != 0 {
-		mode = executableMode
-	}
+	mode := ModeFromFileInfo(fInfo)
 
 	flags := len(pathname)
 	if flags > maxPathSize {
@@ -223,7 +271,7 @@ func NewEntry(pathname string, fInfo os.FileInfo, oid []byte) (*Entry, error) {
 		MtimeNsec: uint32(stat.Mtim.Nsec),
 		Dev:       uint32(stat.Dev),
 		Inode:     uint32(stat.Ino),
-		Mode:      uint32(mode),
+		Mode:      mode,
 		UID:       stat.Uid,
 		GID:       stat.Gid,
 		FileSize:  uint32(stat.Size),
@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/LukasJenicek/ggit/internal/database"
@@ -33,12 +34,15 @@ type Indexer struct {
 type Index struct {
 	Entries *Entries
 	Parents *Parents
+	// Conflicts holds entries of unmerged paths sorted by their stage, such paths are not in Entries.
+	Conflicts map[string][]*Entry
 }
 
 func NewIndex() *Index {
 	return &Index{
-		Entries: NewEntries(),
-		Parents: NewParents(),
+		Entries:   NewEntries(),
+		Parents:   NewParents(),
+		Conflicts: make(map[string][]*Entry),
 	}
 }
 
@@ -47,6 +51,10 @@ func (i *Index) Tracked(path string) bool {
 		return true
 	}
 
+	if _, ok := i.Conflicts[path]; ok {
+		return true
+	}
+
 	if _, ok := i.Parents.Get(path); ok {
 		return true
 	}
@@ -127,9 +135,11 @@ func (i *Indexer) Add(files []string) error {
 		}
 
 		index.Entries.Add(relFilePath, indexEntry)
+		// adding the file marks conflict as resolved
+		delete(index.Conflicts, relFilePath)
 	}
 
-	indexContent, err := i.content.Generate(index.Entries.SortedValues())
+	indexContent, err := i.content.Generate(index.sortedEntries())
 	if err != nil {
 		return fmt.Errorf("index content: %w", err)
 	}
@@ -185,7 +195,8 @@ func (i *Indexer) Load() (*Index, error) {
 
 	currPosition := 12
 	for range entryLen {
-		pathLen := binary.BigEndian.Uint16(content[currPosition+60 : currPosition+62])
+		// flags hold merge stage in upper bits and path length in the lower 12 bits
+		pathLen := binary.BigEndian.Uint16(content[currPosition+60:currPosition+62]) & maxPathSize
 		cursorPos := currPosition + 62
 
 		if pathLen < maxPathSize {
@@ -220,16 +231,14 @@ func (i *Indexer) Load() (*Index, error) {
 			index.Parents.Add(p, entry)
 		}
 
-		index.Entries.Add(string(entry.Path), entry)
-
-		cursorPos++
-		// finding last null byte of entry
-		for cursorPos > 0 && content[cursorPos-1] == 0 {
-			cursorPos++
+		if entry.Stage() > 0 {
+			index.Conflicts[string(entry.Path)] = append(index.Conflicts[string(entry.Path)], entry)
+		} else {
+			index.Entries.Add(string(entry.Path), entry)
 		}
 
-		cursorPos--
-		currPosition = cursorPos
+		// path is followed by 1-8 NUL bytes padding the entry to a multiple of 8 bytes
+		currPosition += (62 + int(pathLen) + 8) &^ 7
 	}
 
 	return index, nil
@@ -275,3 +284,22 @@ func (i *Indexer) createIndexFile() error {
 
 	return nil
 }
+
+// sortedEntries returns entries together with unresolved conflicts in the order they are stored in index file.
+func (i *Index) sortedEntries() []*Entry {
+	entries := i.Entries.SortedValues()
+
+	for _, conflict := range i.Conflicts {
+		entries = append(entries, conflict...)
+	}
+
+	sort.SliceStable(entries, func(a, b int) bool {
+		if string(entries[a].Path) != string(entries[b].Path) {
+			return string(entries[a].Path) < string(entries[b].Path)
+		}
+
+		return entries[a].Stage() < entries[b].Stage()
+	})
+
+	return entries
+}
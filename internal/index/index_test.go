@@ -1,6 +1,7 @@
 package index_test
 
 import (
+	"crypto/sha1"
 	"os"
 	"syscall"
 	"testing"
@@ -173,6 +174,82 @@ func TestReplacesDirectoryWithFile(t *testing.T) {
 	require.EqualValues(t, []string{"hello.txt", "world.txt"}, entriesNames)
 }
 
+func TestLoadConflicts(t *testing.T) {
+	t.Parallel()
+
+	rootDir := "tmp/test"
+
+	// zero ctime makes the entry start with NUL bytes, padding must not swallow them
+	entries := []*index.Entry{
+		{Mode: 0o100644, OID: make([]byte, 20), Flags: 5, Path: []byte("a.txt")},
+		{Ctime: 1739287401, Mode: 0o100644, OID: make([]byte, 20), Flags: 1<<12 | 5, Path: []byte("b.txt")},
+		{Ctime: 1739287401, Mode: 0o100644, OID: make([]byte, 20), Flags: 2<<12 | 5, Path: []byte("b.txt")},
+		{Ctime: 1739287401, Mode: 0o100644, OID: make([]byte, 20), Flags: 3<<12 | 5, Path: []byte("b.txt")},
+		{Mode: 0o100644, OID: make([]byte, 20), Flags: 1<<12 | 5, Path: []byte("c.txt")},
+		{Mode: 0o100644, OID: make([]byte, 20), Flags: 2<<12 | 5, Path: []byte("c.txt")},
+	}
+
+	content := []byte{'D', 'I', 'R', 'C', 0, 0, 0, 2, 0, 0, 0, byte(len(entries))}
+
+	for _, entry := range entries {
+		data, err := entry.Content()
+		require.NoError(t, err)
+
+		content = append(content, data...)
+	}
+
+	checksum := sha1.Sum(content)
+
+	mapFS := fstest.MapFS{
+		"tmp/test": &fstest.MapFile{
+			Mode: os.ModeDir,
+			Sys:  defaultStat(uint32(os.ModeDir), 0),
+		},
+		"tmp/test/b.txt": &fstest.MapFile{
+			Data: []byte("b"),
+			Mode: 0o644,
+			Sys:  defaultStat(uint32(0o644), 1),
+		},
+		"tmp/test/.git/index": &fstest.MapFile{Data: append(content, checksum[:]...)},
+	}
+
+	fs := memory.New(mapFS)
+	locker := filesystem.NewFileLocker(fs)
+	fileWriter, err := filesystem.NewAtomicFileWriter(fs, locker)
+	require.NoError(t, err)
+
+	db, err := database.New(fs, rootDir)
+	require.NoError(t, err)
+
+	indexer, err := index.NewIndexer(fs, fileWriter, locker, db, rootDir)
+	require.NoError(t, err)
+
+	idx, err := indexer.Load()
+	require.NoError(t, err)
+
+	require.Equal(t, 1, idx.Entries.Len())
+	require.Len(t, idx.Conflicts["b.txt"], 3)
+	require.Len(t, idx.Conflicts["c.txt"], 2)
+	require.Equal(t, 3, idx.Conflicts["b.txt"][2].Stage())
+	require.True(t, idx.Tracked("c.txt"))
+
+	// added file resolves its conflict, the other one is kept
+	require.NoError(t, indexer.Add([]string{"b.txt"}))
+
+	idx, err = indexer.Load()
+	require.NoError(t, err)
+
+	entriesNames := make([]string, idx.Entries.Len())
+	for i, entry := range idx.Entries.SortedValues() {
+		entriesNames[i] = string(entry.Path)
+	}
+
+	require.Equal(t, []string{"a.txt", "b.txt"}, entriesNames)
+	require.NotContains(t, idx.Conflicts, "b.txt")
+	require.Len(t, idx.Conflicts["c.txt"], 2)
+	require.Equal(t, 2, idx.Conflicts["c.txt"][1].Stage())
+}
+
 func defaultStat(mode uint32, size int64) *syscall.Stat_t {
 	return &syscall.Stat_t{
 		Dev:     66306,
@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/index"
+)
+
+var ErrNothingToCommit = errors.New("no changes added to commit")
+
+type ChangeType byte
+
+// Change types use the letters of the short status format.
+const (
+	ChangeAdded    ChangeType = 'A'
+	ChangeModified ChangeType = 'M'
+	ChangeDeleted  ChangeType = 'D'
+)
+
+// TreeFile is a blob of a tree with the path relative to the tree root.
+type TreeFile struct {
+	Path string
+	OID  string
+	Mode string
+}
+
+// Change describes how a file differs between two snapshots, old values are empty for added files
+// and new values are empty for deleted ones.
+type Change struct {
+	Path    string
+	Type    ChangeType
+	OldOID  string
+	OldMode string
+	NewOID  string
+	NewMode string
+}
+
+// TreeFiles flattens the tree into files keyed by their path.
+func (repo *Repository) TreeFiles(treeOID string) (map[string]*TreeFile, error) {
+	files := make(map[string]*TreeFile)
+
+	if err := repo.collectTreeFiles(files, treeOID, ""); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+func (repo *Repository) collectTreeFiles(files map[string]*TreeFile, treeOID, dir string) error {
+	obj, err := repo.Database.Load(treeOID)
+	if err != nil {
+		return fmt.Errorf("load tree %s: %w", treeOID, err)
+	}
+
+	tree, ok := obj.(*database.Tree)
+	if !ok {
+		return fmt.Errorf("object %s is not a tree", treeOID)
+	}
+
+	for _, object := range tree.Entries() {
+		switch entry := object.(type) {
+		case *database.Tree:
+			if err := repo.collectTreeFiles(files, hex.EncodeToString(entry.OID()), dir+entry.Name()+"/"); err != nil {
+				return err
+			}
+		case *database.Entry:
+			files[dir+entry.Name] = &TreeFile{
+				Path: dir + entry.Name,
+				OID:  hex.EncodeToString(entry.OID),
+				Mode: entry.Mode(),
+			}
+		}
+	}
+
+	return nil
+}
+
+// TreeDiff compares two trees recursively and returns changed files sorted by path. Subtrees with the same oid
+// are not loaded at all. Empty oid stands for an empty tree.
+func (repo *Repository) TreeDiff(oldTreeOID, newTreeOID string) ([]*Change, error) {
+	var changes []*Change
+
+	if err := repo.compareTrees(&changes, oldTreeOID, newTreeOID, ""); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+
+	return changes, nil
+}
+
+func (repo *Repository) compareTrees(changes *[]*Change, oldTreeOID, newTreeOID, dir string) error {
+	if oldTreeOID == newTreeOID {
+		return nil
+	}
+
+	oldEntries, err := repo.treeEntries(oldTreeOID, dir)
+	if err != nil {
+		return err
+	}
+
+	newEntries, err := repo.treeEntries(newTreeOID, dir)
+	if err != nil {
+		return err
+	}
+
+	for path, oldEntry := range oldEntries {
+		if err := repo.compareEntries(changes, path, oldEntry, newEntries[path]); err != nil {
+			return err
+		}
+	}
+
+	for path, newEntry := range newEntries {
+		if _, ok := oldEntries[path]; !ok {
+			if err := repo.compareEntries(changes, path, nil, newEntry); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// compareEntries compares entries of the path, nil entry does not exist in its tree. A tree replaced by a file
+// shows as deleted files of the tree and the added file and vice versa.
+func (repo *Repository) compareEntries(changes *[]*Change, path string, oldEntry, newEntry *TreeFile) error {
+	oldTreeOID, newTreeOID := "", ""
+
+	if oldEntry != nil && oldEntry.Mode == database.DirectoryMode {
+		oldTreeOID, oldEntry = oldEntry.OID, nil
+	}
+
+	if newEntry != nil && newEntry.Mode == database.DirectoryMode {
+		newTreeOID, newEntry = newEntry.OID, nil
+	}
+
+	if oldTreeOID != "" || newTreeOID != "" {
+		if err := repo.compareTrees(changes, oldTreeOID, newTreeOID, path+"/"); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case oldEntry == nil && newEntry == nil:
+	case oldEntry == nil:
+		*changes = append(*changes, &Change{Path: path, Type: ChangeAdded, NewOID: newEntry.OID, NewMode: newEntry.Mode})
+	case newEntry == nil:
+		*changes = append(*changes, &Change{Path: path, Type: ChangeDeleted, OldOID: oldEntry.OID, OldMode: oldEntry.Mode})
+	case oldEntry.OID != newEntry.OID || oldEntry.Mode != newEntry.Mode:
+		*changes = append(*changes, &Change{
+			Path:    path,
+			Type:    ChangeModified,
+			OldOID:  oldEntry.OID,
+			OldMode: oldEntry.Mode,
+			NewOID:  newEntry.OID,
+			NewMode: newEntry.Mode,
+		})
+	}
+
+	return nil
+}
+
+// treeEntries returns direct children of the tree keyed by their path, subtrees have DirectoryMode.
+func (repo *Repository) treeEntries(treeOID, dir string) (map[string]*TreeFile, error) {
+	entries := make(map[string]*TreeFile)
+
+	if treeOID == "" {
+		return entries, nil
+	}
+
+	obj, err := repo.Database.Load(treeOID)
+	if err != nil {
+		return nil, fmt.Errorf("load tree %s: %w", treeOID, err)
+	}
+
+	tree, ok := obj.(*database.Tree)
+	if !ok {
+		return nil, fmt.Errorf("object %s is not a tree", treeOID)
+	}
+
+	for _, object := range tree.Entries() {
+		switch entry := object.(type) {
+		case *database.Tree:
+			entries[dir+entry.Name()] = &TreeFile{Path: dir + entry.Name(), OID: hex.EncodeToString(entry.OID()), Mode: entry.Mode()}
+		case *database.Entry:
+			entries[dir+entry.Name] = &TreeFile{Path: dir + entry.Name, OID: hex.EncodeToString(entry.OID), Mode: entry.Mode()}
+		}
+	}
+
+	return entries, nil
+}
+
+// HeadFiles returns files of the tree HEAD points to, there are none on an unborn branch.
+func (repo *Repository) HeadFiles() (map[string]*TreeFile, error) {
+	head, err := repo.Refs.ReadHead()
+	if err != nil {
+		return nil, fmt.Errorf("read head: %w", err)
+	}
+
+	if head == "" {
+		return map[string]*TreeFile{}, nil
+	}
+
+	commit, err := repo.Database.LoadCommit(head)
+	if err != nil {
+		return nil, fmt.Errorf("load head commit: %w", err)
+	}
+
+	return repo.TreeFiles(commit.RootOID)
+}
+
+// IndexChanges compares the HEAD tree with the index, i.e. returns changes which would be committed.
+func (repo *Repository) IndexChanges(idx *index.Index) ([]*Change, error) {
+	headFiles, err := repo.HeadFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	return CompareFiles(headFiles, IndexFiles(idx)), nil
+}
+
+// IndexFiles returns staged files in the same shape as files of a tree.
+func IndexFiles(idx *index.Index) map[string]*TreeFile {
+	indexFiles := make(map[string]*TreeFile, idx.Entries.Len())
+
+	for _, entry := range idx.Entries.SortedValues() {
+		path := string(entry.Path)
+
+		indexFiles[path] = &TreeFile{
+			Path: path,
+			OID:  hex.EncodeToString(entry.OID),
+			Mode: fmt.Sprintf("%o", entry.Mode),
+		}
+	}
+
+	return indexFiles
+}
+
+// CompareFiles returns changes turning old files into new ones sorted by path.
+func CompareFiles(oldFiles, newFiles map[string]*TreeFile) []*Change {
+	var changes []*Change
+
+	for path, oldFile := range oldFiles {
+		newFile, ok := newFiles[path]
+
+		switch {
+		case !ok:
+			changes = append(changes, &Change{Path: path, Type: ChangeDeleted, OldOID: oldFile.OID, OldMode: oldFile.Mode})
+		case oldFile.OID != newFile.OID || oldFile.Mode != newFile.Mode:
+			changes = append(changes, &Change{
+				Path:    path,
+				Type:    ChangeModified,
+				OldOID:  oldFile.OID,
+				OldMode: oldFile.Mode,
+				NewOID:  newFile.OID,
+				NewMode: newFile.Mode,
+			})
+		}
+	}
+
+	for path, newFile := range newFiles {
+		if _, ok := oldFiles[path]; !ok {
+			changes = append(changes, &Change{Path: path, Type: ChangeAdded, NewOID: newFile.OID, NewMode: newFile.Mode})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+
+	return changes
+}
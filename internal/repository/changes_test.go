@@ -0,0 +1,128 @@
+package repository_test
+
+import (
+	"encoding/hex"
+	"os"
+	"syscall"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/clock"
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/filesystem/memory"
+	"github.com/LukasJenicek/ggit/internal/repository"
+)
+
+func TestCompareFiles(t *testing.T) {
+	t.Parallel()
+
+	oldFiles := map[string]*repository.TreeFile{
+		"same.txt":    {Path: "same.txt", OID: "aa", Mode: "100644"},
+		"edited.txt":  {Path: "edited.txt", OID: "bb", Mode: "100644"},
+		"script.sh":   {Path: "script.sh", OID: "cc", Mode: "100644"},
+		"dir/old.txt": {Path: "dir/old.txt", OID: "dd", Mode: "100644"},
+	}
+
+	newFiles := map[string]*repository.TreeFile{
+		"same.txt":    {Path: "same.txt", OID: "aa", Mode: "100644"},
+		"edited.txt":  {Path: "edited.txt", OID: "ee", Mode: "100644"},
+		"script.sh":   {Path: "script.sh", OID: "cc", Mode: "100755"},
+		"dir/new.txt": {Path: "dir/new.txt", OID: "ff", Mode: "100644"},
+	}
+
+	require.Equal(t, []*repository.Change{
+		{Path: "dir/new.txt", Type: repository.ChangeAdded, NewOID: "ff", NewMode: "100644"},
+		{Path: "dir/old.txt", Type: repository.ChangeDeleted, OldOID: "dd", OldMode: "100644"},
+		{Path: "edited.txt", Type: repository.ChangeModified, OldOID: "bb", OldMode: "100644", NewOID: "ee", NewMode: "100644"},
+		{Path: "script.sh", Type: repository.ChangeModified, OldOID: "cc", OldMode: "100644", NewOID: "cc", NewMode: "100755"},
+	}, repository.CompareFiles(oldFiles, newFiles))
+
+	require.Empty(t, repository.CompareFiles(oldFiles, oldFiles))
+}
+
+func TestTreeDiff(t *testing.T) {
+	t.Parallel()
+
+	file := func(content string, mode os.FileMode) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(content), Mode: mode, Sys: &syscall.Stat_t{
+			Ino:  26874043,
+			Mode: uint32(mode.Perm()),
+			Size: int64(len(content)),
+			Mtim: syscall.Timespec{Sec: 1739287401, Nsec: 888108884},
+			Ctim: syscall.Timespec{Sec: 1739287401, Nsec: 888108884},
+		}}
+	}
+
+	fs := fstest.MapFS{
+		"tmp/test/":                {Mode: os.ModeDir},
+		"tmp/test/same.txt":        file("same", 0o644),
+		"tmp/test/script.sh":       file("run", 0o644),
+		"tmp/test/thing":           file("thing", 0o644),
+		"tmp/test/lib/a.txt":       file("a", 0o644),
+		"tmp/test/lib/deep/d.txt":  file("d", 0o644),
+		"tmp/test/old/o.txt":       file("o", 0o644),
+		"tmp/test/old/inner/i.txt": file("i", 0o644),
+	}
+
+	repo, err := repository.New(memory.New(fs), clock.NewFakeClock(time.Date(2000, 12, 15, 17, 8, 0o0, 0, time.UTC)), "tmp/test")
+	require.NoError(t, err)
+	require.NoError(t, repo.Init())
+	require.NoError(t, repo.Add([]string{"."}))
+
+	first, err := repo.Commit("first", repository.CommitOptions{})
+	require.NoError(t, err)
+
+	delete(fs, "tmp/test/old/o.txt")
+	delete(fs, "tmp/test/old/inner/i.txt")
+	delete(fs, "tmp/test/thing")
+	delete(fs, "tmp/test/.git/index")
+
+	fs["tmp/test/thing/in.txt"] = file("in", 0o644)
+	fs["tmp/test/script.sh"] = file("run", 0o755)
+	fs["tmp/test/lib/a.txt"] = file("a!", 0o644)
+	fs["tmp/test/lib/deep/new.txt"] = file("new", 0o644)
+
+	require.NoError(t, repo.Add([]string{"."}))
+
+	second, err := repo.Commit("second", repository.CommitOptions{})
+	require.NoError(t, err)
+
+	oid := func(content string) string {
+		hash, err := database.Hash(database.NewBlob([]byte(content)))
+		require.NoError(t, err)
+
+		return hex.EncodeToString(hash)
+	}
+
+	changes, err := repo.TreeDiff(first.RootOID, second.RootOID)
+	require.NoError(t, err)
+	require.Equal(t, []*repository.Change{
+		{Path: "lib/a.txt", Type: repository.ChangeModified, OldOID: oid("a"), OldMode: "100644", NewOID: oid("a!"), NewMode: "100644"},
+		{Path: "lib/deep/new.txt", Type: repository.ChangeAdded, NewOID: oid("new"), NewMode: "100644"},
+		{Path: "old/inner/i.txt", Type: repository.ChangeDeleted, OldOID: oid("i"), OldMode: "100644"},
+		{Path: "old/o.txt", Type: repository.ChangeDeleted, OldOID: oid("o"), OldMode: "100644"},
+		{Path: "script.sh", Type: repository.ChangeModified, OldOID: oid("run"), OldMode: "100644", NewOID: oid("run"), NewMode: "100755"},
+		{Path: "thing", Type: repository.ChangeDeleted, OldOID: oid("thing"), OldMode: "100644"},
+		{Path: "thing/in.txt", Type: repository.ChangeAdded, NewOID: oid("in"), NewMode: "100644"},
+	}, changes)
+
+	// tree diff matches comparison of flattened trees
+	firstFiles, err := repo.TreeFiles(first.RootOID)
+	require.NoError(t, err)
+
+	secondFiles, err := repo.TreeFiles(second.RootOID)
+	require.NoError(t, err)
+
+	require.Equal(t, repository.CompareFiles(firstFiles, secondFiles), changes)
+
+	changes, err = repo.TreeDiff(second.RootOID, second.RootOID)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	changes, err = repo.TreeDiff("", first.RootOID)
+	require.NoError(t, err)
+	require.Len(t, changes, 7)
+}
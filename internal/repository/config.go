@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/LukasJenicek/ggit/internal/config"
+	"github.com/LukasJenicek/ggit/internal/database"
+)
+
+// LocalConfig parses .git/config of the repository, missing file means nothing is configured.
+// Subsections are keyed like `branch "main"`.
+func (repo *Repository) LocalConfig() (map[string]map[string]any, error) {
+	content, err := repo.FS.ReadFile(filepath.Join(repo.GitPath, "config"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return map[string]map[string]any{}, nil
+		}
+
+		return nil, fmt.Errorf("read repository config: %w", err)
+	}
+
+	values, err := config.ParseIniConfig(content)
+	if err != nil {
+		return nil, fmt.Errorf("parse repository config: %w", err)
+	}
+
+	return values, nil
+}
+
+// Upstream returns the ref the branch merges from according to branch.<name>.remote and branch.<name>.merge,
+// empty string means the branch has no upstream.
+func (repo *Repository) Upstream(ref string) (string, error) {
+	branch, ok := strings.CutPrefix(ref, database.HeadsDir+"/")
+	if !ok {
+		return "", nil
+	}
+
+	values, err := repo.LocalConfig()
+	if err != nil {
+		return "", err
+	}
+
+	section := values[`branch "`+branch+`"`]
+	remote, _ := section["remote"].(string)
+	merge, _ := section["merge"].(string)
+
+	if remote == "" || merge == "" {
+		return "", nil
+	}
+
+	if remote == "." {
+		return merge, nil
+	}
+
+	// merge ref is mapped to remote tracking branch by fetch refspec of the remote, e.g. refs/heads/*:refs/remotes/origin/*
+	fetch, _ := values[`remote "`+remote+`"`]["fetch"].(string)
+
+	src, dst, ok := strings.Cut(strings.TrimPrefix(fetch, "+"), ":")
+	if !ok {
+		return "", nil
+	}
+
+	srcPrefix, srcGlob := strings.CutSuffix(src, "*")
+	dstPrefix, dstGlob := strings.CutSuffix(dst, "*")
+
+	switch {
+	case srcGlob && dstGlob && strings.HasPrefix(merge, srcPrefix):
+		return dstPrefix + strings.TrimPrefix(merge, srcPrefix), nil
+	case !srcGlob && !dstGlob && merge == src:
+		return dst, nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+)
+
+var (
+	ErrInvalidIdentity = errors.New("identity is not 'Name <email>'")
+	ErrUnknownIdentity = errors.New("author identity unknown, set user.name and user.email in git config")
+)
+
+// Signature is identity requested by the user, e.g. via --author or GIT_AUTHOR_* variables.
+// Empty fields fall back to the git config and the repository clock.
+type Signature struct {
+	Name  string
+	Email string
+	// Date in any format accepted by database.ParseDate.
+	Date string
+}
+
+type CommitOptions struct {
+	Author    Signature
+	Committer Signature
+	// AllowEmpty permits a commit with the same tree as its parent.
+	AllowEmpty bool
+}
+
+// ParseIdentity parses "Name <email>" as given to --author.
+func ParseIdentity(value string) (Signature, error) {
+	emailStart := strings.Index(value, "<")
+	emailEnd := strings.LastIndex(value, ">")
+
+	if emailStart == -1 || emailEnd < emailStart || strings.TrimSpace(value[emailEnd+1:]) != "" {
+		return Signature{}, fmt.Errorf("%w: %s", ErrInvalidIdentity, value)
+	}
+
+	name := strings.TrimSpace(value[:emailStart])
+	if name == "" {
+		return Signature{}, fmt.Errorf("%w: %s", ErrInvalidIdentity, value)
+	}
+
+	return Signature{
+		Name:  name,
+		Email: strings.TrimSpace(value[emailStart+1 : emailEnd]),
+	}, nil
+}
+
+// Committer resolves identity recorded in reflog when refs are moved by commands other than commit.
+func (repo *Repository) Committer(signature Signature) (*database.Author, error) {
+	return repo.identity(signature, repo.Clock.Now())
+}
+
+// identity resolves signature to commit identity.
+// Missing date is taken from now so author and committer created by one commit share the same time.
+func (repo *Repository) identity(signature Signature, now time.Time) (*database.Author, error) {
+	name := signature.Name
+	email := signature.Email
+
+	if repo.GitConfig != nil && repo.GitConfig.User != nil {
+		if name == "" {
+			name = repo.GitConfig.User.Name
+		}
+
+		if email == "" {
+			email = repo.GitConfig.User.Email
+		}
+	}
+
+	if name == "" || email == "" {
+		return nil, ErrUnknownIdentity
+	}
+
+	date := now
+
+	if signature.Date != "" {
+		parsed, err := database.ParseDate(signature.Date)
+		if err != nil {
+			return nil, err
+		}
+
+		date = parsed
+	}
+
+	return database.NewAuthor(email, name, &date), nil
+}
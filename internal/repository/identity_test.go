@@ -0,0 +1,22 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/repository"
+)
+
+func TestParseIdentity(t *testing.T) {
+	t.Parallel()
+
+	signature, err := repository.ParseIdentity("Jane Doe <jane@example.com>")
+	require.NoError(t, err)
+	require.Equal(t, repository.Signature{Name: "Jane Doe", Email: "jane@example.com"}, signature)
+
+	for _, invalid := range []string{"Jane Doe", "<jane@example.com>", "Jane <jane@example.com> extra"} {
+		_, err = repository.ParseIdentity(invalid)
+		require.ErrorIs(t, err, repository.ErrInvalidIdentity, invalid)
+	}
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+)
+
+var ErrNoCommits = errors.New("current branch does not have any commits yet")
+
+// Log walks the history starting from given commits and returns reachable commits
+// ordered by committer date, newest first. Limit <= 0 means no limit.
+func (repo *Repository) Log(start []string, limit int) ([]*database.Commit, error) {
+	visited := make(map[string]bool)
+	queue := make([]*database.Commit, 0, len(start))
+
+	for _, oid := range start {
+		if visited[oid] {
+			continue
+		}
+
+		commit, err := repo.Database.LoadCommit(oid)
+		if err != nil {
+			return nil, fmt.Errorf("load commit %s: %w", oid, err)
+		}
+
+		visited[oid] = true
+		queue = append(queue, commit)
+	}
+
+	var commits []*database.Commit
+
+	for len(queue) > 0 && (limit <= 0 || len(commits) < limit) {
+		// pick the most recent commit, the queue holds only the frontier of the walk so it stays small
+		newest := 0
+		for i, c := range queue {
+			if c.Committer.Now.After(*queue[newest].Committer.Now) {
+				newest = i
+			}
+		}
+
+		commit := queue[newest]
+		queue = append(queue[:newest], queue[newest+1:]...)
+		commits = append(commits, commit)
+
+		for _, parent := range commit.Parents {
+			if visited[parent] {
+				continue
+			}
+
+			visited[parent] = true
+
+			p, err := repo.Database.LoadCommit(parent)
+			if err != nil {
+				return nil, fmt.Errorf("load parent %s: %w", parent, err)
+			}
+
+			queue = append(queue, p)
+		}
+	}
+
+	return commits, nil
+}
+
+// IsAncestor reports whether ancestor is reachable from descendant by following parents.
+func (repo *Repository) IsAncestor(ancestor, descendant string) (bool, error) {
+	if ancestor == "" || descendant == "" {
+		return false, nil
+	}
+
+	visited := map[string]bool{descendant: true}
+	queue := []string{descendant}
+
+	for len(queue) > 0 {
+		oid := queue[0]
+		queue = queue[1:]
+
+		if oid == ancestor {
+			return true, nil
+		}
+
+		commit, err := repo.Database.LoadCommit(oid)
+		if err != nil {
+			return false, fmt.Errorf("load commit %s: %w", oid, err)
+		}
+
+		for _, parent := range commit.Parents {
+			if !visited[parent] {
+				visited[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// AheadBehind counts commits reachable only from local and commits reachable only from upstream.
+func (repo *Repository) AheadBehind(local, upstream string) (int, int, error) {
+	ours, err := repo.Log([]string{local}, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	theirs, err := repo.Log([]string{upstream}, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	reachable := make(map[string]bool, len(theirs))
+	for _, commit := range theirs {
+		reachable[commit.OID] = true
+	}
+
+	ahead := 0
+
+	for _, commit := range ours {
+		if reachable[commit.OID] {
+			delete(reachable, commit.OID)
+
+			continue
+		}
+
+		ahead++
+	}
+
+	// commits left are reachable only from upstream
+	return ahead, len(reachable), nil
+}
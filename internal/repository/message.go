@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CleanupMode controls how commit message is cleaned before commit is created.
+type CleanupMode string
+
+const (
+	// CleanupDefault behaves as CleanupStrip when message was edited, otherwise as CleanupWhitespace.
+	CleanupDefault CleanupMode = "default"
+	// CleanupStrip removes comment lines on top of CleanupWhitespace.
+	CleanupStrip CleanupMode = "strip"
+	// CleanupWhitespace strips trailing whitespace and collapses consecutive empty lines.
+	CleanupWhitespace CleanupMode = "whitespace"
+	// CleanupVerbatim keeps the message untouched.
+	CleanupVerbatim CleanupMode = "verbatim"
+	// CleanupScissors behaves as CleanupWhitespace but drops everything below the scissors line.
+	CleanupScissors CleanupMode = "scissors"
+)
+
+const (
+	commentChar  = "#"
+	scissorsLine = "# ------------------------ >8 ------------------------"
+)
+
+func ParseCleanupMode(mode string) (CleanupMode, error) {
+	switch CleanupMode(mode) {
+	case CleanupDefault, CleanupStrip, CleanupWhitespace, CleanupVerbatim, CleanupScissors:
+		return CleanupMode(mode), nil
+	}
+
+	return "", fmt.Errorf("invalid cleanup mode %s", mode)
+}
+
+// CleanupMessage
+// Applies cleanup mode to the commit message the same way as git stripspace does.
+// The returned message has no trailing new line, it is added when commit is serialized.
+func CleanupMessage(message string, mode CleanupMode, edited bool) string {
+	if mode == CleanupDefault {
+		mode = CleanupWhitespace
+		if edited {
+			mode = CleanupStrip
+		}
+	}
+
+	if mode == CleanupVerbatim {
+		return strings.TrimSuffix(message, "\n")
+	}
+
+	lines := strings.Split(message, "\n")
+	cleaned := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		if mode == CleanupScissors && edited && line == scissorsLine {
+			break
+		}
+
+		if mode == CleanupStrip && strings.HasPrefix(line, commentChar) {
+			continue
+		}
+
+		line = strings.TrimRight(line, " \t\r")
+
+		// collapse consecutive empty lines and drop the leading ones
+		if line == "" && (len(cleaned) == 0 || cleaned[len(cleaned)-1] == "") {
+			continue
+		}
+
+		cleaned = append(cleaned, line)
+	}
+
+	return strings.TrimRight(strings.Join(cleaned, "\n"), "\n")
+}
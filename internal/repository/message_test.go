@@ -0,0 +1,87 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/repository"
+)
+
+func TestCleanupMessage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		message  string
+		mode     repository.CleanupMode
+		edited   bool
+		expected string
+	}{
+		{
+			name:     "default keeps comments when message was not edited",
+			message:  "\n\nsubject  \n\n\n# not a comment\n",
+			mode:     repository.CleanupDefault,
+			expected: "subject\n\n# not a comment",
+		},
+		{
+			name:     "default strips comments when message was edited",
+			message:  "subject\n# Please enter the commit message\n\nbody\t\n# On branch main\n",
+			mode:     repository.CleanupDefault,
+			edited:   true,
+			expected: "subject\n\nbody",
+		},
+		{
+			name:     "strip",
+			message:  "# comment\nsubject\n",
+			mode:     repository.CleanupStrip,
+			expected: "subject",
+		},
+		{
+			name:     "whitespace",
+			message:  "subject \n\n\n\nbody\n\n",
+			mode:     repository.CleanupWhitespace,
+			edited:   true,
+			expected: "subject\n\nbody",
+		},
+		{
+			name:     "verbatim",
+			message:  "  subject \n\n\n# comment\n",
+			mode:     repository.CleanupVerbatim,
+			edited:   true,
+			expected: "  subject \n\n\n# comment",
+		},
+		{
+			name:     "scissors",
+			message:  "subject\n# comment\n# ------------------------ >8 ------------------------\ndiff\n",
+			mode:     repository.CleanupScissors,
+			edited:   true,
+			expected: "subject\n# comment",
+		},
+		{
+			name:     "only comments",
+			message:  "# comment\n\n# another\n",
+			mode:     repository.CleanupStrip,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.expected, repository.CleanupMessage(tt.message, tt.mode, tt.edited))
+		})
+	}
+}
+
+func TestParseCleanupMode(t *testing.T) {
+	t.Parallel()
+
+	mode, err := repository.ParseCleanupMode("scissors")
+	require.NoError(t, err)
+	require.Equal(t, repository.CleanupScissors, mode)
+
+	_, err = repository.ParseCleanupMode("unknown")
+	require.Error(t, err)
+}
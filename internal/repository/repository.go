@@ -6,7 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"time"
+	"strings"
 
 	"github.com/LukasJenicek/ggit/internal/clock"
 	"github.com/LukasJenicek/ggit/internal/config"
@@ -16,7 +16,11 @@ import (
 	"github.com/LukasJenicek/ggit/internal/workspace"
 )
 
-var ErrNoFilesToCommit = errors.New("nothing added to commit (use 'ggit add' to track)")
+var (
+	ErrNoFilesToCommit    = errors.New("nothing added to commit (use 'ggit add' to track)")
+	ErrEmptyCommitMessage = errors.New("aborting commit due to empty commit message")
+	ErrUnmergedFiles      = errors.New("committing is not possible because you have unmerged files")
+)
 
 // Repository
 // Cwd = Is relative folder where you run ggit commands.
@@ -158,7 +162,14 @@ func (repo *Repository) Add(paths []string) error {
 	return nil
 }
 
-func (repo *Repository) Commit() (*database.Commit, error) {
+// Commit stores the tree of staged files and a commit pointing to it, then moves HEAD.
+// Message is expected to be already cleaned, see CleanupMessage.
+// Commit time is taken from the repository clock unless overridden by options.
+func (repo *Repository) Commit(message string, options CommitOptions) (*database.Commit, error) {
+	if strings.TrimSpace(message) == "" {
+		return nil, ErrEmptyCommitMessage
+	}
+
 	idx, err := repo.Index.Load()
 	if err != nil {
 		return nil, fmt.Errorf("load index: %w", err)
@@ -169,12 +180,23 @@ func (repo *Repository) Commit() (*database.Commit, error) {
 		return nil, ErrNoFilesToCommit
 	}
 
+	if !options.AllowEmpty {
+		changes, err := repo.IndexChanges(idx)
+		if err != nil {
+			return nil, fmt.Errorf("compare index with head: %w", err)
+		}
+
+		if len(changes) == 0 {
+			return nil, ErrNothingToCommit
+		}
+	}
+
 	entries := make([]*database.Entry, 0, entriesLen)
 
 	for _, iEntry := range idx.Entries.SortedValues() {
 		filePath := string(iEntry.Path)
 
-		entry, err := database.NewEntry(filepath.Base(filePath), filePath, iEntry.OID, false)
+		entry, err := database.NewEntry(filepath.Base(filePath), filePath, iEntry.OID, iEntry.Executable())
 		if err != nil {
 			return nil, fmt.Errorf("create entry: %w", err)
 		}
@@ -192,18 +214,29 @@ func (repo *Repository) Commit() (*database.Commit, error) {
 		return nil, fmt.Errorf("store tree structure: %w", err)
 	}
 
-	now := time.Now()
-	author := database.NewAuthor(repo.GitConfig.User.Email, repo.GitConfig.User.Name, &now)
+	now := repo.Clock.Now()
 
-	// TODO: read from file or cmd argument
-	commitMessage := "all"
+	committer, err := repo.identity(options.Committer, now)
+	if err != nil {
+		return nil, fmt.Errorf("committer identity: %w", err)
+	}
+
+	author, err := repo.identity(options.Author, now)
+	if err != nil {
+		return nil, fmt.Errorf("author identity: %w", err)
+	}
 
 	parent, err := repo.Refs.ReadHead()
 	if err != nil {
 		return nil, fmt.Errorf("read head: %w", err)
 	}
 
-	c, err := database.NewCommit(parent, hex.EncodeToString(rootID), author, commitMessage)
+	var parents []string
+	if parent != "" {
+		parents = append(parents, parent)
+	}
+
+	c, err := database.NewCommit(parents, hex.EncodeToString(rootID), author, committer, message)
 	if err != nil {
 		return nil, fmt.Errorf("create commit: %w", err)
 	}
@@ -213,8 +246,25 @@ func (repo *Repository) Commit() (*database.Commit, error) {
 		return nil, fmt.Errorf("store commit: %w", err)
 	}
 
+	reflogMessage := "commit: "
+	if parent == "" {
+		reflogMessage = "commit (initial): "
+	}
+
+	subject, _, _ := strings.Cut(message, "\n")
+
+	expected := parent
+	if parent == "" {
+		expected = database.NullOID
+	}
+
 	cID := hex.EncodeToString(commitID)
-	if err = repo.Refs.UpdateHead(cID); err != nil {
+
+	// HEAD must still point to the parent, otherwise concurrent commit would be lost
+	transaction := repo.Refs.Transaction()
+	transaction.Update(database.HEAD, cID, expected)
+
+	if err = transaction.Commit(committer, reflogMessage+subject); err != nil {
 		return nil, fmt.Errorf("update head: %w", err)
 	}
 
@@ -208,9 +208,12 @@ func TestRepository_Commit(t *testing.T) {
 	err = repo.Add([]string{"hello.txt", "world.txt"})
 	require.NoError(t, err)
 
-	_, err = repo.Commit()
+	commit, err := repo.Commit("all", repository.CommitOptions{})
 	require.NoError(t, err)
 
+	require.Equal(t, "Lukas Jenicek <lukas.jenicek5@gmail.com> 1734282480 +0000", commit.Author.String())
+	require.Equal(t, commit.Author.String(), commit.Committer.String())
+
 	helloBlob := hash(t, database.NewBlob([]byte("hello")))
 	worldBlob := hash(t, database.NewBlob([]byte("world")))
 
@@ -234,6 +237,20 @@ func TestRepository_Commit(t *testing.T) {
 		_, err = fs.Open("tmp/test/.git/objects/" + file[0:2] + "/" + file[2:])
 		require.NoError(t, err)
 	}
+
+	idx, err := repo.Index.Load()
+	require.NoError(t, err)
+
+	changes, err := repo.IndexChanges(idx)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	_, err = repo.Commit("again", repository.CommitOptions{})
+	require.ErrorIs(t, err, repository.ErrNothingToCommit)
+
+	empty, err := repo.Commit("empty", repository.CommitOptions{AllowEmpty: true})
+	require.NoError(t, err)
+	require.Equal(t, commit.RootOID, empty.RootOID)
 }
 
 func hash(t *testing.T, object database.Object) []byte {
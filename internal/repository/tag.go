@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/LukasJenicek/ggit/internal/database"
+)
+
+// Tag stores annotated tag object pointing to object, the tag ref itself is not written.
+func (repo *Repository) Tag(name, object, message string, tagger Signature) (*database.Tag, error) {
+	raw, err := repo.Database.ReadObject(object)
+	if err != nil {
+		return nil, fmt.Errorf("read tagged object %s: %w", object, err)
+	}
+
+	identity, err := repo.identity(tagger, repo.Clock.Now())
+	if err != nil {
+		return nil, fmt.Errorf("tagger identity: %w", err)
+	}
+
+	tag, err := database.NewTag(object, raw.Type, name, identity, message)
+	if err != nil {
+		return nil, fmt.Errorf("create tag: %w", err)
+	}
+
+	oid, err := repo.Database.Store(tag)
+	if err != nil {
+		return nil, fmt.Errorf("store tag: %w", err)
+	}
+
+	tag.OID = hex.EncodeToString(oid)
+
+	return tag, nil
+}
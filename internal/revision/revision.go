@@ -0,0 +1,527 @@
+package revision
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/LukasJenicek/ggit/internal/clock"
+	"github.com/LukasJenicek/ggit/internal/database"
+)
+
+var (
+	// ErrInvalidRevision is returned when expression does not follow revision syntax.
+	ErrInvalidRevision = errors.New("invalid revision")
+	// ErrUnknownRevision is returned when expression is valid but does not name any object.
+	ErrUnknownRevision = errors.New("unknown revision")
+)
+
+type operationKind int
+
+const (
+	// rev~n: n-th generation ancestor following first parents.
+	ancestorOperation operationKind = iota
+	// rev^n: n-th parent.
+	parentOperation
+	// rev^{type}: peel object until it has given type.
+	peelOperation
+)
+
+type operation struct {
+	kind       operationKind
+	n          int
+	objectType string
+}
+
+// expression is parsed form of: {name}[@{selector}]{operations}[:{path}].
+type expression struct {
+	name        string
+	selector    string
+	hasSelector bool
+	ops         []operation
+	path        string
+	hasPath     bool
+	original    string
+}
+
+// Resolver turns git revision syntax into object ids.
+type Resolver struct {
+	database *database.Database
+	refs     *database.Refs
+	// clock is used by relative reflog selectors like @{yesterday}.
+	clock clock.Clock
+}
+
+func New(db *database.Database, refs *database.Refs, clock clock.Clock) (*Resolver, error) {
+	if db == nil {
+		return nil, errors.New("database is nil")
+	}
+
+	if refs == nil {
+		return nil, errors.New("refs is nil")
+	}
+
+	if clock == nil {
+		return nil, errors.New("clock is nil")
+	}
+
+	return &Resolver{
+		database: db,
+		refs:     refs,
+		clock:    clock,
+	}, nil
+}
+
+// Resolve
+// Supported syntax:
+//   - HEAD, @, branch, tag and remote names, full and abbreviated object ids
+//   - rev~n and rev^n ancestry
+//   - rev^{type} and rev^{} peeling
+//   - @{-n} n-th previously checked out branch
+//   - ref@{n} and ref@{date} values from reflog, empty ref means the current branch
+//   - rev:path lookup of an entry inside the tree of rev
+func (r *Resolver) Resolve(rev string) (string, error) {
+	expr, err := parse(rev)
+	if err != nil {
+		return "", err
+	}
+
+	oid, err := r.resolveBase(expr)
+	if err != nil {
+		return "", err
+	}
+
+	for _, op := range expr.ops {
+		oid, err = r.apply(oid, op, rev)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if !expr.hasPath {
+		return oid, nil
+	}
+
+	return r.lookupPath(oid, expr.path, rev)
+}
+
+func (r *Resolver) resolveBase(expr *expression) (string, error) {
+	if !expr.hasSelector {
+		return r.resolveName(expr.name, expr.original)
+	}
+
+	if n, ok := strings.CutPrefix(expr.selector, "-"); ok {
+		// previous checkouts have no name, negative selector of a named ref is not a date either
+		if expr.name != "" {
+			return "", fmt.Errorf("%w: %s", ErrInvalidRevision, expr.original)
+		}
+
+		nth, err := strconv.Atoi(n)
+		if err != nil || nth < 1 {
+			return "", fmt.Errorf("%w: %s", ErrInvalidRevision, expr.original)
+		}
+
+		branch, err := r.PreviousBranch(nth)
+		if err != nil {
+			return "", err
+		}
+
+		if branch == "" {
+			return "", fmt.Errorf("%w: %s: only %d checkout(s) in the reflog", ErrUnknownRevision, expr.original, nth-1)
+		}
+
+		return r.resolveName(branch, expr.original)
+	}
+
+	ref, err := r.reflogRef(expr.name, expr.original)
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := r.refs.ReadReflog(ref)
+	if err != nil {
+		return "", fmt.Errorf("read %s reflog: %w", ref, err)
+	}
+
+	if len(entries) == 0 {
+		return "", fmt.Errorf("%w: %s: log for '%s' is empty", ErrUnknownRevision, expr.original, expr.name)
+	}
+
+	if nth, err := strconv.Atoi(expr.selector); err == nil && nth >= 0 {
+		return nthReflogEntry(entries, nth, expr)
+	}
+
+	date, err := database.ParseApproxidate(expr.selector, r.clock.Now())
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %w", ErrInvalidRevision, expr.original, err)
+	}
+
+	// newest entry written before the date holds value of the ref at that time
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !entries[i].Identity.Now.After(date) {
+			return entries[i].NewOID, nil
+		}
+	}
+
+	// the date is older than the log, the oldest known value is used as git does
+	if entries[0].OldOID != database.NullOID {
+		return entries[0].OldOID, nil
+	}
+
+	return entries[0].NewOID, nil
+}
+
+// reflogRef finds ref whose log is used by selector, empty name stands for the current branch.
+func (r *Resolver) reflogRef(name, original string) (string, error) {
+	if name == "@" {
+		name = database.HEAD
+	}
+
+	if name == "" {
+		head, err := r.refs.Head()
+		if err != nil {
+			return "", fmt.Errorf("read HEAD: %w", err)
+		}
+
+		if head.Detached() {
+			return database.HEAD, nil
+		}
+
+		return head.Ref, nil
+	}
+
+	ref, err := r.refs.ExpandRef(name)
+	if err != nil {
+		return "", fmt.Errorf("expand ref %s: %w", name, err)
+	}
+
+	if ref == "" {
+		return "", fmt.Errorf("%w: %s", ErrUnknownRevision, original)
+	}
+
+	return ref, nil
+}
+
+// nthReflogEntry returns value of ref n updates ago, @{0} is the current value.
+func nthReflogEntry(entries []*database.ReflogEntry, nth int, expr *expression) (string, error) {
+	if nth < len(entries) {
+		return entries[len(entries)-1-nth].NewOID, nil
+	}
+
+	if nth == len(entries) && entries[0].OldOID != database.NullOID {
+		return entries[0].OldOID, nil
+	}
+
+	return "", fmt.Errorf("%w: %s: log for '%s' only has %d entries", ErrUnknownRevision, expr.original, expr.name, len(entries))
+}
+
+func (r *Resolver) resolveName(name, original string) (string, error) {
+	if name == "@" {
+		name = "HEAD"
+	}
+
+	oid, err := r.refs.ReadRef(name)
+	if err != nil {
+		return "", fmt.Errorf("read ref %s: %w", name, err)
+	}
+
+	if oid != "" {
+		return oid, nil
+	}
+
+	if len(name) >= database.MinPrefixLength && len(name) <= 40 {
+		oid, err := r.database.ResolvePrefix(name)
+		if err == nil {
+			return oid, nil
+		}
+
+		var ambiguous *database.ErrAmbiguousObjectID
+		if errors.As(err, &ambiguous) {
+			return "", fmt.Errorf("resolve %s: %w", original, err)
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrUnknownRevision, original)
+}
+
+// PreviousBranch returns n-th previously checked out branch, empty when there are not enough checkouts.
+// It scans "checkout: moving from X to Y" messages of HEAD reflog from the newest one.
+func (r *Resolver) PreviousBranch(nth int) (string, error) {
+	entries, err := r.refs.ReadReflog("HEAD")
+	if err != nil {
+		return "", fmt.Errorf("read HEAD reflog: %w", err)
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		rest, ok := strings.CutPrefix(entries[i].Message, "checkout: moving from ")
+		if !ok {
+			continue
+		}
+
+		nth--
+		if nth == 0 {
+			from, _, _ := strings.Cut(rest, " to ")
+
+			return from, nil
+		}
+	}
+
+	return "", nil
+}
+
+func (r *Resolver) apply(oid string, op operation, original string) (string, error) {
+	switch op.kind {
+	case ancestorOperation:
+		for range op.n {
+			commit, err := r.peelCommit(oid, original)
+			if err != nil {
+				return "", err
+			}
+
+			if commit.Parent() == "" {
+				return "", fmt.Errorf("%w: %s", ErrUnknownRevision, original)
+			}
+
+			oid = commit.Parent()
+		}
+
+		return oid, nil
+	case parentOperation:
+		commit, err := r.peelCommit(oid, original)
+		if err != nil {
+			return "", err
+		}
+
+		if op.n == 0 {
+			return commit.OID, nil
+		}
+
+		if op.n > len(commit.Parents) {
+			return "", fmt.Errorf("%w: %s", ErrUnknownRevision, original)
+		}
+
+		return commit.Parents[op.n-1], nil
+	case peelOperation:
+		return r.Peel(oid, op.objectType)
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrInvalidRevision, original)
+}
+
+// Peel dereferences object until it has required type, empty type stops at first non-tag object.
+func (r *Resolver) Peel(oid, objectType string) (string, error) {
+	for {
+		raw, err := r.database.ReadObject(oid)
+		if err != nil {
+			return "", fmt.Errorf("read object %s: %w", oid, err)
+		}
+
+		if raw.Type == objectType || (objectType == "" && raw.Type != database.TagType) {
+			return oid, nil
+		}
+
+		if raw.Type == database.TagType {
+			tag, err := r.database.LoadTag(oid)
+			if err != nil {
+				return "", fmt.Errorf("load tag %s: %w", oid, err)
+			}
+
+			oid = tag.Object
+
+			continue
+		}
+
+		if raw.Type != database.CommitType || objectType != database.TreeType {
+			return "", fmt.Errorf("%w: object %s does not peel to %s", ErrUnknownRevision, oid, objectType)
+		}
+
+		commit, err := r.database.LoadCommit(oid)
+		if err != nil {
+			return "", fmt.Errorf("load commit %s: %w", oid, err)
+		}
+
+		oid = commit.RootOID
+	}
+}
+
+func (r *Resolver) peelCommit(oid, original string) (*database.Commit, error) {
+	commitOID, err := r.Peel(oid, database.CommitType)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", original, err)
+	}
+
+	commit, err := r.database.LoadCommit(commitOID)
+	if err != nil {
+		return nil, fmt.Errorf("load commit %s: %w", commitOID, err)
+	}
+
+	return commit, nil
+}
+
+func (r *Resolver) lookupPath(oid, path, original string) (string, error) {
+	treeOID, err := r.Peel(oid, database.TreeType)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", original, err)
+	}
+
+	for _, name := range strings.Split(path, "/") {
+		if name == "" {
+			continue
+		}
+
+		obj, err := r.database.Load(treeOID)
+		if err != nil {
+			return "", fmt.Errorf("load tree %s: %w", treeOID, err)
+		}
+
+		tree, ok := obj.(*database.Tree)
+		if !ok {
+			return "", fmt.Errorf("%w: path '%s' does not exist in '%s'", ErrUnknownRevision, path, original)
+		}
+
+		treeOID = ""
+
+		for _, entry := range tree.Entries() {
+			switch e := entry.(type) {
+			case *database.Tree:
+				if e.Name() == name {
+					treeOID = hex.EncodeToString(e.OID())
+				}
+			case *database.Entry:
+				if e.Name == name {
+					treeOID = hex.EncodeToString(e.OID)
+				}
+			}
+		}
+
+		if treeOID == "" {
+			return "", fmt.Errorf("%w: path '%s' does not exist in '%s'", ErrUnknownRevision, path, original)
+		}
+	}
+
+	return treeOID, nil
+}
+
+func parse(rev string) (*expression, error) {
+	invalid := fmt.Errorf("%w: %s", ErrInvalidRevision, rev)
+
+	if rev == "" {
+		return nil, invalid
+	}
+
+	expr := &expression{original: rev}
+
+	if colon := indexOutsideBraces(rev, ':'); colon != -1 {
+		expr.path = rev[colon+1:]
+		expr.hasPath = true
+		rev = rev[:colon]
+	}
+
+	end := len(rev)
+	if i := strings.IndexAny(rev, "~^"); i != -1 {
+		end = i
+	}
+
+	if i := strings.Index(rev[:end], "@{"); i != -1 {
+		end = i
+	}
+
+	expr.name = rev[:end]
+	rest := rev[end:]
+
+	if selector, ok := strings.CutPrefix(rest, "@{"); ok {
+		closing := strings.Index(selector, "}")
+		if closing == -1 {
+			return nil, invalid
+		}
+
+		expr.selector = selector[:closing]
+		expr.hasSelector = true
+		rest = selector[closing+1:]
+	}
+
+	if expr.name == "" && !expr.hasSelector {
+		return nil, invalid
+	}
+
+	for rest != "" {
+		kind := rest[0]
+		rest = rest[1:]
+
+		if kind == '^' && strings.HasPrefix(rest, "{") {
+			closing := strings.Index(rest, "}")
+			if closing == -1 {
+				return nil, invalid
+			}
+
+			expr.ops = append(expr.ops, operation{kind: peelOperation, objectType: rest[1:closing]})
+			rest = rest[closing+1:]
+
+			continue
+		}
+
+		if kind != '^' && kind != '~' {
+			return nil, invalid
+		}
+
+		digits := 0
+		for digits < len(rest) && rest[digits] >= '0' && rest[digits] <= '9' {
+			digits++
+		}
+
+		n := 1
+
+		if digits > 0 {
+			var err error
+
+			n, err = strconv.Atoi(rest[:digits])
+			if err != nil {
+				return nil, invalid
+			}
+		}
+
+		rest = rest[digits:]
+
+		op := operation{kind: ancestorOperation, n: n}
+		if kind == '^' {
+			op.kind = parentOperation
+		}
+
+		expr.ops = append(expr.ops, op)
+	}
+
+	for _, op := range expr.ops {
+		if op.kind != peelOperation {
+			continue
+		}
+
+		switch op.objectType {
+		case "", database.BlobType, database.TreeType, database.CommitType, database.TagType:
+		default:
+			return nil, invalid
+		}
+	}
+
+	return expr, nil
+}
+
+func indexOutsideBraces(s string, c byte) int {
+	depth := 0
+
+	for i := range len(s) {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case c:
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
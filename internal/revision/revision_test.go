@@ -0,0 +1,175 @@
+package revision_test
+
+import (
+	"encoding/hex"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/LukasJenicek/ggit/internal/clock"
+	"github.com/LukasJenicek/ggit/internal/database"
+	"github.com/LukasJenicek/ggit/internal/filesystem"
+	"github.com/LukasJenicek/ggit/internal/filesystem/memory"
+	"github.com/LukasJenicek/ggit/internal/revision"
+)
+
+func TestResolver_Resolve(t *testing.T) {
+	t.Parallel()
+
+	fs := fstest.MapFS{}
+	memFS := memory.New(fs)
+
+	db, err := database.New(memFS, "tmp")
+	require.NoError(t, err)
+
+	blobID, err := db.Store(database.NewBlob([]byte("hello")))
+	require.NoError(t, err)
+
+	root := database.NewRootTree()
+	entry, err := database.NewEntry("hello.txt", "hello.txt", blobID, false)
+	require.NoError(t, err)
+	root.AddEntry(entry)
+
+	docs := database.NewTree(root, "docs")
+	entry, err = database.NewEntry("readme.md", "docs/readme.md", blobID, false)
+	require.NoError(t, err)
+	docs.AddEntry(entry)
+	root.AddEntry(docs)
+
+	treeID, err := db.StoreTree(root)
+	require.NoError(t, err)
+
+	tree := hex.EncodeToString(treeID)
+	first := storeCommit(t, db, tree, nil, "first", 1)
+	second := storeCommit(t, db, tree, []string{first}, "second", 2)
+	side := storeCommit(t, db, tree, []string{first}, "side", 3)
+	merge := storeCommit(t, db, tree, []string{second, side}, "merge", 4)
+
+	now := time.Unix(1700000005, 0)
+	annotated, err := database.NewTag(second, database.CommitType, "v2", database.NewAuthor("lukas.jenicek5@gmail.com", "Lukas Jenicek", &now), "release")
+	require.NoError(t, err)
+
+	annotatedID, err := db.Store(annotated)
+	require.NoError(t, err)
+
+	tag := hex.EncodeToString(annotatedID)
+
+	fs["tmp/.git/refs/tags/v2"] = &fstest.MapFile{Data: []byte(tag + "\n")}
+	fs["tmp/.git/HEAD"] = &fstest.MapFile{Data: []byte("ref: refs/heads/master\n")}
+	fs["tmp/.git/refs/heads/master"] = &fstest.MapFile{Data: []byte(merge + "\n")}
+	fs["tmp/.git/refs/heads/feature"] = &fstest.MapFile{Data: []byte(side + "\n")}
+	fs["tmp/.git/refs/tags/v1"] = &fstest.MapFile{Data: []byte(first + "\n")}
+	fs["tmp/.git/refs/remotes/origin/main"] = &fstest.MapFile{Data: []byte(second + "\n")}
+	fs["tmp/.git/logs/HEAD"] = &fstest.MapFile{Data: []byte(
+		first + " " + side + " Lukas Jenicek <lukas.jenicek5@gmail.com> 1700000000 +0100\tcheckout: moving from master to feature\n" +
+			side + " " + merge + " Lukas Jenicek <lukas.jenicek5@gmail.com> 1700000100 +0100\tcheckout: moving from feature to master\n",
+	)}
+	fs["tmp/.git/logs/refs/heads/master"] = &fstest.MapFile{Data: []byte(
+		database.NullOID + " " + first + " Lukas Jenicek <lukas.jenicek5@gmail.com> 1700000000 +0100\tcommit (initial): first\n" +
+			first + " " + second + " Lukas Jenicek <lukas.jenicek5@gmail.com> 1700086400 +0100\tcommit: second\n" +
+			second + " " + merge + " Lukas Jenicek <lukas.jenicek5@gmail.com> 1700172800 +0100\tcommit: merge\n",
+	)}
+
+	locker := filesystem.NewFileLocker(memFS)
+	writer, err := filesystem.NewAtomicFileWriter(memFS, locker)
+	require.NoError(t, err)
+
+	refs, err := database.NewRefs(memFS, "tmp/.git", writer)
+	require.NoError(t, err)
+
+	resolver, err := revision.New(db, refs, clock.NewFakeClock(time.Unix(1700000000+3*24*3600, 0)))
+	require.NoError(t, err)
+
+	tests := []struct {
+		rev      string
+		expected string
+		err      error
+	}{
+		{rev: "HEAD", expected: merge},
+		{rev: "@", expected: merge},
+		{rev: "master", expected: merge},
+		{rev: "refs/heads/feature", expected: side},
+		{rev: "v1", expected: first},
+		{rev: "origin/main", expected: second},
+		{rev: "v2", expected: tag},
+		{rev: "v2^{tag}", expected: tag},
+		{rev: "v2^{}", expected: second},
+		{rev: "v2^{commit}", expected: second},
+		{rev: "v2^{tree}", expected: tree},
+		{rev: "v2~1", expected: first},
+		{rev: "v2:hello.txt", expected: hex.EncodeToString(blobID)},
+		{rev: "v1^{tag}", err: revision.ErrUnknownRevision},
+		{rev: merge, expected: merge},
+		{rev: side[:8], expected: side},
+		{rev: "HEAD~", expected: second},
+		{rev: "HEAD~2", expected: first},
+		{rev: "HEAD^2", expected: side},
+		{rev: "master^2~1", expected: first},
+		{rev: "HEAD^0", expected: merge},
+		{rev: "HEAD^{commit}", expected: merge},
+		{rev: "HEAD^{tree}", expected: tree},
+		{rev: "HEAD:", expected: tree},
+		{rev: "HEAD:docs/readme.md", expected: hex.EncodeToString(blobID)},
+		{rev: "HEAD~1:hello.txt", expected: hex.EncodeToString(blobID)},
+		{rev: "@{-1}", expected: side},
+		{rev: "@{-2}", expected: merge},
+		{rev: "@{-3}", err: revision.ErrUnknownRevision},
+		{rev: "@{0}", expected: merge},
+		{rev: "@{1}", expected: second},
+		{rev: "master@{2}", expected: first},
+		{rev: "master@{1}~1", expected: first},
+		{rev: "master@{3}", err: revision.ErrUnknownRevision},
+		{rev: "HEAD@{1}", expected: side},
+		{rev: "HEAD@{2}", expected: first},
+		{rev: "master@{yesterday}", expected: merge},
+		{rev: "master@{60.hours.ago}", expected: first},
+		{rev: "master@{1.year.ago}", expected: first},
+		{rev: "feature@{0}", err: revision.ErrUnknownRevision},
+		{rev: "missing@{0}", err: revision.ErrUnknownRevision},
+		{rev: "master@{someday}", err: revision.ErrInvalidRevision},
+		{rev: "master@{-1}", err: revision.ErrInvalidRevision},
+		{rev: "HEAD@{-3}", err: revision.ErrInvalidRevision},
+		{rev: "master@{-1.day.ago}", err: revision.ErrInvalidRevision},
+		{rev: "HEAD~3", err: revision.ErrUnknownRevision},
+		{rev: "HEAD^3", err: revision.ErrUnknownRevision},
+		{rev: "HEAD:missing.txt", err: revision.ErrUnknownRevision},
+		{rev: "HEAD^{tree}^{commit}", err: revision.ErrUnknownRevision},
+		{rev: "unknown", err: revision.ErrUnknownRevision},
+		{rev: "HEAD^{foo}", err: revision.ErrInvalidRevision},
+		{rev: "HEAD@{", err: revision.ErrInvalidRevision},
+		{rev: "", err: revision.ErrInvalidRevision},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rev, func(t *testing.T) {
+			t.Parallel()
+
+			oid, err := resolver.Resolve(tt.rev)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, oid)
+		})
+	}
+}
+
+func storeCommit(t *testing.T, db *database.Database, tree string, parents []string, message string, seconds int64) string {
+	t.Helper()
+
+	now := time.Unix(1700000000+seconds, 0)
+	author := database.NewAuthor("lukas.jenicek5@gmail.com", "Lukas Jenicek", &now)
+
+	commit, err := database.NewCommit(parents, tree, author, author, message)
+	require.NoError(t, err)
+
+	oid, err := db.Store(commit)
+	require.NoError(t, err)
+
+	return hex.EncodeToString(oid)
+}
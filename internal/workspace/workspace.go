@@ -80,6 +80,16 @@ func (w Workspace) ListDir(dir string) ([]*Stat, error) {
 	return stats, nil
 }
 
+// ReadFile returns content of the file at path relative to the workspace root.
+func (w Workspace) ReadFile(path string) ([]byte, error) {
+	content, err := w.fs.ReadFile(filepath.Join(w.rootDir, path))
+	if err != nil {
+		return nil, fmt.Errorf("read file %q: %w", path, err)
+	}
+
+	return content, nil
+}
+
 // ListFiles
 // os.Walkdir: The files are walked in lexical order which makes the output deterministic.
 func (w Workspace) ListFiles() ([]string, error) {